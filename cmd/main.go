@@ -373,12 +373,11 @@ func RunRootCommand(ctx context.Context, opt Options, args []string) error {
 
 	klog.Info("Application started", "pid", os.Getpid())
 
-	var llmClient gollm.Client
+	clientOpts := []gollm.Option{gollm.WithUserAgent("kubectl-ai/" + version)}
 	if opt.SkipVerifySSL {
-		llmClient, err = gollm.NewClient(ctx, opt.ProviderID, gollm.WithSkipVerifySSL())
-	} else {
-		llmClient, err = gollm.NewClient(ctx, opt.ProviderID)
+		clientOpts = append(clientOpts, gollm.WithSkipVerifySSL())
 	}
+	llmClient, err := gollm.NewClient(ctx, opt.ProviderID, clientOpts...)
 	if err != nil {
 		return fmt.Errorf("creating llm client: %w", err)
 	}
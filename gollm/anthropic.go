@@ -0,0 +1,815 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gollm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/gollm/internal/sse"
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/api"
+	"k8s.io/klog/v2"
+)
+
+func init() {
+	if err := RegisterProvider("anthropic", anthropicFactory); err != nil {
+		klog.Fatalf("Failed to register anthropic provider: %v", err)
+	}
+}
+
+const (
+	// anthropicDefaultBaseURL is the Anthropic API, which ANTHROPIC_BASE_URL overrides.
+	anthropicDefaultBaseURL = "https://api.anthropic.com/"
+	// anthropicAPIVersion is the version of the Messages API the client speaks.
+	anthropicAPIVersion = "2023-06-01"
+	// anthropicDefaultModel is used when neither the chat nor ANTHROPIC_MODEL names a model.
+	anthropicDefaultModel = "claude-sonnet-4-20250514"
+	// anthropicMaxTokens is the maximum number of tokens generated by a response.
+	anthropicMaxTokens = 4096
+	// statusOverloaded is the status the Anthropic API responds with while it is overloaded.
+	statusOverloaded = 529
+)
+
+// anthropicFactory is the provider factory function for the Anthropic API.
+func anthropicFactory(ctx context.Context, opts ClientOptions) (Client, error) {
+	return NewAnthropicClient(ctx, opts)
+}
+
+// AnthropicClient implements the gollm.Client interface for the Anthropic Messages API.
+type AnthropicClient struct {
+	baseURL    *url.URL
+	apiKey     string
+	httpClient *http.Client
+}
+
+var _ Client = &AnthropicClient{}
+
+// NewAnthropicClient creates a client for the Anthropic API, authenticated with the
+// ANTHROPIC_API_KEY env var. ANTHROPIC_BASE_URL overrides the URL of the API.
+func NewAnthropicClient(ctx context.Context, opts ClientOptions) (*AnthropicClient, error) {
+	apiKey := os.Getenv("ANTHROPIC_API_KEY")
+	if apiKey == "" {
+		return nil, errors.New("Anthropic API key not found. Set via ANTHROPIC_API_KEY env var")
+	}
+
+	base := os.Getenv("ANTHROPIC_BASE_URL")
+	if base == "" {
+		base = anthropicDefaultBaseURL
+	}
+	baseURL, err := url.Parse(base)
+	if err != nil {
+		return nil, fmt.Errorf("parsing ANTHROPIC_BASE_URL %q: %w", base, err)
+	}
+
+	return &AnthropicClient{
+		baseURL:    baseURL,
+		apiKey:     apiKey,
+		httpClient: createCustomHTTPClient(opts),
+	}, nil
+}
+
+func (c *AnthropicClient) Close() error {
+	return nil
+}
+
+// doRequest sends a request to the API, and returns the response if it succeeded.
+// A response with an error status is returned as an *APIError.
+func (c *AnthropicClient) doRequest(ctx context.Context, method, relativePath string, query url.Values, body any) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("building json body: %w", err)
+		}
+		reader = bytes.NewReader(b)
+	}
+	u := c.baseURL.JoinPath(relativePath)
+	u.RawQuery = query.Encode()
+	httpRequest, err := http.NewRequestWithContext(ctx, method, u.String(), reader)
+	if err != nil {
+		return nil, fmt.Errorf("building http request: %w", err)
+	}
+	httpRequest.Header.Set("x-api-key", c.apiKey)
+	httpRequest.Header.Set("anthropic-version", anthropicAPIVersion)
+	if body != nil {
+		httpRequest.Header.Set("Content-Type", "application/json")
+	}
+
+	httpResponse, err := c.httpClient.Do(httpRequest)
+	if err != nil {
+		return nil, fmt.Errorf("performing http request: %w", err)
+	}
+	if httpResponse.StatusCode/100 == 2 {
+		return httpResponse, nil
+	}
+
+	defer httpResponse.Body.Close()
+	b, _ := io.ReadAll(httpResponse.Body)
+	var errorResponse struct {
+		Error anthropicErrorDetail `json:"error"`
+	}
+	message := string(b)
+	if json.Unmarshal(b, &errorResponse) == nil && errorResponse.Error.Message != "" {
+		message = errorResponse.Error.Type + ": " + errorResponse.Error.Message
+	}
+	return nil, &APIError{StatusCode: httpResponse.StatusCode, Message: message}
+}
+
+// GenerateCompletion generates a completion for the given prompt, as a single-turn chat.
+func (c *AnthropicClient) GenerateCompletion(ctx context.Context, req *CompletionRequest) (CompletionResponse, error) {
+	response, err := c.StartChat("", req.Model).Send(ctx, req.Prompt)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkTextContent(response); err != nil {
+		return nil, err
+	}
+	return &chatCompletionChunk{chatResponse: response}, nil
+}
+
+// GenerateCompletionStream streams a completion for the given prompt.
+func (c *AnthropicClient) GenerateCompletionStream(ctx context.Context, req *CompletionRequest) (CompletionResponseIterator, error) {
+	return streamCompletionViaChat(ctx, c, req)
+}
+
+// SetResponseSchema is not supported by the Anthropic API, and is ignored.
+func (c *AnthropicClient) SetResponseSchema(schema *Schema) error {
+	klog.Warning("AnthropicClient.SetResponseSchema is not supported, ignoring the response schema")
+	return nil
+}
+
+// Name returns "anthropic", the name the Anthropic provider is registered under.
+func (c *AnthropicClient) Name() string {
+	return "anthropic"
+}
+
+// Capabilities returns the features supported by the Anthropic provider.
+func (c *AnthropicClient) Capabilities() ProviderCapabilities {
+	return ProviderCapabilities{Tools: true, Streaming: true}
+}
+
+// ListModels lists the models available to the API key.
+func (c *AnthropicClient) ListModels(ctx context.Context) ([]string, error) {
+	var models []string
+	query := url.Values{"limit": {"1000"}}
+	for {
+		httpResponse, err := c.doRequest(ctx, http.MethodGet, "v1/models", query, nil)
+		if err != nil {
+			return nil, fmt.Errorf("listing Anthropic models: %w", err)
+		}
+		var page struct {
+			Data []struct {
+				ID string `json:"id"`
+			} `json:"data"`
+			HasMore bool   `json:"has_more"`
+			LastID  string `json:"last_id"`
+		}
+		err = json.NewDecoder(httpResponse.Body).Decode(&page)
+		httpResponse.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("decoding Anthropic models: %w", err)
+		}
+		for _, model := range page.Data {
+			models = append(models, model.ID)
+		}
+		if !page.HasMore || page.LastID == "" {
+			return models, nil
+		}
+		query.Set("after_id", page.LastID)
+	}
+}
+
+// HealthCheck checks that the API is reachable and accepts the API key, by listing a model.
+func (c *AnthropicClient) HealthCheck(ctx context.Context) error {
+	httpResponse, err := c.doRequest(ctx, http.MethodGet, "v1/models", url.Values{"limit": {"1"}}, nil)
+	if err != nil {
+		return fmt.Errorf("anthropic health check failed: %w", err)
+	}
+	return httpResponse.Body.Close()
+}
+
+// StartChat starts a new chat session with the specified system prompt and model.
+func (c *AnthropicClient) StartChat(systemPrompt, model string) Chat {
+	if model == "" {
+		model = os.Getenv("ANTHROPIC_MODEL")
+	}
+	if model == "" {
+		model = anthropicDefaultModel
+	}
+	klog.V(1).Infof("Starting new Anthropic chat session with model: %s", model)
+	return &anthropicChat{
+		client: c,
+		model:  model,
+		system: systemPrompt,
+	}
+}
+
+// anthropicChat is a chat with the Anthropic Messages API.
+type anthropicChat struct {
+	client              *AnthropicClient
+	model               string
+	system              string
+	messages            []anthropicMessage
+	tools               []anthropicTool
+	functionDefinitions []*FunctionDefinition
+}
+
+var _ Chat = &anthropicChat{}
+
+// SetFunctionDefinitions configures the tools available to the model, with their
+// parameters as the JSON schema of their input.
+func (c *anthropicChat) SetFunctionDefinitions(functionDefinitions []*FunctionDefinition) error {
+	c.functionDefinitions = functionDefinitions
+	c.tools = nil
+	for _, fn := range functionDefinitions {
+		inputSchema, err := convertSchemaToMap(fn.Parameters)
+		if err != nil {
+			return fmt.Errorf("converting parameters of function %q: %w", fn.Name, err)
+		}
+		// The input of a tool is always an object
+		if _, ok := inputSchema["type"]; !ok {
+			inputSchema["type"] = "object"
+		}
+		c.tools = append(c.tools, anthropicTool{
+			Name:        fn.Name,
+			Description: fn.Description,
+			InputSchema: inputSchema,
+		})
+	}
+	return nil
+}
+
+// anthropicContentBlocks converts the contents of a user message to content blocks.
+// Tool results come first, as the API requires.
+func anthropicContentBlocks(contents []any) ([]anthropicContentBlock, error) {
+	var results, others []anthropicContentBlock
+	for _, content := range contents {
+		switch v := content.(type) {
+		case string:
+			others = append(others, anthropicContentBlock{Type: "text", Text: v})
+		case FunctionCallResult:
+			block, err := anthropicToolResultBlock(v)
+			if err != nil {
+				return nil, err
+			}
+			results = append(results, block)
+		case []FunctionCallResult:
+			for _, result := range v {
+				block, err := anthropicToolResultBlock(result)
+				if err != nil {
+					return nil, err
+				}
+				results = append(results, block)
+			}
+		default:
+			return nil, fmt.Errorf("unexpected type of content: %T", content)
+		}
+	}
+	return append(results, others...), nil
+}
+
+// anthropicToolResultBlock returns the tool_result block of a function result, with the result as JSON.
+func anthropicToolResultBlock(result FunctionCallResult) (anthropicContentBlock, error) {
+	content, err := json.Marshal(result.Result)
+	if err != nil {
+		return anthropicContentBlock{}, fmt.Errorf("marshalling result of function %q: %w", result.Name, err)
+	}
+	return anthropicContentBlock{
+		Type:      "tool_result",
+		ToolUseID: result.ID,
+		Content:   string(content),
+		IsError:   result.IsError,
+	}, nil
+}
+
+// anthropicToolUseBlock returns the tool_use block of a function call.
+func anthropicToolUseBlock(call FunctionCall) (anthropicContentBlock, error) {
+	arguments := call.Arguments
+	if arguments == nil {
+		arguments = map[string]any{}
+	}
+	input, err := json.Marshal(arguments)
+	if err != nil {
+		return anthropicContentBlock{}, fmt.Errorf("marshalling arguments of function %q: %w", call.Name, err)
+	}
+	return anthropicContentBlock{Type: "tool_use", ID: call.ID, Name: call.Name, Input: input}, nil
+}
+
+// request returns the request for the conversation so far.
+func (c *anthropicChat) request(stream bool) *anthropicRequest {
+	return &anthropicRequest{
+		Model:     c.model,
+		MaxTokens: anthropicMaxTokens,
+		System:    c.system,
+		Messages:  c.messages,
+		Tools:     c.tools,
+		Stream:    stream,
+	}
+}
+
+// addUserMessage adds the contents to the conversation as a user message.
+func (c *anthropicChat) addUserMessage(contents []any) error {
+	if len(contents) == 0 {
+		return errors.New("no content provided")
+	}
+	blocks, err := anthropicContentBlocks(contents)
+	if err != nil {
+		return err
+	}
+	c.messages = append(c.messages, anthropicMessage{Role: "user", Content: blocks})
+	return nil
+}
+
+// Send sends the contents as a user message, and returns the response of the model.
+func (c *anthropicChat) Send(ctx context.Context, contents ...any) (ChatResponse, error) {
+	if override, rest := takeModelOverride(contents); override != "" {
+		c.model, contents = string(override), rest
+	}
+	if err := c.addUserMessage(contents); err != nil {
+		return nil, err
+	}
+
+	httpResponse, err := c.client.doRequest(ctx, http.MethodPost, "v1/messages", nil, c.request(false))
+	if err != nil {
+		// Drop the user message so that a retried Send does not duplicate it
+		c.messages = c.messages[:len(c.messages)-1]
+		return nil, fmt.Errorf("anthropic chat failed: %w", err)
+	}
+	defer httpResponse.Body.Close()
+
+	var response anthropicResponse
+	if err := json.NewDecoder(httpResponse.Body).Decode(&response); err != nil {
+		c.messages = c.messages[:len(c.messages)-1]
+		return nil, fmt.Errorf("decoding Anthropic response: %w", err)
+	}
+	c.messages = append(c.messages, anthropicMessage{Role: "assistant", Content: response.Content})
+
+	return &anthropicChatResponse{
+		candidate: newAnthropicCandidate(response.Content, response.StopReason),
+		usage:     anthropicUsageOf(response.Usage, c.model),
+	}, nil
+}
+
+// SendStreaming sends the contents as a user message, and streams the response of the model.
+func (c *anthropicChat) SendStreaming(ctx context.Context, contents ...any) (ChatResponseIterator, error) {
+	if override, rest := takeModelOverride(contents); override != "" {
+		c.model, contents = string(override), rest
+	}
+	if err := c.addUserMessage(contents); err != nil {
+		return nil, err
+	}
+
+	httpResponse, err := c.client.doRequest(ctx, http.MethodPost, "v1/messages", nil, c.request(true))
+	if err != nil {
+		c.messages = c.messages[:len(c.messages)-1]
+		return nil, fmt.Errorf("anthropic stream failed: %w", err)
+	}
+
+	return func(yield func(ChatResponse, error) bool) {
+		defer httpResponse.Body.Close()
+
+		// blocks are the content blocks received, by index; the input of a tool call
+		// is streamed as partial JSON, and only complete once its block stops
+		var blocks []anthropicContentBlock
+		var partialInputs []strings.Builder
+		var usage anthropicUsage
+		var stopReason string
+		defer func() { c.recordStreamedTurn(blocks) }()
+
+		for event, err := range sse.Read(httpResponse.Body) {
+			if err != nil {
+				yield(nil, fmt.Errorf("reading Anthropic stream: %w", err))
+				return
+			}
+			var data anthropicStreamEvent
+			if err := json.Unmarshal([]byte(event.Data), &data); err != nil {
+				yield(nil, fmt.Errorf("decoding Anthropic stream event %q: %w", event.Type, err))
+				return
+			}
+
+			switch data.Type {
+			case "message_start":
+				if data.Message != nil && data.Message.Usage != nil {
+					usage = *data.Message.Usage
+				}
+
+			case "content_block_start":
+				if data.ContentBlock == nil || data.Index != len(blocks) {
+					yield(nil, fmt.Errorf("unexpected Anthropic content block %d", data.Index))
+					return
+				}
+				blocks = append(blocks, *data.ContentBlock)
+				partialInputs = append(partialInputs, strings.Builder{})
+
+			case "content_block_delta":
+				if data.Delta == nil || data.Index >= len(blocks) {
+					continue
+				}
+				switch data.Delta.Type {
+				case "text_delta":
+					blocks[data.Index].Text += data.Delta.Text
+					if !yield(&anthropicChatResponse{candidate: &anthropicCandidate{text: data.Delta.Text}}, nil) {
+						return
+					}
+				case "input_json_delta":
+					partialInputs[data.Index].WriteString(data.Delta.PartialJSON)
+				}
+
+			case "content_block_stop":
+				if data.Index >= len(blocks) || blocks[data.Index].Type != "tool_use" {
+					continue
+				}
+				block := &blocks[data.Index]
+				block.Input = json.RawMessage(partialInputs[data.Index].String())
+				if len(block.Input) == 0 {
+					block.Input = json.RawMessage("{}")
+				}
+				call, err := block.functionCall()
+				if err != nil {
+					yield(nil, err)
+					return
+				}
+				if !yield(&anthropicChatResponse{candidate: &anthropicCandidate{functionCalls: []FunctionCall{call}}}, nil) {
+					return
+				}
+
+			case "message_delta":
+				if data.Delta != nil {
+					stopReason = data.Delta.StopReason
+				}
+				if data.Usage != nil {
+					usage.OutputTokens = data.Usage.OutputTokens
+				}
+
+			case "message_stop":
+				yield(&anthropicChatResponse{
+					candidate: &anthropicCandidate{stopReason: stopReason},
+					usage:     anthropicUsageOf(&usage, c.model),
+				}, nil)
+				return
+
+			case "error":
+				yield(nil, anthropicStreamError(data.Error))
+				return
+			}
+		}
+		yield(nil, errors.New("anthropic stream ended before the message was complete"))
+	}, nil
+}
+
+// recordStreamedTurn records the content blocks of a finished stream in the history, without
+// the text blocks left empty and the tool calls whose input is incomplete. If there are none,
+// the unanswered user message is dropped instead, so that the history stays sendable.
+func (c *anthropicChat) recordStreamedTurn(blocks []anthropicContentBlock) {
+	var content []anthropicContentBlock
+	for _, block := range blocks {
+		if (block.Type == "text" && block.Text != "") || (block.Type == "tool_use" && block.Input != nil) {
+			content = append(content, block)
+		}
+	}
+	if len(content) == 0 {
+		c.messages = c.messages[:len(c.messages)-1]
+		return
+	}
+	c.messages = append(c.messages, anthropicMessage{Role: "assistant", Content: content})
+}
+
+// anthropicStreamError converts an error event of a stream to an error, as an
+// *APIError with the status the same error has outside of a stream.
+func anthropicStreamError(detail *anthropicErrorDetail) error {
+	if detail == nil {
+		return errors.New("anthropic stream error")
+	}
+	status := http.StatusInternalServerError
+	switch detail.Type {
+	case "overloaded_error":
+		status = statusOverloaded
+	case "rate_limit_error":
+		status = http.StatusTooManyRequests
+	case "invalid_request_error":
+		status = http.StatusBadRequest
+	}
+	return &APIError{StatusCode: status, Message: detail.Type + ": " + detail.Message}
+}
+
+// IsRetryableError returns true for the errors that the default policy retries,
+// and while the API is overloaded.
+func (c *anthropicChat) IsRetryableError(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == statusOverloaded {
+		return true
+	}
+	return DefaultIsRetryableError(err)
+}
+
+// Validate checks the chat's function definitions without issuing a request.
+func (c *anthropicChat) Validate() error {
+	return validationResult(validateFunctionDefinitions(c.functionDefinitions))
+}
+
+// Initialize replaces the conversation with a previous one. The API requires roles
+// to alternate, so consecutive messages from the same role are merged.
+func (c *anthropicChat) Initialize(history []*api.Message) error {
+	c.messages = nil
+	for _, msg := range history {
+		role, blocks, err := messageToAnthropicBlocks(msg)
+		if err != nil {
+			klog.Warningf("skipping message %s in Anthropic chat history: %v", msg.ID, err)
+			continue
+		}
+		if len(blocks) == 0 {
+			continue
+		}
+		if n := len(c.messages); n > 0 && c.messages[n-1].Role == role {
+			c.messages[n-1].Content = append(c.messages[n-1].Content, blocks...)
+			continue
+		}
+		c.messages = append(c.messages, anthropicMessage{Role: role, Content: blocks})
+	}
+	return nil
+}
+
+// messageToAnthropicBlocks converts a message of the agent's history to the role and
+// content blocks of an Anthropic message. Messages that were not exchanged with the model have no blocks.
+func messageToAnthropicBlocks(msg *api.Message) (string, []anthropicContentBlock, error) {
+	switch msg.Type {
+	case api.MessageTypeText:
+		text, ok := msg.Payload.(string)
+		if !ok {
+			return "", nil, fmt.Errorf("unexpected payload type %T for text message", msg.Payload)
+		}
+		switch msg.Source {
+		case api.MessageSourceUser:
+			return "user", []anthropicContentBlock{{Type: "text", Text: text}}, nil
+		case api.MessageSourceModel:
+			return "assistant", []anthropicContentBlock{{Type: "text", Text: text}}, nil
+		default:
+			return "", nil, nil
+		}
+
+	case api.MessageTypeToolCallRequest:
+		calls, err := functionCallsFromPayload(msg.Payload)
+		if err != nil {
+			return "", nil, err
+		}
+		if calls == nil {
+			return "assistant", []anthropicContentBlock{{Type: "text", Text: fmt.Sprintf("%v", msg.Payload)}}, nil
+		}
+		var blocks []anthropicContentBlock
+		for _, call := range calls {
+			block, err := anthropicToolUseBlock(call)
+			if err != nil {
+				return "", nil, err
+			}
+			blocks = append(blocks, block)
+		}
+		return "assistant", blocks, nil
+
+	case api.MessageTypeToolCallResponse:
+		result, ok, err := functionCallResultFromPayload(msg.Payload)
+		if err != nil {
+			return "", nil, err
+		}
+		if !ok {
+			text, err := payloadToText(msg.Payload)
+			if err != nil {
+				return "", nil, err
+			}
+			return "user", []anthropicContentBlock{{Type: "text", Text: text}}, nil
+		}
+		block, err := anthropicToolResultBlock(result)
+		if err != nil {
+			return "", nil, err
+		}
+		return "user", []anthropicContentBlock{block}, nil
+
+	default:
+		return "", nil, nil
+	}
+}
+
+// anthropicUsageOf normalizes the token usage of a response to model.
+func anthropicUsageOf(usage *anthropicUsage, model string) *Usage {
+	if usage == nil {
+		return nil
+	}
+	return &Usage{
+		InputTokens:      usage.InputTokens,
+		OutputTokens:     usage.OutputTokens,
+		TotalTokens:      usage.InputTokens + usage.OutputTokens,
+		CacheReadTokens:  usage.CacheReadInputTokens,
+		CacheWriteTokens: usage.CacheCreationInputTokens,
+		Source:           UsageSourceAPI,
+		Provider:         "anthropic",
+		Model:            model,
+		Timestamp:        time.Now(),
+	}
+}
+
+// anthropicChatResponse is a response, or a streamed part of one, from the Anthropic API.
+type anthropicChatResponse struct {
+	candidate *anthropicCandidate
+	usage     *Usage
+}
+
+var _ ChatResponse = &anthropicChatResponse{}
+
+func (r *anthropicChatResponse) UsageMetadata() any {
+	if r.usage == nil {
+		return nil
+	}
+	return r.usage
+}
+
+func (r *anthropicChatResponse) Candidates() []Candidate {
+	return []Candidate{r.candidate}
+}
+
+func (r *anthropicChatResponse) RequiresToolCall() bool {
+	return requiresToolCall(r.Candidates())
+}
+
+// anthropicCandidate is the content of a response: its text and the functions it calls.
+type anthropicCandidate struct {
+	text          string
+	functionCalls []FunctionCall
+	stopReason    string
+}
+
+var _ Candidate = &anthropicCandidate{}
+
+// newAnthropicCandidate returns the candidate of a response with the given content.
+// A tool call whose input is not an object is passed with no arguments.
+func newAnthropicCandidate(content []anthropicContentBlock, stopReason string) *anthropicCandidate {
+	candidate := &anthropicCandidate{stopReason: stopReason}
+	for _, block := range content {
+		switch block.Type {
+		case "text":
+			candidate.text += block.Text
+		case "tool_use":
+			call, err := block.functionCall()
+			if err != nil {
+				klog.Warning(err)
+			}
+			candidate.functionCalls = append(candidate.functionCalls, call)
+		}
+	}
+	return candidate
+}
+
+func (c *anthropicCandidate) String() string {
+	return c.text
+}
+
+func (c *anthropicCandidate) Parts() []Part {
+	var parts []Part
+	if c.text != "" {
+		parts = append(parts, &anthropicPart{text: c.text})
+	}
+	if len(c.functionCalls) != 0 {
+		parts = append(parts, &anthropicPart{functionCalls: c.functionCalls})
+	}
+	return parts
+}
+
+func (c *anthropicCandidate) HasToolCalls() bool {
+	return hasToolCalls(c.Parts())
+}
+
+// FinishReason returns why the model stopped generating the candidate.
+func (c *anthropicCandidate) FinishReason() FinishReason {
+	switch c.stopReason {
+	case "":
+		return FinishReasonUnspecified
+	case "end_turn", "stop_sequence":
+		return FinishReasonStop
+	case "max_tokens":
+		return FinishReasonMaxTokens
+	case "tool_use":
+		return FinishReasonToolUse
+	case "refusal":
+		return FinishReasonContentFiltered
+	default:
+		return FinishReasonOther
+	}
+}
+
+// IsRefusal reports whether the model refused to respond or its response was filtered.
+func (c *anthropicCandidate) IsRefusal() bool {
+	return c.FinishReason() == FinishReasonContentFiltered
+}
+
+// anthropicPart is the text or the function calls of a candidate.
+type anthropicPart struct {
+	text          string
+	functionCalls []FunctionCall
+}
+
+func (p *anthropicPart) AsText() (string, bool) {
+	return p.text, p.text != ""
+}
+
+func (p *anthropicPart) AsFunctionCalls() ([]FunctionCall, bool) {
+	return p.functionCalls, len(p.functionCalls) != 0
+}
+
+type anthropicRequest struct {
+	Model     string             `json:"model"`
+	MaxTokens int                `json:"max_tokens"`
+	System    string             `json:"system,omitempty"`
+	Messages  []anthropicMessage `json:"messages"`
+	Tools     []anthropicTool    `json:"tools,omitempty"`
+	Stream    bool               `json:"stream,omitempty"`
+}
+
+type anthropicMessage struct {
+	Role    string                  `json:"role"`
+	Content []anthropicContentBlock `json:"content"`
+}
+
+// anthropicContentBlock is a text, tool_use or tool_result content block.
+type anthropicContentBlock struct {
+	Type string `json:"type"`
+	// Text is set for text blocks.
+	Text string `json:"text,omitempty"`
+	// ID, Name and Input are set for tool_use blocks.
+	ID    string          `json:"id,omitempty"`
+	Name  string          `json:"name,omitempty"`
+	Input json.RawMessage `json:"input,omitempty"`
+	// ToolUseID, Content and IsError are set for tool_result blocks.
+	ToolUseID string `json:"tool_use_id,omitempty"`
+	Content   string `json:"content,omitempty"`
+	IsError   bool   `json:"is_error,omitempty"`
+}
+
+// functionCall returns the function call of a tool_use block.
+func (b *anthropicContentBlock) functionCall() (FunctionCall, error) {
+	call := FunctionCall{ID: b.ID, Name: b.Name}
+	if err := json.Unmarshal(b.Input, &call.Arguments); err != nil {
+		return call, fmt.Errorf("parsing input of Anthropic tool call %q: %w", b.Name, err)
+	}
+	return call, nil
+}
+
+type anthropicTool struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	InputSchema map[string]any `json:"input_schema"`
+}
+
+type anthropicResponse struct {
+	ID         string                  `json:"id"`
+	Model      string                  `json:"model"`
+	Content    []anthropicContentBlock `json:"content"`
+	StopReason string                  `json:"stop_reason"`
+	Usage      *anthropicUsage         `json:"usage"`
+}
+
+type anthropicUsage struct {
+	InputTokens              int `json:"input_tokens"`
+	OutputTokens             int `json:"output_tokens"`
+	CacheCreationInputTokens int `json:"cache_creation_input_tokens"`
+	CacheReadInputTokens     int `json:"cache_read_input_tokens"`
+}
+
+type anthropicErrorDetail struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+}
+
+// anthropicStreamEvent is the data of an event of a streamed response.
+type anthropicStreamEvent struct {
+	Type         string                 `json:"type"`
+	Index        int                    `json:"index"`
+	Message      *anthropicResponse     `json:"message"`
+	ContentBlock *anthropicContentBlock `json:"content_block"`
+	Delta        *struct {
+		Type        string `json:"type"`
+		Text        string `json:"text"`
+		PartialJSON string `json:"partial_json"`
+		StopReason  string `json:"stop_reason"`
+	} `json:"delta"`
+	Usage *anthropicUsage       `json:"usage"`
+	Error *anthropicErrorDetail `json:"error"`
+}
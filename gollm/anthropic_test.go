@@ -0,0 +1,310 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gollm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// newTestAnthropicClient returns a client of the Anthropic API served by handler.
+func newTestAnthropicClient(t *testing.T, handler http.HandlerFunc) *AnthropicClient {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	t.Setenv("ANTHROPIC_API_KEY", "test-key")
+	t.Setenv("ANTHROPIC_BASE_URL", server.URL)
+	client, err := NewAnthropicClient(context.Background(), ClientOptions{})
+	if err != nil {
+		t.Fatalf("NewAnthropicClient failed: %v", err)
+	}
+	return client
+}
+
+func TestAnthropicToolCallRoundTrip(t *testing.T) {
+	responses := []string{
+		`{"content":[{"type":"text","text":"Let me check."},{"type":"tool_use","id":"toolu_1","name":"kubectl","input":{"command":"get pods"}}],
+		  "stop_reason":"tool_use","usage":{"input_tokens":10,"output_tokens":5}}`,
+		`{"content":[{"type":"text","text":"There are no pods."}],"stop_reason":"end_turn","usage":{"input_tokens":20,"output_tokens":4}}`,
+	}
+	var requests []anthropicRequest
+	client := newTestAnthropicClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/messages" || r.Header.Get("x-api-key") != "test-key" || r.Header.Get("anthropic-version") == "" {
+			t.Errorf("unexpected request %s with headers %v", r.URL.Path, r.Header)
+		}
+		var req anthropicRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("decoding request: %v", err)
+		}
+		requests = append(requests, req)
+		fmt.Fprint(w, responses[len(requests)-1])
+	})
+
+	chat := client.StartChat("You are a Kubernetes assistant.", "claude-test")
+	err := chat.SetFunctionDefinitions([]*FunctionDefinition{{
+		Name:        "kubectl",
+		Description: "Runs kubectl",
+		Parameters: &Schema{
+			Type:       TypeObject,
+			Properties: map[string]*Schema{"command": {Type: TypeString}},
+			Required:   []string{"command"},
+		},
+	}})
+	if err != nil {
+		t.Fatalf("SetFunctionDefinitions failed: %v", err)
+	}
+
+	response, err := chat.Send(context.Background(), "list the pods")
+	if err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	candidate := response.Candidates()[0]
+	if candidate.FinishReason() != FinishReasonToolUse {
+		t.Errorf("expected FinishReasonToolUse, got %v", candidate.FinishReason())
+	}
+	var calls []FunctionCall
+	for _, part := range candidate.Parts() {
+		if fc, ok := part.AsFunctionCalls(); ok {
+			calls = append(calls, fc...)
+		}
+	}
+	if len(calls) != 1 || calls[0].ID != "toolu_1" || calls[0].Arguments["command"] != "get pods" {
+		t.Fatalf("expected the kubectl tool call, got %+v", calls)
+	}
+	if usage := response.UsageMetadata().(*Usage); usage.TotalTokens != 15 || usage.Provider != "anthropic" {
+		t.Errorf("unexpected usage %+v", usage)
+	}
+
+	response, err = chat.Send(context.Background(), FunctionCallResult{ID: "toolu_1", Name: "kubectl", Result: map[string]any{"stdout": "No resources found"}})
+	if err != nil {
+		t.Fatalf("Send of the tool result failed: %v", err)
+	}
+	if got := response.Candidates()[0].String(); got != "There are no pods." {
+		t.Errorf("unexpected response %q", got)
+	}
+
+	first := requests[0]
+	if first.System != "You are a Kubernetes assistant." || first.Model != "claude-test" || first.MaxTokens == 0 {
+		t.Errorf("unexpected request %+v", first)
+	}
+	if len(first.Tools) != 1 || first.Tools[0].InputSchema["type"] != "object" || first.Tools[0].InputSchema["properties"] == nil {
+		t.Errorf("expected the kubectl tool with its input schema, got %+v", first.Tools)
+	}
+
+	// The second request replays the tool call and answers it with a tool_result
+	second := requests[1].Messages
+	if len(second) != 3 || second[1].Role != "assistant" || second[1].Content[1].Type != "tool_use" {
+		t.Fatalf("expected the tool call in the history, got %+v", second)
+	}
+	result := second[2].Content[0]
+	if second[2].Role != "user" || result.Type != "tool_result" || result.ToolUseID != "toolu_1" || !strings.Contains(result.Content, "No resources found") {
+		t.Errorf("expected the tool result, got %+v", second[2])
+	}
+}
+
+func TestAnthropicGenerateCompletionToolCallsOnly(t *testing.T) {
+	client := newTestAnthropicClient(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"content":[{"type":"tool_use","id":"toolu_1","name":"kubectl","input":{"command":"get pods"}}],
+		  "stop_reason":"tool_use","usage":{"input_tokens":10,"output_tokens":5}}`)
+	})
+
+	_, err := client.GenerateCompletion(context.Background(), &CompletionRequest{Model: "claude-test", Prompt: "list the pods"})
+	if !errors.Is(err, ErrNoTextContent) {
+		t.Errorf("expected ErrNoTextContent, got %v", err)
+	}
+}
+
+func TestAnthropicSendStreaming(t *testing.T) {
+	events := []string{
+		`event: message_start
+data: {"type":"message_start","message":{"content":[],"usage":{"input_tokens":12,"output_tokens":1}}}`,
+		`event: content_block_start
+data: {"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}`,
+		`event: ping
+data: {"type":"ping"}`,
+		`event: content_block_delta
+data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Checking "}}`,
+		`event: content_block_delta
+data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"pods."}}`,
+		`event: content_block_stop
+data: {"type":"content_block_stop","index":0}`,
+		`event: content_block_start
+data: {"type":"content_block_start","index":1,"content_block":{"type":"tool_use","id":"toolu_1","name":"kubectl","input":{}}}`,
+		`event: content_block_delta
+data: {"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"{\"command\": \"get"}}`,
+		`event: content_block_delta
+data: {"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":" pods\"}"}}`,
+		`event: content_block_stop
+data: {"type":"content_block_stop","index":1}`,
+		`event: message_delta
+data: {"type":"message_delta","delta":{"stop_reason":"tool_use"},"usage":{"output_tokens":20}}`,
+		`event: message_stop
+data: {"type":"message_stop"}`,
+	}
+	var requests []anthropicRequest
+	client := newTestAnthropicClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var req anthropicRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("decoding request: %v", err)
+		}
+		requests = append(requests, req)
+		if !req.Stream {
+			fmt.Fprint(w, `{"content":[{"type":"text","text":"There are no pods."}],"stop_reason":"end_turn"}`)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, strings.Join(events, "\n\n")+"\n\n")
+	})
+
+	chat := client.StartChat("", "claude-test")
+	stream, err := chat.SendStreaming(context.Background(), "list the pods")
+	if err != nil {
+		t.Fatalf("SendStreaming failed: %v", err)
+	}
+	var text strings.Builder
+	var calls []FunctionCall
+	var last ChatResponse
+	for response, err := range stream {
+		if err != nil {
+			t.Fatalf("stream error: %v", err)
+		}
+		for _, part := range response.Candidates()[0].Parts() {
+			if s, ok := part.AsText(); ok {
+				text.WriteString(s)
+			}
+			if fc, ok := part.AsFunctionCalls(); ok {
+				calls = append(calls, fc...)
+			}
+		}
+		last = response
+	}
+
+	if !requests[0].Stream {
+		t.Error("expected a streaming request")
+	}
+	if text.String() != "Checking pods." {
+		t.Errorf("unexpected text %q", text.String())
+	}
+	if len(calls) != 1 || calls[0].Arguments["command"] != "get pods" {
+		t.Errorf("expected the kubectl tool call, got %+v", calls)
+	}
+	if got := last.Candidates()[0].FinishReason(); got != FinishReasonToolUse {
+		t.Errorf("expected FinishReasonToolUse, got %v", got)
+	}
+	if usage := last.UsageMetadata().(*Usage); usage.InputTokens != 12 || usage.OutputTokens != 20 {
+		t.Errorf("unexpected usage %+v", usage)
+	}
+
+	// The streamed turn is in the history of the next request
+	if _, err := chat.Send(context.Background(), FunctionCallResult{ID: "toolu_1", Name: "kubectl"}); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	history := requests[1].Messages
+	if len(history) != 3 || len(history[1].Content) != 2 || string(history[1].Content[1].Input) != `{"command":"get pods"}` {
+		t.Errorf("expected the streamed text and tool call in the history, got %+v", history)
+	}
+}
+
+func TestAnthropicErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		body      string
+		stream    bool
+		retryable bool
+	}{
+		{
+			name:   "invalid request",
+			status: http.StatusBadRequest,
+			body:   `{"type":"error","error":{"type":"invalid_request_error","message":"max_tokens: field required"}}`,
+		},
+		{
+			name:      "overloaded",
+			status:    statusOverloaded,
+			body:      `{"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}`,
+			retryable: true,
+		},
+		{
+			name:      "overloaded while streaming",
+			status:    http.StatusOK,
+			body:      "event: error\ndata: {\"type\":\"error\",\"error\":{\"type\":\"overloaded_error\",\"message\":\"Overloaded\"}}\n\n",
+			stream:    true,
+			retryable: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestAnthropicClient(t, func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				fmt.Fprint(w, tt.body)
+			})
+			chat := client.StartChat("", "claude-test")
+
+			var err error
+			if tt.stream {
+				stream, streamErr := chat.SendStreaming(context.Background(), "hello")
+				if streamErr != nil {
+					t.Fatalf("SendStreaming failed: %v", streamErr)
+				}
+				for _, err = range stream {
+				}
+			} else {
+				_, err = chat.Send(context.Background(), "hello")
+			}
+
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("expected an APIError, got %v", err)
+			}
+			if got := chat.IsRetryableError(err); got != tt.retryable {
+				t.Errorf("expected retryable %v, got %v for %v", tt.retryable, got, err)
+			}
+			// The failed turn is not kept in the history
+			if n := len(chat.(*anthropicChat).messages); n != 0 {
+				t.Errorf("expected an empty history, got %d messages", n)
+			}
+		})
+	}
+}
+
+func TestAnthropicListModels(t *testing.T) {
+	pages := map[string]string{
+		"":                         `{"data":[{"id":"claude-opus-4-1-20250805"},{"id":"claude-sonnet-4-20250514"}],"has_more":true,"last_id":"claude-sonnet-4-20250514"}`,
+		"claude-sonnet-4-20250514": `{"data":[{"id":"claude-3-5-haiku-20241022"}],"has_more":false,"last_id":"claude-3-5-haiku-20241022"}`,
+	}
+	client := newTestAnthropicClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/models" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		fmt.Fprint(w, pages[r.URL.Query().Get("after_id")])
+	})
+
+	models, err := client.ListModels(context.Background())
+	if err != nil {
+		t.Fatalf("ListModels failed: %v", err)
+	}
+	want := []string{"claude-opus-4-1-20250805", "claude-sonnet-4-20250514", "claude-3-5-haiku-20241022"}
+	if !slices.Equal(models, want) {
+		t.Errorf("expected models %v, got %v", want, models)
+	}
+}
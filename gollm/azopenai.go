@@ -71,7 +71,7 @@ func NewAzureOpenAIClient(ctx context.Context, opts ClientOptions) (*AzureOpenAI
 	}
 
 	// Create a custom HTTP client (supports SkipVerifySSL)
-	httpClient := createCustomHTTPClient(opts.SkipVerifySSL)
+	httpClient := createCustomHTTPClient(opts)
 
 	azureOpenAIKey := os.Getenv("AZURE_OPENAI_API_KEY")
 	clientOpts := &azopenai.ClientOptions{
@@ -125,6 +125,21 @@ func (c *AzureOpenAIClient) GenerateCompletion(ctx context.Context, request *Com
 	return &AzureOpenAICompletionResponse{response: *resp.Choices[0].Message.Content}, nil
 }
 
+// GenerateCompletionStream streams a completion for the given prompt.
+func (c *AzureOpenAIClient) GenerateCompletionStream(ctx context.Context, req *CompletionRequest) (CompletionResponseIterator, error) {
+	return streamCompletionViaChat(ctx, c, req)
+}
+
+// Name returns "azopenai", the name the Azure OpenAI provider is registered under.
+func (c *AzureOpenAIClient) Name() string {
+	return "azopenai"
+}
+
+// Capabilities returns the features supported by the Azure OpenAI provider.
+func (c *AzureOpenAIClient) Capabilities() ProviderCapabilities {
+	return ProviderCapabilities{Tools: true}
+}
+
 func (c *AzureOpenAIClient) ListModels(ctx context.Context) ([]string, error) {
 	cred, err := azidentity.NewDefaultAzureCredential(nil)
 	if err != nil {
@@ -226,13 +241,17 @@ func (r *AzureOpenAICompletionResponse) UsageMetadata() any {
 }
 
 type AzureOpenAIChat struct {
-	client  *azopenai.Client
-	model   string
-	history []azopenai.ChatRequestMessageClassification
-	tools   []azopenai.ChatCompletionsToolDefinitionClassification
+	client              *azopenai.Client
+	model               string
+	history             []azopenai.ChatRequestMessageClassification
+	tools               []azopenai.ChatCompletionsToolDefinitionClassification
+	functionDefinitions []*FunctionDefinition
 }
 
 func (c *AzureOpenAIChat) Send(ctx context.Context, contents ...any) (ChatResponse, error) {
+	if override, rest := takeModelOverride(contents); override != "" {
+		c.model, contents = string(override), rest
+	}
 	for _, content := range contents {
 		switch v := content.(type) {
 		case string:
@@ -270,12 +289,20 @@ func (c *AzureOpenAIChat) IsRetryableError(err error) bool {
 	return false
 }
 
+// Validate checks the chat's function definitions without issuing a request.
+func (c *AzureOpenAIChat) Validate() error {
+	return validationResult(validateFunctionDefinitions(c.functionDefinitions))
+}
+
 func (c *AzureOpenAIChat) Initialize(messages []*api.Message) error {
 	klog.Warning("chat history persistence is not supported for provider 'azopenai', using in-memory chat history")
 	return nil
 }
 
 func (c *AzureOpenAIChat) SendStreaming(ctx context.Context, contents ...any) (ChatResponseIterator, error) {
+	if override, rest := takeModelOverride(contents); override != "" {
+		c.model, contents = string(override), rest
+	}
 	// TODO: Implement streaming
 	response, err := c.Send(ctx, contents...)
 	if err != nil {
@@ -313,10 +340,27 @@ func (r *AzureOpenAIChatResponse) Candidates() []Candidate {
 	return candidates
 }
 
+func (r *AzureOpenAIChatResponse) RequiresToolCall() bool {
+	return requiresToolCall(r.Candidates())
+}
+
 type AzureOpenAICandidate struct {
 	candidate azopenai.ChatChoice
 }
 
+// FinishReason returns why the model stopped generating the candidate.
+func (r *AzureOpenAICandidate) FinishReason() FinishReason {
+	if r.candidate.FinishReason == nil {
+		return FinishReasonUnspecified
+	}
+	return openAIFinishReason(string(*r.candidate.FinishReason))
+}
+
+// IsRefusal reports whether the model refused to respond or its response was filtered.
+func (r *AzureOpenAICandidate) IsRefusal() bool {
+	return r.FinishReason() == FinishReasonContentFiltered
+}
+
 func (r *AzureOpenAICandidate) String() string {
 	var response strings.Builder
 	response.WriteString("[")
@@ -362,6 +406,10 @@ func (r *AzureOpenAICandidate) Parts() []Part {
 	return parts
 }
 
+func (r *AzureOpenAICandidate) HasToolCalls() bool {
+	return hasToolCalls(r.Parts())
+}
+
 type AzureOpenAIPart struct {
 	text         *string
 	functionCall *azopenai.FunctionCall
@@ -393,6 +441,7 @@ func (p *AzureOpenAIPart) AsFunctionCalls() ([]FunctionCall, bool) {
 }
 
 func (c *AzureOpenAIChat) SetFunctionDefinitions(functionDefinitions []*FunctionDefinition) error {
+	c.functionDefinitions = functionDefinitions
 	var tools []azopenai.ChatCompletionsToolDefinitionClassification
 	for _, functionDefinition := range functionDefinitions {
 		tools = append(tools, &azopenai.ChatCompletionsFunctionToolDefinition{Function: fnDefToAzureOpenAITool(functionDefinition)})
@@ -0,0 +1,48 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gollm
+
+import (
+	"context"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// GenerateCompletionBatch generates a completion for each of reqs, with at most concurrency
+// requests in flight at once, or all of them if concurrency is not positive. Any limits
+// configured on the client, such as WithMaxConcurrentRequests, still apply.
+// The responses and errors are in the order of reqs; a request that fails has a nil response.
+func GenerateCompletionBatch(ctx context.Context, client Client, reqs []*CompletionRequest, concurrency int) ([]CompletionResponse, []error) {
+	responses := make([]CompletionResponse, len(reqs))
+	errs := make([]error, len(reqs))
+
+	var g errgroup.Group
+	if concurrency > 0 {
+		g.SetLimit(concurrency)
+	}
+	for i, req := range reqs {
+		// Requests that have not started when the context is done are not sent
+		if err := ctx.Err(); err != nil {
+			errs[i] = err
+			continue
+		}
+		g.Go(func() error {
+			responses[i], errs[i] = client.GenerateCompletion(ctx, req)
+			return nil
+		})
+	}
+	g.Wait()
+	return responses, errs
+}
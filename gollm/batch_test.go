@@ -0,0 +1,99 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gollm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// echoCompletionClient is a Client whose completions echo their prompt. Requests with
+// lower-numbered prompts take longer, so that they complete out of order.
+type echoCompletionClient struct {
+	Client
+
+	inFlight    atomic.Int32
+	maxInFlight atomic.Int32
+}
+
+func (c *echoCompletionClient) GenerateCompletion(ctx context.Context, req *CompletionRequest) (CompletionResponse, error) {
+	n := c.inFlight.Add(1)
+	defer c.inFlight.Add(-1)
+	for {
+		highest := c.maxInFlight.Load()
+		if n <= highest || c.maxInFlight.CompareAndSwap(highest, n) {
+			break
+		}
+	}
+
+	i, err := strconv.Atoi(req.Prompt)
+	if err != nil {
+		return nil, fmt.Errorf("bad prompt %q", req.Prompt)
+	}
+	time.Sleep(time.Duration(10-i%10) * time.Millisecond)
+	return &simpleCompletionResponse{content: req.Prompt}, nil
+}
+
+func TestGenerateCompletionBatch(t *testing.T) {
+	const concurrency = 4
+	client := &echoCompletionClient{}
+	var reqs []*CompletionRequest
+	for i := range 20 {
+		reqs = append(reqs, &CompletionRequest{Prompt: strconv.Itoa(i)})
+	}
+	reqs[7].Prompt = "seven"
+
+	responses, errs := GenerateCompletionBatch(context.Background(), client, reqs, concurrency)
+
+	if len(responses) != len(reqs) || len(errs) != len(reqs) {
+		t.Fatalf("expected %d responses and errors, got %d and %d", len(reqs), len(responses), len(errs))
+	}
+	for i, req := range reqs {
+		if i == 7 {
+			if errs[i] == nil || responses[i] != nil {
+				t.Errorf("request %d: expected only an error, got %v, %v", i, responses[i], errs[i])
+			}
+			continue
+		}
+		if errs[i] != nil {
+			t.Errorf("request %d: unexpected error: %v", i, errs[i])
+			continue
+		}
+		if got := responses[i].Response(); got != req.Prompt {
+			t.Errorf("request %d: expected response %q, got %q", i, req.Prompt, got)
+		}
+	}
+	if got := client.maxInFlight.Load(); got > concurrency {
+		t.Errorf("expected at most %d requests in flight, got %d", concurrency, got)
+	}
+}
+
+func TestGenerateCompletionBatchCanceled(t *testing.T) {
+	client := &echoCompletionClient{}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	responses, errs := GenerateCompletionBatch(ctx, client, []*CompletionRequest{{Prompt: "1"}, {Prompt: "2"}}, 1)
+	for i := range responses {
+		if responses[i] != nil || !errors.Is(errs[i], context.Canceled) {
+			t.Errorf("request %d: expected context.Canceled, got %v, %v", i, responses[i], errs[i])
+		}
+	}
+}
@@ -19,16 +19,33 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"log/slog"
+	"maps"
+	"net/http"
+	"net/url"
 	"os"
+	"regexp"
+	"slices"
+	"sort"
 	"strings"
+	"sync"
 	"time"
+	"unicode"
 
 	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/api"
 	"github.com/aws/aws-sdk-go-v2/aws"
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
 	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/feature/ec2/imds"
+	"github.com/aws/aws-sdk-go-v2/service/bedrock"
 	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime"
 	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime/document"
 	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime/types"
+	"github.com/aws/smithy-go"
+	"github.com/go-logr/logr"
+	"github.com/google/uuid"
 	"k8s.io/klog/v2"
 )
 
@@ -44,280 +61,2416 @@ func newBedrockClientFactory(ctx context.Context, opts ClientOptions) (Client, e
 	return NewBedrockClient(ctx, opts)
 }
 
+// BedrockOptions are options specific to the Bedrock provider.
+type BedrockOptions struct {
+	// AutoInferenceProfile prefixes bare foundation model IDs with the cross-region
+	// inference profile prefix of the configured region, for example "us." in us-east-1.
+	// Model ARNs, such as tagged application inference profiles, are always used as-is.
+	// Can also be enabled with the BEDROCK_AUTO_INFERENCE_PROFILE environment variable.
+	AutoInferenceProfile bool
+
+	// RegionFromInstanceMetadata gets the region from the EC2 instance metadata service, as on
+	// EC2 or EKS, if no region is configured. The region defaults to us-east-1 if it is unavailable.
+	RegionFromInstanceMetadata bool
+
+	// FailFastOnNoCredentials probes for AWS credentials when the client is created,
+	// returning ErrNoAWSCredentials if none are configured.
+	FailFastOnNoCredentials bool
+
+	// ConfigLoadTimeout bounds the time NewBedrockClient takes to load the AWS configuration,
+	// which can be slow where credentials or settings come from a remote source. It defaults to
+	// defaultConfigLoadTimeout, and a negative value disables it.
+	ConfigLoadTimeout time.Duration
+
+	// ModelsCacheTTL is how long ListModels results are reused before being fetched again.
+	// Defaults to defaultModelsCacheTTL.
+	ModelsCacheTTL time.Duration
+
+	// SystemPromptEnhancer rewrites the system prompt of every chat.
+	// Defaults to DefaultBedrockSystemPromptEnhancer.
+	SystemPromptEnhancer SystemPromptEnhancer
+
+	// OutputNormalizer, if set, rewrites the text of the responses of chats whose system prompt
+	// is written for the tool-use shim, such as with DefaultBedrockOutputNormalizer. Streamed
+	// text is then returned at once, with the final response of the stream.
+	OutputNormalizer OutputNormalizer
+
+	// ModelFallback lists the models a chat retries a turn against, in order, when its
+	// model fails with a retryable error or is unavailable. The system prompt is still
+	// the one enhanced for the chat's own model.
+	ModelFallback []string
+
+	// DryRun makes Send and SendStreaming describe the request they would send,
+	// in the UsageMetadata of their response, instead of calling Bedrock.
+	DryRun bool
+
+	// AdditionalModelRequestFields are model-specific inference parameters that the
+	// Converse API does not cover, such as {"top_k": 50} for Claude models.
+	AdditionalModelRequestFields map[string]any
+
+	// TopK, if positive, limits sampling to the K most likely tokens. It is sent as the
+	// top_k additional model request field to models that accept it (Claude), and omitted otherwise.
+	TopK int32
+
+	// MaxTokens, if positive, is the maximum number of tokens generated by a response.
+	// Defaults to the most the model can generate, capped at 64000, or 4096 for unknown models.
+	MaxTokens int32
+
+	// Temperature and TopP, if set, control the randomness of responses. They are
+	// checked against the ranges the model accepts when a chat is started.
+	Temperature *float32
+	TopP        *float32
+
+	// CredentialsRefresh loads the AWS configuration again, and retries the request once,
+	// when a request fails because its credentials have expired, so that a long-running
+	// client picks up SSO or assumed-role credentials renewed in the meantime.
+	CredentialsRefresh bool
+
+	// StreamRetry resumes a streamed response that fails midway with a retryable error,
+	// up to maxStreamResumes times, by requesting its continuation from the text received
+	// so far. Responses that include tool calls cannot be resumed, and fail as before.
+	StreamRetry bool
+
+	// StrictModel fails the first request of chats started with a model that is not known to
+	// work with the Converse API. By default, unknown models are used with a warning. Chats
+	// started with a model that is not usable with the client's region or inference parameters
+	// fail on their first request either way.
+	StrictModel bool
+
+	// MaxHistoryMessages, if positive, caps the number of messages a chat keeps in its
+	// conversation. Before each request, the oldest messages beyond the cap are dropped.
+	MaxHistoryMessages int
+
+	// AutoContinue makes Send request the continuation of a response cut off at the token
+	// limit, up to maxAutoContinues times, and return it stitched to the response as a single
+	// message. Responses that include tool calls, and streamed responses, are not continued.
+	AutoContinue bool
+
+	// FailoverRegions lists the regions a chat retries a turn in, in order, when the client's
+	// region fails with a retryable error, such as when Bedrock is unavailable or throttles
+	// requests, or does not offer the model. Every turn starts in the client's region.
+	FailoverRegions []string
+
+	// RequestMetadata tags every request with key-value pairs, such as a tenant or a feature,
+	// by which Bedrock model invocation logs can be filtered. At most 14 pairs are allowed,
+	// with keys and values of at most 256 letters, digits, spaces or any of :_@$#=/+,-.
+	// The idempotencyKey and requestID keys are reserved.
+	RequestMetadata map[string]string
+
+	// ToolResultFormat is how function call results are sent to the model. It defaults to
+	// ToolResultFormatJSON.
+	ToolResultFormat ToolResultFormat
+
+	// EndpointURL, if set, is the endpoint of the Bedrock runtime API in the client's region,
+	// such as a VPC interface endpoint or a FIPS endpoint, in place of the default one.
+	// Failover regions use their default endpoints.
+	EndpointURL string
+}
+
+// ToolResultFormat is the content type of the function call results sent to Bedrock.
+type ToolResultFormat string
+
+const (
+	// ToolResultFormatJSON sends results as JSON documents. It suits models trained on
+	// structured tool results, such as Anthropic Claude and Amazon Nova models.
+	ToolResultFormatJSON ToolResultFormat = "json"
+	// ToolResultFormatText sends results as their JSON encoding, in text blocks. It suits
+	// models that only accept text tool results, such as Meta Llama and Mistral models.
+	ToolResultFormatText ToolResultFormat = "text"
+)
+
+// maxStreamResumes is the number of times StreamRetry resumes a single streamed response.
+const maxStreamResumes = 2
+
+// maxAutoContinues is the number of times AutoContinue continues a single response.
+const maxAutoContinues = 3
+
+// defaultConfigLoadTimeout is the default BedrockOptions.ConfigLoadTimeout.
+const defaultConfigLoadTimeout = 30 * time.Second
+
+// defaultModelsCacheTTL is the default BedrockOptions.ModelsCacheTTL.
+const defaultModelsCacheTTL = 5 * time.Minute
+
+// SystemPromptEnhancer returns the system prompt to send in place of the original.
+type SystemPromptEnhancer func(original string) string
+
+// DefaultBedrockSystemPromptEnhancer strengthens the JSON formatting instructions of
+// prompts written for the tool-use shim, which Bedrock models otherwise tend not to follow.
+// Other prompts are returned unchanged.
+func DefaultBedrockSystemPromptEnhancer(original string) string {
+	if !isToolUseShimPrompt(original) {
+		return original
+	}
+
+	enhanced := original
+	enhanced += "\n\nCRITICAL JSON FORMATTING REQUIREMENTS:\n"
+	enhanced += "1. You MUST ALWAYS wrap your JSON responses in ```json code blocks exactly as shown in the examples above.\n"
+	enhanced += "2. NEVER respond with raw JSON without the markdown ```json formatting.\n"
+	enhanced += "3. Ensure your JSON is syntactically correct with proper commas between fields.\n"
+	enhanced += "4. This is critical for proper parsing. Example format:\n"
+	enhanced += "```json\n{\"thought\": \"your reasoning\", \"action\": {\"name\": \"tool_name\", \"command\": \"command\"}}\n```\n"
+	enhanced += "Note the comma after the \"thought\" field! Malformed JSON will cause failures."
+	return enhanced
+}
+
+// isToolUseShimPrompt reports whether prompt is written for the tool-use shim,
+// which asks for actions in ```json blocks.
+func isToolUseShimPrompt(prompt string) bool {
+	return strings.Contains(prompt, "```json") && strings.Contains(prompt, "\"action\"")
+}
+
+// OutputNormalizer returns the text to return in place of the text of a response.
+type OutputNormalizer func(text string) string
+
+// fencedBlockPattern matches the content of a fenced code block, optionally tagged as JSON.
+var fencedBlockPattern = regexp.MustCompile("(?s)```(?:json)?[ \t]*\n?(.*?)```")
+
+// DefaultBedrockOutputNormalizer cleans up responses in the format of the tool-use shim.
+// It finds the first fenced block, or the whole text, that holds a JSON object, moves
+// any prose around it into the object's thought, and returns the object alone in a
+// single ```json block. Text without such an object, including malformed JSON, is
+// returned unchanged, so that the caller can report it.
+func DefaultBedrockOutputNormalizer(text string) string {
+	var object map[string]any
+	var prose string
+	for _, match := range fencedBlockPattern.FindAllStringSubmatchIndex(text, -1) {
+		if json.Unmarshal([]byte(text[match[2]:match[3]]), &object) == nil && object != nil {
+			prose = strings.TrimSpace(strings.TrimSpace(text[:match[0]]) + "\n" + strings.TrimSpace(text[match[1]:]))
+			break
+		}
+	}
+	if object == nil {
+		// Models sometimes leave out the fences
+		if err := json.Unmarshal([]byte(strings.TrimSpace(text)), &object); err != nil || object == nil {
+			return text
+		}
+	}
+
+	if prose != "" {
+		if thought, _ := object["thought"].(string); thought != "" {
+			prose += "\n" + thought
+		}
+		object["thought"] = prose
+	}
+	normalized, err := json.Marshal(object)
+	if err != nil {
+		return text
+	}
+	return "```json\n" + string(normalized) + "\n```"
+}
+
+// ErrNoAWSCredentials is returned when no AWS credentials can be resolved.
+var ErrNoAWSCredentials = errors.New("no AWS credentials found")
+
+// credentialsProbeTimeout bounds how long FailFastOnNoCredentials waits for credentials to resolve.
+const credentialsProbeTimeout = 5 * time.Second
+
+// instanceMetadataTimeout bounds how long RegionFromInstanceMetadata waits for the instance metadata service.
+const instanceMetadataTimeout = 2 * time.Second
+
 // BedrockClient implements the gollm.Client interface for AWS Bedrock models
 type BedrockClient struct {
+	runtime bedrockAPI
+	// control is the Bedrock control plane API, used for health checks.
+	control bedrockControlAPI
+	region  string
+	opts    BedrockOptions
+	// failoverRegions are the regions of BedrockOptions.FailoverRegions, with their runtimes.
+	failoverRegions []bedrockRegion
+	// cfg is the AWS configuration the client was created with.
+	cfg aws.Config
+
+	// fetchModels lists the models available in a region. It defaults to the built-in list.
+	fetchModels func(ctx context.Context, region string) ([]string, error)
+	// models caches the results of fetchModels, if set.
+	models *modelListCache
+	// logger receives diagnostic events; see log.
+	logger *slog.Logger
+
+	// now returns the current time, for timing streams. It defaults to time.Now.
+	now func() time.Time
+	// tokenizer estimates token counts. It defaults to HeuristicTokenizer.
+	tokenizer Tokenizer
+	// toolResults configures how the function call results are sent to the model.
+	toolResults bedrockToolResults
+	// schema is the response schema of the client's chats, and responseTool the tool
+	// they respond with to conform to it; see SetResponseSchema.
+	schema       *Schema
+	responseTool types.Tool
+
+	// lifetime is cancelled by Close, which ends the requests in flight; see requestContext.
+	lifetimeOnce  sync.Once
+	lifetime      context.Context
+	closeLifetime context.CancelFunc
+}
+
+// log returns the logger for the client's diagnostic events, falling back to klog.
+func (c *BedrockClient) log() *slog.Logger {
+	if c.logger != nil {
+		return c.logger
+	}
+	return slog.New(logr.ToSlogHandler(klog.Background()))
+}
+
+// logFor returns the logger for the events of a request made with ctx,
+// which includes the request ID of ctx, if any.
+func (c *BedrockClient) logFor(ctx context.Context) *slog.Logger {
+	if id, ok := RequestIDFromContext(ctx); ok {
+		return c.log().With("requestID", id)
+	}
+	return c.log()
+}
+
+// bedrockRegion is a region a chat can send its requests to.
+type bedrockRegion struct {
+	name    string
+	runtime bedrockAPI
+}
+
+// bedrockAPI is the subset of the Bedrock runtime API used by the client.
+type bedrockAPI interface {
+	Converse(ctx context.Context, input *bedrockruntime.ConverseInput) (*bedrockruntime.ConverseOutput, error)
+	ConverseStream(ctx context.Context, input *bedrockruntime.ConverseStreamInput) (bedrockEventStream, error)
+}
+
+// bedrockControlAPI is the subset of the Bedrock control plane API used by the client.
+// It is implemented by *bedrock.Client.
+type bedrockControlAPI interface {
+	GetFoundationModel(ctx context.Context, input *bedrock.GetFoundationModelInput, optFns ...func(*bedrock.Options)) (*bedrock.GetFoundationModelOutput, error)
+}
+
+// bedrockEventStream is a stream of ConverseStream events.
+// It is implemented by *bedrockruntime.ConverseStreamEventStream.
+type bedrockEventStream interface {
+	Events() <-chan types.ConverseStreamOutput
+	Close() error
+	Err() error
+}
+
+// bedrockRuntimeAPI implements bedrockAPI using the AWS SDK client.
+type bedrockRuntimeAPI struct {
 	client *bedrockruntime.Client
 }
 
-// Ensure BedrockClient implements the Client interface
-var _ Client = &BedrockClient{}
+func (a *bedrockRuntimeAPI) Converse(ctx context.Context, input *bedrockruntime.ConverseInput) (*bedrockruntime.ConverseOutput, error) {
+	return a.client.Converse(ctx, input)
+}
+
+func (a *bedrockRuntimeAPI) ConverseStream(ctx context.Context, input *bedrockruntime.ConverseStreamInput) (bedrockEventStream, error) {
+	output, err := a.client.ConverseStream(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	return output.GetStream(), nil
+}
+
+// refreshingBedrockAPI is a bedrockAPI that, when a request fails because its credentials
+// have expired, reloads the AWS configuration and retries the request once.
+type refreshingBedrockAPI struct {
+	// reload returns a bedrockAPI using freshly resolved credentials.
+	reload func(ctx context.Context) (bedrockAPI, error)
+
+	mu  sync.Mutex
+	api bedrockAPI
+}
+
+func (a *refreshingBedrockAPI) current() bedrockAPI {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.api
+}
+
+// refresh replaces the API whose credentials expired, unless another request already has.
+func (a *refreshingBedrockAPI) refresh(ctx context.Context, expired bedrockAPI) (bedrockAPI, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.api != expired {
+		return a.api, nil
+	}
+	api, err := a.reload(ctx)
+	if err != nil {
+		return nil, err
+	}
+	a.api = api
+	return api, nil
+}
+
+func (a *refreshingBedrockAPI) Converse(ctx context.Context, input *bedrockruntime.ConverseInput) (*bedrockruntime.ConverseOutput, error) {
+	api := a.current()
+	output, err := api.Converse(ctx, input)
+	if !isExpiredTokenError(err) {
+		return output, err
+	}
+	refreshed, refreshErr := a.refresh(ctx, api)
+	if refreshErr != nil {
+		return nil, fmt.Errorf("%w (refreshing credentials failed: %v)", err, refreshErr)
+	}
+	return refreshed.Converse(ctx, input)
+}
+
+func (a *refreshingBedrockAPI) ConverseStream(ctx context.Context, input *bedrockruntime.ConverseStreamInput) (bedrockEventStream, error) {
+	api := a.current()
+	stream, err := api.ConverseStream(ctx, input)
+	if !isExpiredTokenError(err) {
+		return stream, err
+	}
+	refreshed, refreshErr := a.refresh(ctx, api)
+	if refreshErr != nil {
+		return nil, fmt.Errorf("%w (refreshing credentials failed: %v)", err, refreshErr)
+	}
+	return refreshed.ConverseStream(ctx, input)
+}
+
+// isExpiredTokenError returns true if err means that the credentials of the request have expired.
+func isExpiredTokenError(err error) bool {
+	var apiErr smithy.APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	switch apiErr.ErrorCode() {
+	case "ExpiredTokenException", "ExpiredToken":
+		return true
+	}
+	return false
+}
+
+// ErrInvalidInferenceParameter is returned by the requests of a chat whose inference
+// parameters, such as its temperature, are outside the range its model accepts.
+var ErrInvalidInferenceParameter = errors.New("invalid inference parameter")
+
+// ErrClientClosed is returned by requests made after the client has been closed,
+// and ends the streams that were in flight when it was.
+var ErrClientClosed = errors.New("client is closed")
+
+// ErrEmptyStream is returned by a streaming response that ended without producing any events.
+var ErrEmptyStream = errors.New("stream ended without producing any events")
+
+var (
+	// ErrUnmatchedToolResult is returned when a tool result does not answer any outstanding tool call.
+	ErrUnmatchedToolResult = errors.New("tool result does not match an outstanding tool call")
+	// ErrMissingToolResult is returned when a message does not answer every outstanding tool call.
+	ErrMissingToolResult = errors.New("missing tool result for outstanding tool call")
+)
+
+var (
+	// ErrModelNotReady is returned when Bedrock reports that the model cannot serve
+	// requests yet, typically because access to it has not been enabled.
+	ErrModelNotReady = errors.New("bedrock model is not ready")
+	// ErrThroughputRequired is returned when the model cannot be invoked with on-demand
+	// throughput and must be called through an inference profile or provisioned throughput.
+	ErrThroughputRequired = errors.New("bedrock model does not support on-demand throughput")
+)
+
+// Ensure BedrockClient implements the Client interface
+var _ Client = &BedrockClient{}
+
+// NewBedrockClient creates a new client for interacting with AWS Bedrock models
+func NewBedrockClient(ctx context.Context, opts ClientOptions) (*BedrockClient, error) {
+	if opts.CandidateCount > 1 {
+		return nil, fmt.Errorf("bedrock returns a single candidate per response, %d candidates are not supported", opts.CandidateCount)
+	}
+
+	if err := validateEndpointURL(opts.Bedrock.EndpointURL); err != nil {
+		return nil, err
+	}
+	if err := validateRequestMetadata(opts.Bedrock.RequestMetadata); err != nil {
+		return nil, err
+	}
+	switch opts.Bedrock.ToolResultFormat {
+	case "", ToolResultFormatJSON, ToolResultFormatText:
+	default:
+		return nil, fmt.Errorf("unknown tool result format %q, expected %q or %q", opts.Bedrock.ToolResultFormat, ToolResultFormatJSON, ToolResultFormatText)
+	}
+
+	var loadOptions []func(*config.LoadOptions) error
+	if opts.Region != "" {
+		if err := validateAWSRegion(opts.Region); err != nil {
+			return nil, err
+		}
+		loadOptions = append(loadOptions, config.WithRegion(opts.Region))
+	}
+
+	if opts.ProxyURL != "" {
+		proxy, err := proxyFunc(opts)
+		if err != nil {
+			return nil, err
+		}
+		httpClient := awshttp.NewBuildableClient().WithTransportOptions(func(t *http.Transport) {
+			t.Proxy = proxy
+		})
+		loadOptions = append(loadOptions, config.WithHTTPClient(httpClient))
+	}
+
+	backoff := retryBackoff(opts)
+	loadOptions = append(loadOptions, config.WithRetryer(func() aws.Retryer {
+		return retry.NewStandard(func(o *retry.StandardOptions) {
+			o.Backoff = retry.BackoffDelayerFunc(func(attempt int, err error) (time.Duration, error) {
+				return backoff.backoff(attempt), nil
+			})
+		})
+	}))
+
+	// Load AWS config with timeout protection
+	configCtx, cancel := configLoadContext(ctx, opts.Bedrock.ConfigLoadTimeout)
+	defer cancel()
+
+	cfg, err := config.LoadDefaultConfig(configCtx, loadOptions...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+
+	bedrockOpts := opts.Bedrock
+	if cfg.Region == "" && bedrockOpts.RegionFromInstanceMetadata {
+		region, err := instanceMetadataRegion(ctx, cfg)
+		if err != nil {
+			klog.V(1).Infof("Could not get the region from instance metadata: %v", err)
+		}
+		cfg.Region = region
+	}
+
+	// Default to us-east-1 for Bedrock if no region is set
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+
+	if v := os.Getenv("BEDROCK_AUTO_INFERENCE_PROFILE"); v == "1" || strings.ToLower(v) == "true" {
+		bedrockOpts.AutoInferenceProfile = true
+	}
+
+	if bedrockOpts.FailFastOnNoCredentials {
+		if err := probeAWSCredentials(ctx, cfg); err != nil {
+			return nil, err
+		}
+	}
+
+	modelsCacheTTL := bedrockOpts.ModelsCacheTTL
+	if modelsCacheTTL == 0 {
+		modelsCacheTTL = defaultModelsCacheTTL
+	}
+
+	// The credentials of the configuration are cached, and refreshed before they expire.
+	// Credentials renewed outside of the process, as by 'aws sso login', need the
+	// configuration to be loaded again, which CredentialsRefresh does.
+	newRuntime := func(region, endpoint string) bedrockAPI {
+		cfg := cfg.Copy()
+		cfg.Region = region
+		var runtime bedrockAPI = &bedrockRuntimeAPI{client: bedrockruntime.NewFromConfig(cfg, withBedrockUserAgent(opts.UserAgent), withBedrockEndpoint(endpoint))}
+		if !bedrockOpts.CredentialsRefresh {
+			return runtime
+		}
+		return &refreshingBedrockAPI{
+			api: runtime,
+			reload: func(ctx context.Context) (bedrockAPI, error) {
+				klog.V(1).Info("Bedrock credentials expired, loading the AWS config again")
+				cfg, err := config.LoadDefaultConfig(ctx, loadOptions...)
+				if err != nil {
+					return nil, fmt.Errorf("failed to load AWS config: %w", err)
+				}
+				cfg.Region = region
+				return &bedrockRuntimeAPI{client: bedrockruntime.NewFromConfig(cfg, withBedrockUserAgent(opts.UserAgent), withBedrockEndpoint(endpoint))}, nil
+			},
+		}
+	}
+
+	var failoverRegions []bedrockRegion
+	for _, region := range bedrockOpts.FailoverRegions {
+		if err := validateAWSRegion(region); err != nil {
+			return nil, fmt.Errorf("failover region: %w", err)
+		}
+		if region != cfg.Region {
+			failoverRegions = append(failoverRegions, bedrockRegion{name: region, runtime: newRuntime(region, "")})
+		}
+	}
+
+	client := &BedrockClient{
+		runtime:         newRuntime(cfg.Region, bedrockOpts.EndpointURL),
+		control:         bedrock.NewFromConfig(cfg, withBedrockControlUserAgent(opts.UserAgent)),
+		region:          cfg.Region,
+		opts:            bedrockOpts,
+		failoverRegions: failoverRegions,
+		cfg:             cfg,
+		models:          newModelListCache(modelsCacheTTL),
+		logger:          opts.Logger,
+		tokenizer:       opts.Tokenizer,
+		toolResults: bedrockToolResults{
+			format:   bedrockOpts.ToolResultFormat,
+			maxBytes: opts.MaxToolResultBytes,
+		},
+	}
+	for _, region := range append([]string{cfg.Region}, bedrockOpts.FailoverRegions...) {
+		if unknown, suggestion := unknownAWSRegion(region); unknown && region != "" {
+			client.log().Warn("unknown AWS region, using it anyway", "region", region, "didYouMean", suggestion)
+		}
+	}
+	return client, nil
+}
+
+// Unwrap returns the AWS SDK client of the Bedrock runtime API in the client's region, for
+// calling features that gollm does not wrap. Requests made with it bypass gollm: they are not
+// retried, failed over, limited, or reported to usage callbacks. It returns nil if the client
+// was not created by NewBedrockClient.
+func (c *BedrockClient) Unwrap() *bedrockruntime.Client {
+	runtime := c.runtime
+	if refreshing, ok := runtime.(*refreshingBedrockAPI); ok {
+		runtime = refreshing.current()
+	}
+	if sdk, ok := runtime.(*bedrockRuntimeAPI); ok {
+		return sdk.client
+	}
+	return nil
+}
+
+// AWSConfig returns the AWS configuration the client was created with, from which clients
+// of other AWS services can be created, such as the Bedrock control plane API for batch
+// inference or model invocation logging. Like Unwrap, such clients bypass gollm.
+func (c *BedrockClient) AWSConfig() aws.Config {
+	return c.cfg.Copy()
+}
+
+// withBedrockUserAgent appends userAgent, such as kubectl-ai/v0.1.0, to the
+// User-Agent the AWS SDK sends with every request.
+func withBedrockUserAgent(userAgent string) func(*bedrockruntime.Options) {
+	return func(o *bedrockruntime.Options) {
+		if userAgent == "" {
+			return
+		}
+		key, value, _ := strings.Cut(userAgent, "/")
+		o.APIOptions = append(o.APIOptions, awsmiddleware.AddUserAgentKeyValue(key, value))
+	}
+}
+
+// withBedrockControlUserAgent is withBedrockUserAgent for the Bedrock control plane API.
+func withBedrockControlUserAgent(userAgent string) func(*bedrock.Options) {
+	return func(o *bedrock.Options) {
+		if userAgent == "" {
+			return
+		}
+		key, value, _ := strings.Cut(userAgent, "/")
+		o.APIOptions = append(o.APIOptions, awsmiddleware.AddUserAgentKeyValue(key, value))
+	}
+}
+
+// withBedrockEndpoint makes the AWS SDK send requests to endpoint, if it is set.
+func withBedrockEndpoint(endpoint string) func(*bedrockruntime.Options) {
+	return func(o *bedrockruntime.Options) {
+		if endpoint != "" {
+			o.BaseEndpoint = aws.String(endpoint)
+		}
+	}
+}
+
+// validateEndpointURL checks that endpoint, if set, is an absolute https URL.
+func validateEndpointURL(endpoint string) error {
+	if endpoint == "" {
+		return nil
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return fmt.Errorf("parsing Bedrock endpoint URL %q: %w", endpoint, err)
+	}
+	if u.Scheme != "https" || u.Host == "" {
+		return fmt.Errorf("invalid Bedrock endpoint URL %q: expected https:// followed by a host", endpoint)
+	}
+	return nil
+}
+
+// maxRequestMetadataEntries and maxRequestMetadataLength are the limits Bedrock sets on
+// the entries of RequestMetadata, less the ones set by requestMetadata, and on the length
+// of their keys and values.
+const (
+	maxRequestMetadataEntries = 16 - len(reservedRequestMetadataKeys)
+	maxRequestMetadataLength  = 256
+)
+
+// reservedRequestMetadataKeys are the RequestMetadata keys set by requestMetadata.
+var reservedRequestMetadataKeys = [...]string{"idempotencyKey", "requestID"}
+
+// requestMetadataPattern matches the characters Bedrock allows in RequestMetadata keys and values.
+var requestMetadataPattern = regexp.MustCompile(`^[a-zA-Z0-9\s:_@$#=/+,\-.]*$`)
+
+// validateRequestMetadata returns an error if Bedrock would reject metadata as RequestMetadata.
+func validateRequestMetadata(metadata map[string]string) error {
+	if len(metadata) > maxRequestMetadataEntries {
+		return fmt.Errorf("invalid Bedrock request metadata: %d entries, at most %d are allowed", len(metadata), maxRequestMetadataEntries)
+	}
+	for key, value := range metadata {
+		if slices.Contains(reservedRequestMetadataKeys[:], key) {
+			return fmt.Errorf("invalid Bedrock request metadata key %q: it is set by gollm", key)
+		}
+		if key == "" || len(key) > maxRequestMetadataLength || !requestMetadataPattern.MatchString(key) {
+			return fmt.Errorf("invalid Bedrock request metadata key %q: expected 1 to %d letters, digits, spaces or any of :_@$#=/+,-.", key, maxRequestMetadataLength)
+		}
+		if len(value) > maxRequestMetadataLength || !requestMetadataPattern.MatchString(value) {
+			return fmt.Errorf("invalid Bedrock request metadata value %q for key %q: expected at most %d letters, digits, spaces or any of :_@$#=/+,-.", value, key, maxRequestMetadataLength)
+		}
+	}
+	return nil
+}
+
+// configLoadContext returns the context in which NewBedrockClient loads the AWS configuration:
+// ctx, bounded by timeout, or by defaultConfigLoadTimeout if it is zero. A negative timeout
+// leaves ctx unbounded.
+func configLoadContext(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	switch {
+	case timeout < 0:
+		return context.WithCancel(ctx)
+	case timeout == 0:
+		timeout = defaultConfigLoadTimeout
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// instanceMetadataRegion returns the region of the EC2 instance, or EKS node, the client runs on.
+func instanceMetadataRegion(ctx context.Context, cfg aws.Config) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, instanceMetadataTimeout)
+	defer cancel()
+
+	output, err := imds.NewFromConfig(cfg).GetRegion(ctx, &imds.GetRegionInput{})
+	if err != nil {
+		return "", err
+	}
+	return output.Region, nil
+}
+
+// probeAWSCredentials checks that credentials can be resolved from the AWS config within a short deadline.
+func probeAWSCredentials(ctx context.Context, cfg aws.Config) error {
+	if cfg.Credentials == nil {
+		return fmt.Errorf("%w: no credentials provider is configured", ErrNoAWSCredentials)
+	}
+
+	probeCtx, cancel := context.WithTimeout(ctx, credentialsProbeTimeout)
+	defer cancel()
+
+	if _, err := cfg.Credentials.Retrieve(probeCtx); err != nil {
+		return fmt.Errorf("%w: set AWS_ACCESS_KEY_ID/AWS_SECRET_ACCESS_KEY, AWS_PROFILE, or run 'aws sso login': %w", ErrNoAWSCredentials, err)
+	}
+	return nil
+}
+
+// Close cancels the requests in flight, ending their streams with ErrClientClosed.
+// Further requests fail with ErrClientClosed.
+func (c *BedrockClient) Close() error {
+	c.lifetimeContext()
+	c.closeLifetime()
+	return nil
+}
+
+// clock returns the current time.
+func (c *BedrockClient) clock() time.Time {
+	if c.now != nil {
+		return c.now()
+	}
+	return time.Now()
+}
+
+// countTokens estimates the number of tokens in text.
+func (c *BedrockClient) countTokens(text string) int {
+	if c.tokenizer != nil {
+		return c.tokenizer.Count(text)
+	}
+	return HeuristicTokenizer{}.Count(text)
+}
+
+// lifetimeContext returns the context that is cancelled when the client is closed.
+func (c *BedrockClient) lifetimeContext() context.Context {
+	c.lifetimeOnce.Do(func() {
+		c.lifetime, c.closeLifetime = context.WithCancel(context.Background())
+	})
+	return c.lifetime
+}
+
+// requestContext returns a context for a request made with ctx, which is also cancelled,
+// with ErrClientClosed as its cause, when the client is closed. The returned function
+// must be called once the request is over.
+func (c *BedrockClient) requestContext(ctx context.Context) (context.Context, context.CancelFunc, error) {
+	lifetime := c.lifetimeContext()
+	if lifetime.Err() != nil {
+		return nil, nil, ErrClientClosed
+	}
+	ctx, cancel := context.WithCancelCause(ctx)
+	stop := context.AfterFunc(lifetime, func() { cancel(ErrClientClosed) })
+	return ctx, func() {
+		stop()
+		cancel(nil)
+	}, nil
+}
+
+var _ HealthChecker = &BedrockClient{}
+
+// HealthCheck looks up the default model with the Bedrock control plane API, which is
+// not billed. It fails if Bedrock cannot be reached, the credentials are rejected, or the
+// model is not offered in the client's region. Clients not created by NewBedrockClient
+// have no control plane client, and always pass.
+func (c *BedrockClient) HealthCheck(ctx context.Context) error {
+	if c.control == nil {
+		return nil
+	}
+	ctx, done, err := c.requestContext(ctx)
+	if err != nil {
+		return err
+	}
+	defer done()
+
+	model := baseModelID(getBedrockModel("", c.region))
+	if _, err := c.control.GetFoundationModel(ctx, &bedrock.GetFoundationModelInput{ModelIdentifier: aws.String(model)}); err != nil {
+		return fmt.Errorf("bedrock health check: %w", err)
+	}
+	return nil
+}
+
+// StartChat starts a new chat session with the specified system prompt and model
+func (c *BedrockClient) StartChat(systemPrompt, model string) Chat {
+	selectedModel := getBedrockModel(model, c.region)
+	if c.opts.AutoInferenceProfile {
+		selectedModel = applyInferenceProfilePrefix(selectedModel, c.region)
+	}
+
+	modelErr := c.validateChatModel(selectedModel)
+
+	log := c.log().With("model", selectedModel)
+	log.Debug("starting Bedrock chat")
+	if supported, reason := ModelSupportReason(selectedModel); !supported && modelErr == nil {
+		log.Warn("Bedrock model is not known to work with the Converse API, using it anyway", "reason", reason)
+	}
+
+	enhance := c.opts.SystemPromptEnhancer
+	if enhance == nil {
+		enhance = defaultSystemPromptEnhancerFor(selectedModel)
+	}
+	enhancedPrompt := enhance(systemPrompt)
+	if enhancedPrompt != systemPrompt {
+		log.Debug("enhanced Bedrock system prompt")
+	}
+
+	chat := &bedrockChat{
+		client:       c,
+		systemPrompt: enhancedPrompt,
+		model:        selectedModel,
+		messages:     []types.Message{},
+		responseTool: c.responseTool,
+	}
+	if c.opts.OutputNormalizer != nil && isToolUseShimPrompt(systemPrompt) {
+		chat.normalizeOutput = c.opts.OutputNormalizer
+	}
+
+	if modelErr != nil {
+		log.Warn("invalid Bedrock chat model", "error", modelErr)
+		chat.modelErr = modelErr
+	}
+
+	for _, fallback := range c.opts.ModelFallback {
+		if c.opts.AutoInferenceProfile {
+			fallback = applyInferenceProfilePrefix(fallback, c.region)
+		}
+		if supported, reason := ModelSupportReason(fallback); !supported {
+			log.Warn("Bedrock fallback model is not known to work with the Converse API, using it anyway", "fallback", fallback, "reason", reason)
+		}
+		if err := validateInferenceParameters(fallback, c.opts); err != nil {
+			log.Warn("ignoring Bedrock fallback model", "fallback", fallback, "error", err)
+			continue
+		}
+		chat.fallbackModels = append(chat.fallbackModels, fallback)
+	}
+
+	return chat
+}
+
+// StartChatWithSystemBlocks starts a chat whose system prompt is sent as several blocks,
+// for example to separate instructions from context. The first block is treated as the
+// system prompt passed to StartChat, and is the one enhanced by the SystemPromptEnhancer.
+func (c *BedrockClient) StartChatWithSystemBlocks(systemBlocks []string, model string) Chat {
+	if len(systemBlocks) == 0 {
+		return c.StartChat("", model)
+	}
+	chat := c.StartChat(systemBlocks[0], model).(*bedrockChat)
+	chat.systemContext = slices.Clone(systemBlocks[1:])
+	return chat
+}
+
+// StartChatWithOptions starts a chat configured with opts. A response schema in opts
+// takes the place of the client's response schema for this chat only.
+func (c *BedrockClient) StartChatWithOptions(systemPrompt, model string, opts ...ChatOption) (Chat, error) {
+	options := newChatOptions(opts)
+	chat := c.StartChat(systemPrompt, model).(*bedrockChat)
+	if options.Schema != nil {
+		responseTool, err := bedrockResponseTool(options.Schema)
+		if err != nil {
+			return nil, err
+		}
+		chat.responseTool = responseTool
+	}
+	return chat, nil
+}
+
+// bedrockResponseTool returns the tool a chat with a response schema is made to call:
+// the input of that call, which conforms to schema, is returned, and kept in the
+// history, as the JSON text of the response.
+func bedrockResponseTool(schema *Schema) (types.Tool, error) {
+	if schema.Type != TypeObject {
+		return nil, fmt.Errorf("bedrock response schema must be an object, got %q", schema.Type)
+	}
+	inputSchema, err := convertSchemaToMap(schema)
+	if err != nil {
+		return nil, fmt.Errorf("converting response schema: %w", err)
+	}
+	return &types.ToolMemberToolSpec{Value: types.ToolSpecification{
+		Name:        aws.String(bedrockResponseToolName),
+		Description: aws.String("Respond to the user. The input of this tool is the response."),
+		InputSchema: &types.ToolInputSchemaMemberJson{Value: document.NewLazyDocument(inputSchema)},
+	}}, nil
+}
+
+// validateChatModel checks that model can be used in the client's region, and accepts
+// the client's inference parameters. Models that ModelSupportReason does not know are
+// only rejected with StrictModel, since Bedrock keeps adding models that work with the
+// Converse API.
+func (c *BedrockClient) validateChatModel(model string) error {
+	if supported, reason := ModelSupportReason(model); !supported && c.opts.StrictModel {
+		return fmt.Errorf("unsupported bedrock model %q: %s", model, reason)
+	}
+	if err := validateModelRegion(model, c.region); err != nil {
+		return err
+	}
+	return validateInferenceParameters(model, c.opts)
+}
+
+// GenerateCompletion generates a single completion for the given request
+func (c *BedrockClient) GenerateCompletion(ctx context.Context, req *CompletionRequest) (CompletionResponse, error) {
+	chat := c.StartChat("", req.Model)
+	chatResponse, err := chat.Send(ctx, req.Prompt)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkTextContent(chatResponse); err != nil {
+		return nil, err
+	}
+
+	// Wrap ChatResponse in a CompletionResponse
+	return &bedrockCompletionResponse{
+		chatResponse: chatResponse,
+	}, nil
+}
+
+// GenerateCompletionStream streams a completion for the given request.
+// It uses the same single-turn chat, and so the same inference configuration, as GenerateCompletion.
+func (c *BedrockClient) GenerateCompletionStream(ctx context.Context, req *CompletionRequest) (CompletionResponseIterator, error) {
+	return streamCompletionViaChat(ctx, c, req)
+}
+
+// SetResponseSchema constrains the responses of the chats started afterwards to match
+// schema, which must be an object. Calling with nil will clear the current schema.
+func (c *BedrockClient) SetResponseSchema(schema *Schema) error {
+	if schema == nil {
+		c.schema, c.responseTool = nil, nil
+		return nil
+	}
+	responseTool, err := bedrockResponseTool(schema)
+	if err != nil {
+		return err
+	}
+	c.schema, c.responseTool = schema, responseTool
+	return nil
+}
+
+// CurrentSchema returns the schema set with SetResponseSchema, or nil if there is none.
+func (c *BedrockClient) CurrentSchema() *Schema {
+	return c.schema
+}
+
+// Name returns "bedrock", the name the Bedrock provider is registered under.
+func (c *BedrockClient) Name() string {
+	return "bedrock"
+}
+
+// Capabilities returns the features supported by the Bedrock provider.
+func (c *BedrockClient) Capabilities() ProviderCapabilities {
+	return ProviderCapabilities{Tools: true, Streaming: true, ResponseSchema: true}
+}
+
+// ListModels returns the list of supported Bedrock models.
+// Results are cached per region for BedrockOptions.ModelsCacheTTL.
+func (c *BedrockClient) ListModels(ctx context.Context) ([]string, error) {
+	fetch := c.fetchModels
+	if fetch == nil {
+		fetch = builtinBedrockModels
+	}
+	if c.models == nil {
+		return fetch(ctx, c.region)
+	}
+	return c.models.get(ctx, c.region, fetch)
+}
+
+// RefreshModels discards the cached model lists and fetches the models again.
+func (c *BedrockClient) RefreshModels(ctx context.Context) ([]string, error) {
+	if c.models != nil {
+		c.models.invalidate()
+	}
+	return c.ListModels(ctx)
+}
+
+// builtinBedrockModels returns the models listed by default, using the inference profiles of the region.
+func builtinBedrockModels(ctx context.Context, region string) ([]string, error) {
+	return []string{
+		regionalInferenceProfile(defaultBedrockModel, region),                         // Claude Sonnet 4 (default)
+		regionalInferenceProfile("anthropic.claude-3-7-sonnet-20250219-v1:0", region), // Claude 3.7 Sonnet
+	}, nil
+}
+
+// modelListCache caches model lists per region for a fixed TTL.
+type modelListCache struct {
+	ttl time.Duration
+	now func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]modelListEntry
+}
+
+type modelListEntry struct {
+	models    []string
+	fetchedAt time.Time
+}
+
+func newModelListCache(ttl time.Duration) *modelListCache {
+	return &modelListCache{
+		ttl:     ttl,
+		now:     time.Now,
+		entries: make(map[string]modelListEntry),
+	}
+}
+
+// get returns the cached models for region, calling fetch if there are none or they have expired.
+// Failed fetches are not cached.
+func (m *modelListCache) get(ctx context.Context, region string, fetch func(ctx context.Context, region string) ([]string, error)) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if entry, ok := m.entries[region]; ok && m.now().Sub(entry.fetchedAt) < m.ttl {
+		return slices.Clone(entry.models), nil
+	}
+
+	models, err := fetch(ctx, region)
+	if err != nil {
+		return nil, err
+	}
+	m.entries[region] = modelListEntry{models: models, fetchedAt: m.now()}
+	return slices.Clone(models), nil
+}
+
+// invalidate discards all cached model lists.
+func (m *modelListCache) invalidate() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	clear(m.entries)
+}
+
+// bedrockChat implements the Chat interface for Bedrock conversations
+type bedrockChat struct {
+	client       *BedrockClient
+	systemPrompt string
+	model        string
+	messages     []types.Message
+	toolConfig   *types.ToolConfiguration
+	functionDefs []*FunctionDefinition
+
+	// responseTool, if set, is the tool the model calls with the chat's structured response
+	responseTool types.Tool
+
+	// systemContext are further blocks of the system prompt, sent after systemPrompt
+	systemContext []string
+
+	// modelErr is returned by Send and SendStreaming if the model, or its inference parameters, are not supported
+	modelErr error
+	// fallbackModels are tried in order when model is throttled or unavailable
+	fallbackModels []string
+	// normalizeOutput, if set, rewrites the text of responses before they are returned.
+	// The history keeps the text the model generated.
+	normalizeOutput OutputNormalizer
+}
+
+// Initialize rebuilds the conversation from a previous session's messages,
+// including tool calls and their results.
+func (c *bedrockChat) Initialize(history []*api.Message) error {
+	c.messages = []types.Message{}
+	for _, msg := range history {
+		role, blocks, err := messageToBedrockBlocks(msg, c.client.toolResults)
+		if err != nil {
+			c.client.log().Warn("skipping message in Bedrock chat history", "message", msg.ID, "error", err)
+			continue
+		}
+		if len(blocks) == 0 {
+			continue
+		}
+
+		// Bedrock requires roles to alternate, so merge consecutive messages from the same role
+		if n := len(c.messages); n > 0 && c.messages[n-1].Role == role {
+			c.messages[n-1].Content = append(c.messages[n-1].Content, blocks...)
+			continue
+		}
+		c.messages = append(c.messages, types.Message{Role: role, Content: blocks})
+	}
+	return nil
+}
+
+var _ SerializableChat = &bedrockChat{}
+
+// MarshalHistory returns the conversation so far as provider-neutral JSON.
+func (c *bedrockChat) MarshalHistory() ([]byte, error) {
+	history, err := historyMessages(c.messages)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(history)
+}
+
+// historyMessages converts Bedrock messages to their provider-neutral form.
+func historyMessages(messages []types.Message) ([]HistoryMessage, error) {
+	history := make([]HistoryMessage, 0, len(messages))
+	for _, msg := range messages {
+		var role HistoryRole
+		switch msg.Role {
+		case types.ConversationRoleUser:
+			role = HistoryRoleUser
+		case types.ConversationRoleAssistant:
+			role = HistoryRoleAssistant
+		default:
+			return nil, fmt.Errorf("unexpected message role %q", msg.Role)
+		}
+
+		var parts []HistoryPart
+		for _, block := range msg.Content {
+			part, err := bedrockBlockToHistoryPart(block)
+			if err != nil {
+				return nil, err
+			}
+			parts = append(parts, part)
+		}
+		history = append(history, HistoryMessage{Role: role, Parts: parts})
+	}
+	return history, nil
+}
+
+// RestoreHistory replaces the conversation with one returned by MarshalHistory.
+func (c *bedrockChat) RestoreHistory(data []byte) error {
+	var history []HistoryMessage
+	if err := json.Unmarshal(data, &history); err != nil {
+		return fmt.Errorf("parsing chat history: %w", err)
+	}
+
+	messages := make([]types.Message, 0, len(history))
+	for i, msg := range history {
+		var role types.ConversationRole
+		switch msg.Role {
+		case HistoryRoleUser:
+			role = types.ConversationRoleUser
+		case HistoryRoleAssistant:
+			role = types.ConversationRoleAssistant
+		default:
+			return fmt.Errorf("message %d: unexpected role %q", i, msg.Role)
+		}
+
+		var blocks []types.ContentBlock
+		for _, part := range msg.Parts {
+			switch {
+			case part.FunctionCall != nil:
+				blocks = append(blocks, &types.ContentBlockMemberToolUse{Value: types.ToolUseBlock{
+					ToolUseId: aws.String(part.FunctionCall.ID),
+					Name:      aws.String(part.FunctionCall.Name),
+					Input:     document.NewLazyDocument(part.FunctionCall.Arguments),
+				}})
+			case part.FunctionCallResult != nil:
+				resultBlocks, err := processContents(c.client.toolResults, *part.FunctionCallResult)
+				if err != nil {
+					return fmt.Errorf("message %d: %w", i, err)
+				}
+				blocks = append(blocks, resultBlocks...)
+			case part.Document != nil:
+				block, err := documentBlock(*part.Document)
+				if err != nil {
+					return fmt.Errorf("message %d: %w", i, err)
+				}
+				blocks = append(blocks, block)
+			default:
+				blocks = append(blocks, &types.ContentBlockMemberText{Value: part.Text})
+			}
+		}
+		messages = append(messages, types.Message{Role: role, Content: blocks})
+	}
+
+	c.messages = messages
+	return nil
+}
+
+// bedrockBlockToHistoryPart converts a Bedrock content block to its provider-neutral form.
+func bedrockBlockToHistoryPart(block types.ContentBlock) (HistoryPart, error) {
+	switch v := block.(type) {
+	case *types.ContentBlockMemberText:
+		return HistoryPart{Text: v.Value}, nil
+
+	case *types.ContentBlockMemberToolUse:
+		var args map[string]any
+		if v.Value.Input != nil {
+			if err := unmarshalDocument(v.Value.Input, &args); err != nil {
+				return HistoryPart{}, fmt.Errorf("decoding input of tool call %q: %w", aws.ToString(v.Value.ToolUseId), err)
+			}
+		}
+		return HistoryPart{FunctionCall: &FunctionCall{
+			ID:        aws.ToString(v.Value.ToolUseId),
+			Name:      aws.ToString(v.Value.Name),
+			Arguments: args,
+		}}, nil
+
+	case *types.ContentBlockMemberToolResult:
+		result := map[string]any{}
+		for _, content := range v.Value.Content {
+			switch c := content.(type) {
+			case *types.ToolResultContentBlockMemberJson:
+				if err := unmarshalDocument(c.Value, &result); err != nil {
+					return HistoryPart{}, fmt.Errorf("decoding result of tool call %q: %w", aws.ToString(v.Value.ToolUseId), err)
+				}
+			case *types.ToolResultContentBlockMemberText:
+				// Results sent as text with ToolResultFormatText are JSON, unless truncated
+				if err := json.Unmarshal([]byte(c.Value), &result); err != nil {
+					result["text"] = c.Value
+				}
+			default:
+				return HistoryPart{}, fmt.Errorf("unsupported tool result content %T", content)
+			}
+		}
+		return HistoryPart{FunctionCallResult: &FunctionCallResult{
+			ID:      aws.ToString(v.Value.ToolUseId),
+			Result:  result,
+			IsError: v.Value.Status == types.ToolResultStatusError,
+		}}, nil
+
+	case *types.ContentBlockMemberDocument:
+		source, ok := v.Value.Source.(*types.DocumentSourceMemberBytes)
+		if !ok {
+			return HistoryPart{}, fmt.Errorf("unsupported source %T of document %q", v.Value.Source, aws.ToString(v.Value.Name))
+		}
+		return HistoryPart{Document: &DocumentPart{
+			Name:   aws.ToString(v.Value.Name),
+			Format: string(v.Value.Format),
+			Bytes:  source.Value,
+		}}, nil
+
+	default:
+		return HistoryPart{}, fmt.Errorf("unsupported content block %T", block)
+	}
+}
+
+// unmarshalDocument decodes a Smithy document into out via its JSON encoding.
+func unmarshalDocument(doc document.Interface, out any) error {
+	b, err := doc.MarshalSmithyDocument()
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, out)
+}
+
+// messageToBedrockBlocks converts a session message to the role and content blocks of a Bedrock message.
+// Messages that are not part of the model conversation (errors, prompts for user input) yield no blocks.
+func messageToBedrockBlocks(msg *api.Message, toolResults bedrockToolResults) (types.ConversationRole, []types.ContentBlock, error) {
+	switch msg.Type {
+	case api.MessageTypeText:
+		text, ok := msg.Payload.(string)
+		if !ok {
+			return "", nil, fmt.Errorf("unexpected payload type %T for text message", msg.Payload)
+		}
+		switch msg.Source {
+		case api.MessageSourceUser:
+			return types.ConversationRoleUser, []types.ContentBlock{&types.ContentBlockMemberText{Value: text}}, nil
+		case api.MessageSourceModel:
+			return types.ConversationRoleAssistant, []types.ContentBlock{&types.ContentBlockMemberText{Value: text}}, nil
+		default:
+			// Agent text (greetings, status) was never sent to the model
+			return "", nil, nil
+		}
+
+	case api.MessageTypeToolCallRequest:
+		calls, err := functionCallsFromPayload(msg.Payload)
+		if err != nil {
+			return "", nil, err
+		}
+		if calls == nil {
+			// Only a description of the tool call was recorded
+			return types.ConversationRoleAssistant, []types.ContentBlock{&types.ContentBlockMemberText{Value: fmt.Sprintf("%v", msg.Payload)}}, nil
+		}
+		var blocks []types.ContentBlock
+		for _, call := range calls {
+			blocks = append(blocks, &types.ContentBlockMemberToolUse{Value: types.ToolUseBlock{
+				ToolUseId: aws.String(call.ID),
+				Name:      aws.String(call.Name),
+				Input:     document.NewLazyDocument(call.Arguments),
+			}})
+		}
+		return types.ConversationRoleAssistant, blocks, nil
+
+	case api.MessageTypeToolCallResponse:
+		result, ok, err := functionCallResultFromPayload(msg.Payload)
+		if err != nil {
+			return "", nil, err
+		}
+		if !ok {
+			// The result is not associated with a tool call ID, so replay it as text
+			text, err := payloadToText(msg.Payload)
+			if err != nil {
+				return "", nil, err
+			}
+			return types.ConversationRoleUser, []types.ContentBlock{&types.ContentBlockMemberText{Value: text}}, nil
+		}
+		blocks, err := processContents(toolResults, result)
+		if err != nil {
+			return "", nil, err
+		}
+		return types.ConversationRoleUser, blocks, nil
+
+	default:
+		return "", nil, nil
+	}
+}
+
+// functionCallsFromPayload extracts function calls from a tool call request payload.
+// Payloads restored from a persisted session are generic JSON values, so those are decoded too.
+// It returns nil if the payload does not describe function calls.
+func functionCallsFromPayload(payload any) ([]FunctionCall, error) {
+	switch v := payload.(type) {
+	case FunctionCall:
+		return []FunctionCall{v}, nil
+	case *FunctionCall:
+		return []FunctionCall{*v}, nil
+	case []FunctionCall:
+		return v, nil
+	case map[string]any:
+		if _, ok := v["id"]; !ok {
+			return nil, nil
+		}
+		var call FunctionCall
+		if err := remarshal(v, &call); err != nil {
+			return nil, fmt.Errorf("decoding function call: %w", err)
+		}
+		return []FunctionCall{call}, nil
+	case []any:
+		var calls []FunctionCall
+		if err := remarshal(v, &calls); err != nil {
+			return nil, fmt.Errorf("decoding function calls: %w", err)
+		}
+		return calls, nil
+	default:
+		return nil, nil
+	}
+}
+
+// functionCallResultFromPayload extracts a function call result from a tool call response payload.
+// It returns false if the payload is not tied to a tool call ID.
+func functionCallResultFromPayload(payload any) (FunctionCallResult, bool, error) {
+	switch v := payload.(type) {
+	case FunctionCallResult:
+		return v, true, nil
+	case *FunctionCallResult:
+		return *v, true, nil
+	case map[string]any:
+		if _, ok := v["id"]; !ok {
+			return FunctionCallResult{}, false, nil
+		}
+		if _, ok := v["result"]; !ok {
+			return FunctionCallResult{}, false, nil
+		}
+		var result FunctionCallResult
+		if err := remarshal(v, &result); err != nil {
+			return FunctionCallResult{}, false, fmt.Errorf("decoding function call result: %w", err)
+		}
+		return result, true, nil
+	default:
+		return FunctionCallResult{}, false, nil
+	}
+}
+
+// payloadToText renders a message payload as text, using JSON for structured payloads.
+func payloadToText(payload any) (string, error) {
+	if s, ok := payload.(string); ok {
+		return s, nil
+	}
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return "", fmt.Errorf("encoding payload: %w", err)
+	}
+	return string(b), nil
+}
+
+// remarshal converts a generic JSON value into out by round-tripping it through JSON.
+func remarshal(in any, out any) error {
+	b, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, out)
+}
+
+// processContents converts the contents passed to Send into the blocks of a single user message.
+// Strings become text blocks and FunctionCallResults become tool result blocks.
+// When the model made several tool calls in one turn, all of their results must be
+// sent together, so they are placed first, in order, followed by any text.
+// Results are encoded as configured by toolResults.
+func processContents(toolResults bedrockToolResults, contents ...any) ([]types.ContentBlock, error) {
+	var results, others []types.ContentBlock
+	documents := 0
+	for _, content := range contents {
+		switch v := content.(type) {
+		case string:
+			others = append(others, &types.ContentBlockMemberText{Value: v})
+		case FunctionCallResult:
+			block, err := toolResultBlock(v, toolResults)
+			if err != nil {
+				return nil, err
+			}
+			results = append(results, block)
+		case []FunctionCallResult:
+			for _, result := range v {
+				block, err := toolResultBlock(result, toolResults)
+				if err != nil {
+					return nil, err
+				}
+				results = append(results, block)
+			}
+		case DocumentPart:
+			if documents++; documents > maxBedrockDocuments {
+				return nil, fmt.Errorf("too many documents: Bedrock accepts at most %d per message", maxBedrockDocuments)
+			}
+			block, err := documentBlock(v)
+			if err != nil {
+				return nil, err
+			}
+			others = append(others, block)
+		default:
+			return nil, fmt.Errorf("unexpected type of content: %T", content)
+		}
+	}
+	return append(results, others...), nil
+}
+
+// Limits on the documents attached to a Bedrock message.
+const (
+	maxBedrockDocuments     = 5
+	maxBedrockDocumentBytes = 4_500_000
+)
+
+// bedrockDocumentNamePattern matches the document names Bedrock accepts: letters, digits,
+// single whitespace characters, hyphens, parentheses and square brackets.
+var bedrockDocumentNamePattern = regexp.MustCompile(`^[a-zA-Z0-9\-()\[\]]+(\s[a-zA-Z0-9\-()\[\]]+)*$`)
+
+// documentBlock converts a document to a Bedrock content block, checking it against Bedrock's limits.
+func documentBlock(doc DocumentPart) (types.ContentBlock, error) {
+	if !bedrockDocumentNamePattern.MatchString(doc.Name) {
+		return nil, fmt.Errorf("invalid document name %q: only letters, digits, single spaces, hyphens, parentheses and square brackets are allowed", doc.Name)
+	}
+	format := types.DocumentFormat(strings.ToLower(doc.Format))
+	if !slices.Contains(format.Values(), format) {
+		return nil, fmt.Errorf("document %q: unsupported format %q, expected one of %v", doc.Name, doc.Format, format.Values())
+	}
+	if len(doc.Bytes) == 0 {
+		return nil, fmt.Errorf("document %q is empty", doc.Name)
+	}
+	if len(doc.Bytes) > maxBedrockDocumentBytes {
+		return nil, fmt.Errorf("document %q is %d bytes, larger than the %d bytes Bedrock accepts", doc.Name, len(doc.Bytes), maxBedrockDocumentBytes)
+	}
+	return &types.ContentBlockMemberDocument{Value: types.DocumentBlock{
+		Name:   aws.String(doc.Name),
+		Format: format,
+		Source: &types.DocumentSourceMemberBytes{Value: doc.Bytes},
+	}}, nil
+}
+
+// outstandingToolUseIDs returns the IDs of the tool calls made in the model's last turn,
+// all of which must be answered by the next message.
+func (c *bedrockChat) outstandingToolUseIDs() []string {
+	if len(c.messages) == 0 {
+		return nil
+	}
+	last := c.messages[len(c.messages)-1]
+	if last.Role != types.ConversationRoleAssistant {
+		return nil
+	}
+	var ids []string
+	for _, block := range last.Content {
+		if toolUse, ok := block.(*types.ContentBlockMemberToolUse); ok {
+			ids = append(ids, aws.ToString(toolUse.Value.ToolUseId))
+		}
+	}
+	return ids
+}
+
+// defaultBedrockMaxTokens is the maximum number of tokens generated by a response of
+// a model that is not in bedrockModelMaxTokens.
+const defaultBedrockMaxTokens = 4096
+
+// bedrockModelMaxTokens are the default maximum numbers of tokens generated by the responses
+// of models, by model ID prefix: the most each model can generate, capped at 64000.
+var bedrockModelMaxTokens = []struct {
+	prefix    string
+	maxTokens int32
+}{
+	{"anthropic.claude-opus-4", 32000},
+	{"anthropic.claude-sonnet-4", 64000},
+	{"anthropic.claude-3-7-sonnet", 64000},
+	{"anthropic.claude-3-5-sonnet", 8192},
+	{"anthropic.claude-3-5-haiku", 8192},
+	{"anthropic.claude-3-haiku", 4096},
+	{"amazon.nova-premier", 32000},
+	{"amazon.nova-", 10000},
+	{"mistral.mistral-large-2407", 8192},
+}
+
+// maxTokens returns the maximum number of tokens generated by a response of model:
+// BedrockOptions.MaxTokens if it is set, and the default of the model otherwise.
+func (c *bedrockChat) maxTokens(model string) int32 {
+	if c.client.opts.MaxTokens > 0 {
+		return c.client.opts.MaxTokens
+	}
+	base := baseModelID(model)
+	for _, m := range bedrockModelMaxTokens {
+		if strings.HasPrefix(base, m.prefix) {
+			return m.maxTokens
+		}
+	}
+	return defaultBedrockMaxTokens
+}
+
+// buildConverseInput returns the Converse request for the conversation so far.
+func (c *bedrockChat) buildConverseInput() *bedrockruntime.ConverseInput {
+	return &bedrockruntime.ConverseInput{
+		ModelId:                      aws.String(c.model),
+		Messages:                     c.messages,
+		System:                       c.systemBlocks(),
+		InferenceConfig:              c.inferenceConfig(c.model),
+		ToolConfig:                   c.toolConfiguration(),
+		AdditionalModelRequestFields: c.additionalModelRequestFields(c.model),
+	}
+}
+
+// buildConverseStreamInput returns the ConverseStream request for the conversation so far.
+func (c *bedrockChat) buildConverseStreamInput() *bedrockruntime.ConverseStreamInput {
+	return &bedrockruntime.ConverseStreamInput{
+		ModelId:                      aws.String(c.model),
+		Messages:                     c.messages,
+		System:                       c.systemBlocks(),
+		InferenceConfig:              c.inferenceConfig(c.model),
+		ToolConfig:                   c.toolConfiguration(),
+		AdditionalModelRequestFields: c.additionalModelRequestFields(c.model),
+	}
+}
 
-// NewBedrockClient creates a new client for interacting with AWS Bedrock models
-func NewBedrockClient(ctx context.Context, opts ClientOptions) (*BedrockClient, error) {
-	// Load AWS config with timeout protection
-	configCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
-	defer cancel()
+// bedrockResponseToolName is the name of the tool a chat with a response schema responds with.
+const bedrockResponseToolName = "structured_response"
 
-	cfg, err := config.LoadDefaultConfig(configCtx)
-	if err != nil {
-		return nil, fmt.Errorf("failed to load AWS config: %w", err)
+// toolConfiguration returns the tools of the chat's requests. A chat with a response
+// schema must call its response tool or, if it has any, one of its functions.
+func (c *bedrockChat) toolConfiguration() *types.ToolConfiguration {
+	if c.responseTool == nil {
+		return c.toolConfig
+	}
+	config := &types.ToolConfiguration{
+		ToolChoice: &types.ToolChoiceMemberTool{Value: types.SpecificToolChoice{Name: aws.String(bedrockResponseToolName)}},
+	}
+	if c.toolConfig != nil {
+		config.Tools = slices.Clone(c.toolConfig.Tools)
+		config.ToolChoice = &types.ToolChoiceMemberAny{}
 	}
+	config.Tools = append(config.Tools, c.responseTool)
+	return config
+}
 
-	// Default to us-east-1 for Bedrock if no region is set
-	if cfg.Region == "" {
-		cfg.Region = "us-east-1"
+// structureResponse replaces the calls of the response tool in output with their input,
+// as JSON text. A response that called no other tool is then reported as a finished turn.
+func (c *bedrockChat) structureResponse(output *bedrockruntime.ConverseOutput) error {
+	msg, ok := output.Output.(*types.ConverseOutputMemberMessage)
+	if c.responseTool == nil || !ok {
+		return nil
+	}
+	structured, calls := false, false
+	for i, block := range msg.Value.Content {
+		toolUse, ok := block.(*types.ContentBlockMemberToolUse)
+		if !ok {
+			continue
+		}
+		if aws.ToString(toolUse.Value.Name) != bedrockResponseToolName {
+			calls = true
+			continue
+		}
+		text, err := toolUse.Value.Input.MarshalSmithyDocument()
+		if err != nil {
+			return fmt.Errorf("reading structured response: %w", err)
+		}
+		msg.Value.Content[i] = &types.ContentBlockMemberText{Value: string(text)}
+		structured = true
 	}
+	if structured && !calls && output.StopReason == types.StopReasonToolUse {
+		output.StopReason = types.StopReasonEndTurn
+	}
+	return nil
+}
 
-	return &BedrockClient{
-		client: bedrockruntime.NewFromConfig(cfg),
-	}, nil
+// inferenceConfig returns the inference parameters of the chat's requests to model.
+func (c *bedrockChat) inferenceConfig(model string) *types.InferenceConfiguration {
+	return &types.InferenceConfiguration{
+		MaxTokens:   aws.Int32(c.maxTokens(model)),
+		Temperature: c.client.opts.Temperature,
+		TopP:        c.client.opts.TopP,
+	}
 }
 
-// Close cleans up any resources used by the client
-func (c *BedrockClient) Close() error {
+// validateInferenceParameters checks the temperature and top P of opts against the
+// ranges model accepts, so that they fail with a clear error rather than an AWS ValidationException.
+func validateInferenceParameters(model string, opts BedrockOptions) error {
+	// Every supported model family accepts a temperature and top P between 0 and 1,
+	// except Cohere Command models, whose top P must be below 1.
+	maxTopP := float32(1)
+	if strings.HasPrefix(baseModelID(model), "cohere.") {
+		maxTopP = 0.99
+	}
+	if t := opts.Temperature; t != nil && (*t < 0 || *t > 1) {
+		return fmt.Errorf("%w: temperature %v is outside the range [0, 1] of bedrock model %q", ErrInvalidInferenceParameter, *t, model)
+	}
+	if p := opts.TopP; p != nil && (*p < 0 || *p > maxTopP) {
+		return fmt.Errorf("%w: top P %v is outside the range [0, %v] of bedrock model %q", ErrInvalidInferenceParameter, *p, maxTopP, model)
+	}
 	return nil
 }
 
-// StartChat starts a new chat session with the specified system prompt and model
-func (c *BedrockClient) StartChat(systemPrompt, model string) Chat {
-	selectedModel := getBedrockModel(model)
-
-	klog.V(1).Infof("Starting new Bedrock chat session with model: %s", selectedModel)
+// requestMetadata returns the metadata recorded with a request made with ctx in the
+// invocation logs: the configured metadata, the request's idempotency key, so that the
+// attempts of a retried request can be told apart from distinct requests, and its
+// request ID, if any.
+func requestMetadata(ctx context.Context, configured map[string]string) map[string]string {
+	key, ok := IdempotencyKeyFromContext(ctx)
+	if !ok {
+		key = uuid.NewString()
+	}
+	metadata := maps.Clone(configured)
+	if metadata == nil {
+		metadata = make(map[string]string)
+	}
+	metadata["idempotencyKey"] = key
+	if id, ok := RequestIDFromContext(ctx); ok {
+		metadata["requestID"] = id
+	}
+	return metadata
+}
 
-	// Enhance system prompt for tool-use shim compatibility
-	// Detect if tool-use shim is enabled by looking for JSON formatting instructions
-	enhancedPrompt := systemPrompt
-	if strings.Contains(systemPrompt, "```json") && strings.Contains(systemPrompt, "\"action\"") {
-		// Tool-use shim is enabled - add stronger JSON formatting instructions for all Bedrock models
-		enhancedPrompt += "\n\nCRITICAL JSON FORMATTING REQUIREMENTS:\n"
-		enhancedPrompt += "1. You MUST ALWAYS wrap your JSON responses in ```json code blocks exactly as shown in the examples above.\n"
-		enhancedPrompt += "2. NEVER respond with raw JSON without the markdown ```json formatting.\n"
-		enhancedPrompt += "3. Ensure your JSON is syntactically correct with proper commas between fields.\n"
-		enhancedPrompt += "4. This is critical for proper parsing. Example format:\n"
-		enhancedPrompt += "```json\n{\"thought\": \"your reasoning\", \"action\": {\"name\": \"tool_name\", \"command\": \"command\"}}\n```\n"
-		enhancedPrompt += "Note the comma after the \"thought\" field! Malformed JSON will cause failures."
+// additionalModelRequestFields returns the model-specific parameters of a request to model, if there are any.
+func (c *bedrockChat) additionalModelRequestFields(model string) document.Interface {
+	fields := c.modelRequestFields(model)
+	if len(fields) == 0 {
+		return nil
+	}
+	return document.NewLazyDocument(fields)
+}
 
-		klog.V(2).Infof("Enhanced Bedrock prompt with JSON formatting instructions for model: %s", selectedModel)
+// modelRequestFields returns the configured AdditionalModelRequestFields, with TopK
+// added as top_k for the models that accept it. Explicitly configured fields take precedence.
+func (c *bedrockChat) modelRequestFields(model string) map[string]any {
+	opts := c.client.opts
+	if opts.TopK <= 0 || !supportsTopK(model) {
+		return opts.AdditionalModelRequestFields
+	}
+	fields := map[string]any{"top_k": opts.TopK}
+	for k, v := range opts.AdditionalModelRequestFields {
+		fields[k] = v
 	}
+	return fields
+}
 
-	return &bedrockChat{
-		client:       c,
-		systemPrompt: enhancedPrompt,
-		model:        selectedModel,
-		messages:     []types.Message{},
+// supportsTopK returns true if the model accepts the top_k additional request field.
+func supportsTopK(model string) bool {
+	return strings.HasPrefix(baseModelID(model), "anthropic.claude-")
+}
+
+// systemTexts returns the non-empty blocks of the system prompt: the system prompt
+// itself, followed by any further blocks of context.
+func (c *bedrockChat) systemTexts() []string {
+	texts := append([]string{c.systemPrompt}, c.systemContext...)
+	return slices.DeleteFunc(texts, func(text string) bool { return text == "" })
+}
+
+// systemBlocks returns the system prompt of a request, if there is one, with one block per text.
+func (c *bedrockChat) systemBlocks() []types.SystemContentBlock {
+	var blocks []types.SystemContentBlock
+	for _, text := range c.systemTexts() {
+		blocks = append(blocks, &types.SystemContentBlockMemberText{Value: text})
 	}
+	return blocks
 }
 
-// GenerateCompletion generates a single completion for the given request
-func (c *BedrockClient) GenerateCompletion(ctx context.Context, req *CompletionRequest) (CompletionResponse, error) {
-	chat := c.StartChat("", req.Model)
-	chatResponse, err := chat.Send(ctx, req.Prompt)
+// bedrockDryRunRequest is the provider-neutral form of a request described in dry-run mode.
+type bedrockDryRunRequest struct {
+	ModelID   string                `json:"modelId"`
+	System    string                `json:"system,omitempty"`
+	Messages  []HistoryMessage      `json:"messages"`
+	Tools     []*FunctionDefinition `json:"tools,omitempty"`
+	MaxTokens int                   `json:"maxTokens"`
+	Stream    bool                  `json:"stream"`
+
+	AdditionalModelRequestFields map[string]any    `json:"additionalModelRequestFields,omitempty"`
+	RequestMetadata              map[string]string `json:"requestMetadata,omitempty"`
+}
+
+// dryRun describes the request for the conversation so far instead of sending it.
+// The caller rolls back the turn afterwards, so that the history is left unchanged.
+func (c *bedrockChat) dryRun(stream bool) (ChatResponse, error) {
+	messages, err := historyMessages(c.messages)
 	if err != nil {
 		return nil, err
 	}
+	request, err := json.Marshal(bedrockDryRunRequest{
+		ModelID:   c.model,
+		System:    strings.Join(c.systemTexts(), "\n\n"),
+		Messages:  messages,
+		Tools:     c.functionDefs,
+		MaxTokens: int(c.maxTokens(c.model)),
+		Stream:    stream,
+
+		AdditionalModelRequestFields: c.modelRequestFields(c.model),
+		RequestMetadata:              c.client.opts.RequestMetadata,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("serializing dry-run request: %w", err)
+	}
+	return &dryRunResponse{request: &DryRunRequest{
+		Provider:             "bedrock",
+		Model:                c.model,
+		Request:              request,
+		EstimatedInputTokens: c.client.countTokens(string(request)),
+	}}, nil
+}
 
-	// Wrap ChatResponse in a CompletionResponse
-	return &bedrockCompletionResponse{
-		chatResponse: chatResponse,
-	}, nil
+// checkToolResults verifies that the tool results in a new user message answer exactly
+// the outstanding tool calls, which Bedrock would otherwise reject with a ValidationException.
+func (c *bedrockChat) checkToolResults(blocks []types.ContentBlock) error {
+	outstanding := c.outstandingToolUseIDs()
+	answered := make(map[string]bool)
+	for _, block := range blocks {
+		result, ok := block.(*types.ContentBlockMemberToolResult)
+		if !ok {
+			continue
+		}
+		id := aws.ToString(result.Value.ToolUseId)
+		if !slices.Contains(outstanding, id) {
+			if len(outstanding) == 0 {
+				return fmt.Errorf("%w: %q (no tool calls are outstanding)", ErrUnmatchedToolResult, id)
+			}
+			return fmt.Errorf("%w: %q (outstanding: %s)", ErrUnmatchedToolResult, id, strings.Join(outstanding, ", "))
+		}
+		answered[id] = true
+	}
+
+	var missing []string
+	for _, id := range outstanding {
+		if !answered[id] {
+			missing = append(missing, id)
+		}
+	}
+	if len(missing) != 0 {
+		return fmt.Errorf("%w: %s", ErrMissingToolResult, strings.Join(missing, ", "))
+	}
+	return nil
 }
 
-// SetResponseSchema sets the response schema for the client (not supported by Bedrock)
-func (c *BedrockClient) SetResponseSchema(schema *Schema) error {
-	return fmt.Errorf("response schema not supported by Bedrock")
+// orderToolResults sorts the tool results in a new user message, which processContents
+// places first, in the order of the outstanding tool calls they answer, so that results
+// of tool calls run concurrently may be sent in the order they completed.
+func (c *bedrockChat) orderToolResults(blocks []types.ContentBlock) {
+	outstanding := c.outstandingToolUseIDs()
+	results := slices.IndexFunc(blocks, func(block types.ContentBlock) bool {
+		_, ok := block.(*types.ContentBlockMemberToolResult)
+		return !ok
+	})
+	if results < 0 {
+		results = len(blocks)
+	}
+	slices.SortStableFunc(blocks[:results], func(a, b types.ContentBlock) int {
+		idA := aws.ToString(a.(*types.ContentBlockMemberToolResult).Value.ToolUseId)
+		idB := aws.ToString(b.(*types.ContentBlockMemberToolResult).Value.ToolUseId)
+		return slices.Index(outstanding, idA) - slices.Index(outstanding, idB)
+	})
 }
 
-// ListModels returns the list of supported Bedrock models
-func (c *BedrockClient) ListModels(ctx context.Context) ([]string, error) {
-	return []string{
-		"us.anthropic.claude-sonnet-4-20250514-v1:0",   // Claude Sonnet 4 (default)
-		"us.anthropic.claude-3-7-sonnet-20250219-v1:0", // Claude 3.7 Sonnet
-	}, nil
+// trimHistory drops the oldest messages of the conversation beyond MaxHistoryMessages.
+// The kept conversation starts at a user message that does not answer tool calls, so
+// that tool calls are not separated from their results; this keeps fewer messages than
+// the limit, or more when the recent messages are a single, longer exchange of tool calls.
+func (c *bedrockChat) trimHistory() {
+	limit := c.client.opts.MaxHistoryMessages
+	if limit <= 0 || len(c.messages) <= limit {
+		return
+	}
+
+	cut := len(c.messages) - limit
+	start := slices.IndexFunc(c.messages[cut:], isConversationStart)
+	if start >= 0 {
+		start += cut
+	} else {
+		for start = cut - 1; start > 0 && !isConversationStart(c.messages[start]); start-- {
+		}
+	}
+	if start > 0 {
+		c.messages = slices.Clone(c.messages[start:])
+	}
 }
 
-// bedrockChat implements the Chat interface for Bedrock conversations
-type bedrockChat struct {
-	client       *BedrockClient
-	systemPrompt string
-	model        string
-	messages     []types.Message
-	toolConfig   *types.ToolConfiguration
-	functionDefs []*FunctionDefinition
+// beginTurn adds the user message of a turn, made of blocks, to the history, and trims the
+// history to MaxHistoryMessages. It returns a function that restores the history as it was
+// before, for a turn that fails without an answer, so that neither the unanswered message
+// nor the messages trimmed to make room for it are left behind or lost.
+func (c *bedrockChat) beginTurn(blocks []types.ContentBlock) (rollback func()) {
+	history := c.messages
+	c.messages = append(c.messages, types.Message{
+		Role:    types.ConversationRoleUser,
+		Content: blocks,
+	})
+	c.trimHistory()
+	return func() { c.messages = history }
 }
 
-func (cs *bedrockChat) Initialize(history []*api.Message) error {
-	klog.Warning("chat history persistence is not supported for provider 'bedrock', using in-memory chat history")
-	return nil
+// isToolUseBlock returns true if block is a tool call.
+func isToolUseBlock(block types.ContentBlock) bool {
+	_, ok := block.(*types.ContentBlockMemberToolUse)
+	return ok
+}
+
+// isConversationStart returns true if a conversation can start with msg,
+// that is if it is a user message that does not answer tool calls.
+func isConversationStart(msg types.Message) bool {
+	return msg.Role == types.ConversationRoleUser && !slices.ContainsFunc(msg.Content, func(block types.ContentBlock) bool {
+		_, ok := block.(*types.ContentBlockMemberToolResult)
+		return ok
+	})
+}
+
+// bedrockToolResults configures how function call results are sent to Bedrock.
+type bedrockToolResults struct {
+	// format is the content type of the results. It defaults to ToolResultFormatJSON.
+	format ToolResultFormat
+	// maxBytes, if positive, truncates the JSON of the results to this size.
+	maxBytes int
+}
+
+// toolResultBlock converts a function call result to a tool result block, with JSON or text
+// content depending on the configured format. A result whose JSON is larger than the
+// configured maximum is truncated, and sent as text since it is no longer valid JSON.
+func toolResultBlock(result FunctionCallResult, toolResults bedrockToolResults) (types.ContentBlock, error) {
+	status := types.ToolResultStatusSuccess
+	if result.IsError {
+		status = types.ToolResultStatusError
+	}
+	var content types.ToolResultContentBlock = &types.ToolResultContentBlockMemberJson{Value: document.NewLazyDocument(result.Result)}
+	if toolResults.maxBytes > 0 || toolResults.format == ToolResultFormatText {
+		encoded, err := json.Marshal(result.Result)
+		if err != nil {
+			return nil, fmt.Errorf("marshalling result of function %q: %w", result.Name, err)
+		}
+		text, truncated := truncateToolResult(encoded, toolResults.maxBytes)
+		if truncated || toolResults.format == ToolResultFormatText {
+			content = &types.ToolResultContentBlockMemberText{Value: text}
+		}
+	}
+	return &types.ContentBlockMemberToolResult{Value: types.ToolResultBlock{
+		ToolUseId: aws.String(result.ID),
+		Content:   []types.ToolResultContentBlock{content},
+		Status:    status,
+	}}, nil
+}
+
+// overrideModel switches the chat to the model of a ModelOverride in contents, if there is
+// one and it is valid, and returns the other contents.
+func (c *bedrockChat) overrideModel(contents []any) ([]any, error) {
+	override, rest := takeModelOverride(contents)
+	if override == "" {
+		return contents, nil
+	}
+	model := string(override)
+	if c.client.opts.AutoInferenceProfile {
+		model = applyInferenceProfilePrefix(model, c.client.region)
+	}
+	if err := c.client.validateChatModel(model); err != nil {
+		return nil, fmt.Errorf("overriding model: %w", err)
+	}
+	c.client.log().Debug("overriding Bedrock chat model", "from", c.model, "to", model)
+	if supported, reason := ModelSupportReason(model); !supported {
+		c.client.log().Warn("Bedrock model is not known to work with the Converse API, using it anyway", "model", model, "reason", reason)
+	}
+	c.model, c.modelErr = model, nil
+	return rest, nil
 }
 
 // Send sends a message to the chat and returns the response
 func (c *bedrockChat) Send(ctx context.Context, contents ...any) (ChatResponse, error) {
+	contents, err := c.overrideModel(contents)
+	if err != nil {
+		return nil, err
+	}
+	if c.modelErr != nil {
+		return nil, c.modelErr
+	}
+	ctx, done, err := c.client.requestContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
 	if len(contents) == 0 {
 		return nil, errors.New("no content provided")
 	}
 
-	// Convert content to string
-	message := fmt.Sprintf("%v", contents[0])
+	blocks, err := processContents(c.client.toolResults, contents...)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.checkToolResults(blocks); err != nil {
+		return nil, err
+	}
+	c.orderToolResults(blocks)
 
 	// Add user message to conversation history
-	c.messages = append(c.messages, types.Message{
-		Role: types.ConversationRoleUser,
-		Content: []types.ContentBlock{
-			&types.ContentBlockMemberText{Value: message},
-		},
-	})
+	rollback := c.beginTurn(blocks)
 
-	// Prepare the request
-	input := &bedrockruntime.ConverseInput{
-		ModelId:  aws.String(c.model),
-		Messages: c.messages,
-		InferenceConfig: &types.InferenceConfiguration{
-			MaxTokens: aws.Int32(4096),
-		},
+	if c.client.opts.DryRun {
+		defer rollback()
+		return c.dryRun(false)
 	}
-
-	// Add system prompt if provided
-	if c.systemPrompt != "" {
-		input.System = []types.SystemContentBlock{
-			&types.SystemContentBlockMemberText{Value: c.systemPrompt},
-		}
+	input := c.buildConverseInput()
+	input.RequestMetadata = requestMetadata(ctx, c.client.opts.RequestMetadata)
+
+	// Call the Bedrock Converse API, falling back to other models if needed
+	var output *bedrockruntime.ConverseOutput
+	var latency time.Duration
+	target, err := c.withFallback(ctx, func(target bedrockTarget) (err error) {
+		input.ModelId = aws.String(target.model)
+		input.InferenceConfig = c.inferenceConfig(target.model)
+		input.AdditionalModelRequestFields = c.additionalModelRequestFields(target.model)
+		start := c.client.clock()
+		output, err = target.region.runtime.Converse(ctx, input)
+		latency = c.client.clock().Sub(start)
+		return err
+	})
+	model := target.model
+	if err != nil {
+		// Drop the user message so that a retried Send does not duplicate it
+		rollback()
+		return nil, fmt.Errorf("bedrock converse error: %w", c.classifyError(model, err))
 	}
-
-	// Add tool configuration if functions are defined
-	if c.toolConfig != nil {
-		input.ToolConfig = c.toolConfig
+	if c.client.opts.AutoContinue {
+		start := c.client.clock()
+		output = c.continueTruncated(ctx, target.region.runtime, input, output)
+		latency += c.client.clock().Sub(start)
 	}
-
-	// Call the Bedrock Converse API
-	output, err := c.client.client.Converse(ctx, input)
-	if err != nil {
-		return nil, fmt.Errorf("bedrock converse error: %w", err)
+	if err := c.structureResponse(output); err != nil {
+		rollback()
+		return nil, err
 	}
 
 	// Extract response content and update conversation history
 	response := &bedrockResponse{
-		output: output,
-		model:  c.model,
+		output:    output,
+		model:     model,
+		region:    target.region.name,
+		latency:   latency,
+		normalize: c.normalizeOutput,
 	}
 
 	// Update conversation history with assistant's response
+	var content []types.ContentBlock
 	if output.Output != nil {
 		if msg, ok := output.Output.(*types.ConverseOutputMemberMessage); ok {
 			c.messages = append(c.messages, msg.Value)
+			content = msg.Value.Content
 		}
 	}
+	c.logResponse(ctx, model, output.StopReason, content, output.Usage)
 
 	return response, nil
 }
 
-// SendStreaming sends a message and returns a streaming response
+// SendStreaming sends a message and returns a streaming response.
+// If the caller stops iterating early, the content received so far is kept in the history.
 func (c *bedrockChat) SendStreaming(ctx context.Context, contents ...any) (ChatResponseIterator, error) {
+	contents, err := c.overrideModel(contents)
+	if err != nil {
+		return nil, err
+	}
+	if c.modelErr != nil {
+		return nil, c.modelErr
+	}
+	ctx, done, err := c.client.requestContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// Once the stream is handed to the caller, it is over when the caller stops iterating
+	streaming := false
+	defer func() {
+		if !streaming {
+			done()
+		}
+	}()
 	if len(contents) == 0 {
 		return nil, errors.New("no content provided")
 	}
 
-	// Convert content to string
-	message := fmt.Sprintf("%v", contents[0])
+	blocks, err := processContents(c.client.toolResults, contents...)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.checkToolResults(blocks); err != nil {
+		return nil, err
+	}
+	c.orderToolResults(blocks)
 
 	// Add user message to conversation history
+	rollback := c.beginTurn(blocks)
+
+	if c.client.opts.DryRun {
+		defer rollback()
+		response, err := c.dryRun(true)
+		if err != nil {
+			return nil, err
+		}
+		return singletonChatResponseIterator(response), nil
+	}
+	input := c.buildConverseStreamInput()
+	input.RequestMetadata = requestMetadata(ctx, c.client.opts.RequestMetadata)
+
+	// Start the streaming request, falling back to other models if needed
+	start := c.client.clock()
+	var stream bedrockEventStream
+	target, err := c.withFallback(ctx, func(target bedrockTarget) (err error) {
+		input.ModelId = aws.String(target.model)
+		input.InferenceConfig = c.inferenceConfig(target.model)
+		input.AdditionalModelRequestFields = c.additionalModelRequestFields(target.model)
+		stream, err = target.region.runtime.ConverseStream(ctx, input)
+		return err
+	})
+	model := target.model
+	if err != nil {
+		// Drop the user message so that a retried SendStreaming does not duplicate it
+		rollback()
+		return nil, fmt.Errorf("bedrock stream error: %w", c.classifyError(model, err))
+	}
+
+	// Return streaming iterator
+	streaming = true
+	return func(yield func(ChatResponse, error) bool) {
+		defer done()
+		defer func() { stream.Close() }()
+
+		content := &streamedContent{}
+		defer c.recordStreamedTurn(content, rollback)
+		var stopReason types.StopReason
+		var usage *types.TokenUsage
+		defer func() { c.logResponse(ctx, model, stopReason, content.blocks(), usage) }()
+		receivedEvents := false
+		stats := &StreamStats{}
+
+		// A resumed stream numbers its blocks from zero again, continuing the last block received,
+		// and may repeat the trailing whitespace that could not be sent back to the model.
+		var indexOffset int32
+		var repeated string
+		// held is the text kept back from the caller until the end of the stream, to be normalized
+		var held strings.Builder
+		// responseBlocks are the indexes of the calls of the response tool, whose input is streamed as text
+		responseBlocks := map[int32]bool{}
+		releaseHeld := func() string {
+			text := held.String()
+			held.Reset()
+			if text == "" {
+				return ""
+			}
+			return c.normalizeOutput(text)
+		}
+
+		// Process streaming events until the stream ends, or the request is cancelled
+		events := stream.Events()
+	eventLoop:
+		for resumes := 0; ; {
+			var event types.ConverseStreamOutput
+			select {
+			case <-ctx.Done():
+				yield(nil, context.Cause(ctx))
+				return
+			case e, ok := <-events:
+				if ok {
+					event = e
+					break
+				}
+				err := stream.Err()
+				if err == nil || !c.client.opts.StreamRetry || resumes == maxStreamResumes || !c.IsRetryableError(err) {
+					break eventLoop
+				}
+				resumed, trimmed, ok := c.resumeInput(input, content)
+				if !ok {
+					break eventLoop
+				}
+				resumedStream, resumeErr := target.region.runtime.ConverseStream(ctx, resumed)
+				if resumeErr != nil {
+					break eventLoop
+				}
+				c.client.logFor(ctx).Debug("Resuming Bedrock stream", "model", model, "error", err)
+				stream.Close()
+				stream, events = resumedStream, resumedStream.Events()
+				resumes++
+				indexOffset, repeated = content.lastIndex(), trimmed
+				continue
+			}
+			receivedEvents = true
+			switch v := event.(type) {
+			case *types.ConverseStreamOutputMemberContentBlockStart:
+				// Tool calls start with their ID and name; their input follows as deltas
+				if start, ok := v.Value.Start.(*types.ContentBlockStartMemberToolUse); ok {
+					index := indexOffset + aws.ToInt32(v.Value.ContentBlockIndex)
+					if c.responseTool != nil && aws.ToString(start.Value.Name) == bedrockResponseToolName {
+						responseBlocks[index] = true
+						continue
+					}
+					content.startToolUse(index, start.Value)
+				}
+
+			case *types.ConverseStreamOutputMemberContentBlockDelta:
+				stats.Chunks++
+				if stats.Chunks == 1 {
+					stats.TimeToFirstToken = c.client.clock().Sub(start)
+				}
+				index := indexOffset + aws.ToInt32(v.Value.ContentBlockIndex)
+				var text string
+				switch delta := v.Value.Delta.(type) {
+				case *types.ContentBlockDeltaMemberText:
+					text = delta.Value
+				case *types.ContentBlockDeltaMemberToolUse:
+					if !responseBlocks[index] {
+						content.appendToolInput(index, aws.ToString(delta.Value.Input))
+						continue
+					}
+					// The input of the response tool is the structured response
+					text = aws.ToString(delta.Value.Input)
+				}
+				if repeated != "" {
+					text = strings.TrimPrefix(text, repeated)
+					repeated = ""
+				}
+				if text == "" {
+					continue
+				}
+				content.appendText(index, text)
+				if c.normalizeOutput != nil {
+					// The text is normalized as a whole, and returned with the final response
+					held.WriteString(text)
+					continue
+				}
+
+				response := &bedrockStreamResponse{
+					content: text,
+					model:   model,
+					done:    false,
+				}
+
+				if !yield(response, nil) {
+					return
+				}
+
+			case *types.ConverseStreamOutputMemberContentBlockStop:
+				// The input of a tool call is only valid JSON once the block is complete
+				toolUse, err := content.finishToolUse(indexOffset + aws.ToInt32(v.Value.ContentBlockIndex))
+				if err != nil {
+					yield(nil, err)
+					return
+				}
+				if toolUse != nil {
+					response := &bedrockStreamResponse{
+						toolUse: toolUse,
+						model:   model,
+					}
+					if !yield(response, nil) {
+						return
+					}
+				}
+
+			case *types.ConverseStreamOutputMemberMessageStop:
+				stopReason = v.Value.StopReason
+				if stopReason == types.StopReasonToolUse && len(responseBlocks) != 0 && len(content.toolUses) == 0 {
+					stopReason = types.StopReasonEndTurn
+				}
+
+			case *types.ConverseStreamOutputMemberMetadata:
+				// Handle final usage metadata, and report why the response ended
+				usage = v.Value.Usage
+				stats.Duration = c.client.clock().Sub(start)
+				if v.Value.Usage != nil || stopReason != "" || held.Len() > 0 {
+					finalResponse := &bedrockStreamResponse{
+						content:    releaseHeld(),
+						usage:      v.Value.Usage,
+						model:      model,
+						region:     target.region.name,
+						done:       true,
+						stopReason: stopReason,
+						stats:      stats,
+						metrics:    streamResponseMetrics(v.Value.Metrics, stats.Duration),
+					}
+					if !yield(finalResponse, nil) {
+						return
+					}
+				}
+			}
+		}
+
+		// Check for stream errors
+		if err := stream.Err(); err != nil {
+			yield(nil, fmt.Errorf("stream error: %w", err))
+			return
+		}
+		if held.Len() > 0 {
+			if !yield(&bedrockStreamResponse{content: releaseHeld(), model: model}, nil) {
+				return
+			}
+		}
+
+		// Distinguish a stream that silently produced nothing from one that is still running
+		if !receivedEvents {
+			yield(nil, ErrEmptyStream)
+		}
+	}, nil
+}
+
+// continueTruncated requests the continuation of output, the response to input, while it is
+// cut off at the token limit, by sending the text generated so far back as the start of the
+// assistant message. It returns the response stitched together from output and its continuations;
+// if a continuation fails, the response so far is returned, still reporting the token limit.
+func (c *bedrockChat) continueTruncated(ctx context.Context, runtime bedrockAPI, input *bedrockruntime.ConverseInput, output *bedrockruntime.ConverseOutput) *bedrockruntime.ConverseOutput {
+	for range maxAutoContinues {
+		msg, ok := output.Output.(*types.ConverseOutputMemberMessage)
+		if output.StopReason != types.StopReasonMaxTokens || !ok || slices.ContainsFunc(msg.Value.Content, isToolUseBlock) {
+			break
+		}
+		// Bedrock rejects trailing whitespace in the final assistant message
+		blocks := slices.Clone(msg.Value.Content)
+		if len(blocks) > 0 {
+			if last, ok := blocks[len(blocks)-1].(*types.ContentBlockMemberText); ok {
+				if trimmed := strings.TrimRightFunc(last.Value, unicode.IsSpace); trimmed != "" {
+					blocks[len(blocks)-1] = &types.ContentBlockMemberText{Value: trimmed}
+				} else {
+					blocks = blocks[:len(blocks)-1]
+				}
+			}
+		}
+		if len(blocks) == 0 {
+			break
+		}
+
+		continued := *input
+		continued.Messages = append(slices.Clone(input.Messages), types.Message{
+			Role:    types.ConversationRoleAssistant,
+			Content: blocks,
+		})
+		next, err := runtime.Converse(ctx, &continued)
+		if err != nil {
+			c.client.logFor(ctx).Warn("Failed to continue truncated Bedrock response", "model", aws.ToString(input.ModelId), "error", err)
+			break
+		}
+		c.client.logFor(ctx).Debug("Continued truncated Bedrock response", "model", aws.ToString(input.ModelId))
+		output = stitchOutputs(blocks, output, next)
+	}
+	return output
+}
+
+// stitchOutputs returns the response made of blocks, the content of the truncated response
+// prev, followed by the content of its continuation next. The text at the seam is joined into
+// a single block, and the usage and latency of both responses are added up.
+func stitchOutputs(blocks []types.ContentBlock, prev, next *bedrockruntime.ConverseOutput) *bedrockruntime.ConverseOutput {
+	stitched := *next
+	if msg, ok := next.Output.(*types.ConverseOutputMemberMessage); ok {
+		content := slices.Clone(blocks)
+		rest := msg.Value.Content
+		if len(rest) > 0 {
+			last, lastIsText := content[len(content)-1].(*types.ContentBlockMemberText)
+			first, firstIsText := rest[0].(*types.ContentBlockMemberText)
+			if lastIsText && firstIsText {
+				content[len(content)-1] = &types.ContentBlockMemberText{Value: last.Value + first.Value}
+				rest = rest[1:]
+			}
+		}
+		stitched.Output = &types.ConverseOutputMemberMessage{Value: types.Message{
+			Role:    types.ConversationRoleAssistant,
+			Content: append(content, rest...),
+		}}
+	}
+	if prev.Usage != nil && next.Usage != nil {
+		stitched.Usage = &types.TokenUsage{
+			InputTokens:  aws.Int32(aws.ToInt32(prev.Usage.InputTokens) + aws.ToInt32(next.Usage.InputTokens)),
+			OutputTokens: aws.Int32(aws.ToInt32(prev.Usage.OutputTokens) + aws.ToInt32(next.Usage.OutputTokens)),
+			TotalTokens:  aws.Int32(aws.ToInt32(prev.Usage.TotalTokens) + aws.ToInt32(next.Usage.TotalTokens)),
+		}
+	}
+	if prev.Metrics != nil && next.Metrics != nil {
+		stitched.Metrics = &types.ConverseMetrics{
+			LatencyMs: aws.Int64(aws.ToInt64(prev.Metrics.LatencyMs) + aws.ToInt64(next.Metrics.LatencyMs)),
+		}
+	}
+	return &stitched
+}
+
+// logResponse logs a single structured event summarizing a model response.
+func (c *bedrockChat) logResponse(ctx context.Context, model string, stopReason types.StopReason, content []types.ContentBlock, usage *types.TokenUsage) {
+	var tools []string
+	for _, block := range content {
+		if toolUse, ok := block.(*types.ContentBlockMemberToolUse); ok {
+			tools = append(tools, aws.ToString(toolUse.Value.Name))
+		}
+	}
+	attrs := []any{"model", model, "stopReason", string(stopReason)}
+	if len(tools) > 0 {
+		attrs = append(attrs, "tools", tools)
+	}
+	if usage != nil {
+		attrs = append(attrs, "inputTokens", aws.ToInt32(usage.InputTokens), "outputTokens", aws.ToInt32(usage.OutputTokens))
+	}
+	c.client.logFor(ctx).Debug("Bedrock response", attrs...)
+}
+
+// resumeInput returns the request continuing the streamed response received so far in
+// content, which ends the messages of input with the text received as an assistant message.
+// Its trailing whitespace is trimmed, as Bedrock rejects it there, and returned, so that the
+// continuation does not repeat it. It returns false if the response includes tool calls,
+// which could not be continued without repeating them.
+func (c *bedrockChat) resumeInput(input *bedrockruntime.ConverseStreamInput, content *streamedContent) (*bedrockruntime.ConverseStreamInput, string, bool) {
+	if len(content.toolUses) > 0 {
+		return nil, "", false
+	}
+	resumed := *input
+	blocks := content.blocks()
+	if len(blocks) == 0 {
+		return &resumed, "", true
+	}
+
+	last := blocks[len(blocks)-1].(*types.ContentBlockMemberText).Value
+	trimmedText := strings.TrimRightFunc(last, unicode.IsSpace)
+	blocks[len(blocks)-1] = &types.ContentBlockMemberText{Value: trimmedText}
+	if trimmedText == "" {
+		blocks = blocks[:len(blocks)-1]
+	}
+	resumed.Messages = slices.Clone(input.Messages)
+	if len(blocks) > 0 {
+		resumed.Messages = append(resumed.Messages, types.Message{
+			Role:    types.ConversationRoleAssistant,
+			Content: blocks,
+		})
+	}
+	return &resumed, last[len(trimmedText):], true
+}
+
+// recordStreamedTurn records the assistant content of a finished stream in the history.
+// It is called however the stream ends: completed, failed, or abandoned by the caller.
+// Whatever text and completed tool calls were received are kept, so a caller that stops
+// iterating early continues the chat from what it has seen; tool calls whose input was
+// still streaming are discarded. If nothing was received, the turn is rolled back
+// instead, so that the history stays sendable.
+func (c *bedrockChat) recordStreamedTurn(content *streamedContent, rollback func()) {
+	blocks := content.blocks()
+	if len(blocks) == 0 {
+		rollback()
+		return
+	}
 	c.messages = append(c.messages, types.Message{
-		Role: types.ConversationRoleUser,
-		Content: []types.ContentBlock{
-			&types.ContentBlockMemberText{Value: message},
-		},
+		Role:    types.ConversationRoleAssistant,
+		Content: blocks,
 	})
+}
+
+// streamedContent assembles the content blocks of a streamed assistant message.
+type streamedContent struct {
+	// order holds the block indexes in the order they were first seen
+	order    []int32
+	text     map[int32]*strings.Builder
+	toolUses map[int32]*streamedToolUse
+}
+
+// streamedToolUse is a tool call whose input is still being streamed.
+type streamedToolUse struct {
+	start types.ToolUseBlockStart
+	input strings.Builder
+	block *types.ToolUseBlock
+}
 
-	// Prepare the streaming request
-	input := &bedrockruntime.ConverseStreamInput{
-		ModelId:  aws.String(c.model),
-		Messages: c.messages,
-		InferenceConfig: &types.InferenceConfiguration{
-			MaxTokens: aws.Int32(4096),
-		},
+func (s *streamedContent) see(index int32) {
+	if _, ok := s.text[index]; ok {
+		return
 	}
-
-	// Add system prompt if provided
-	if c.systemPrompt != "" {
-		input.System = []types.SystemContentBlock{
-			&types.SystemContentBlockMemberText{Value: c.systemPrompt},
-		}
+	if _, ok := s.toolUses[index]; ok {
+		return
 	}
+	s.order = append(s.order, index)
+}
 
-	// Add tool configuration if functions are defined
-	if c.toolConfig != nil {
-		input.ToolConfig = c.toolConfig
+// lastIndex returns the index of the last block seen, or zero if there is none.
+func (s *streamedContent) lastIndex() int32 {
+	if len(s.order) == 0 {
+		return 0
 	}
+	return s.order[len(s.order)-1]
+}
 
-	// Start the streaming request
-	output, err := c.client.client.ConverseStream(ctx, input)
-	if err != nil {
-		return nil, fmt.Errorf("bedrock stream error: %w", err)
+func (s *streamedContent) appendText(index int32, text string) {
+	if s.text == nil {
+		s.text = make(map[int32]*strings.Builder)
 	}
+	s.see(index)
+	if s.text[index] == nil {
+		s.text[index] = &strings.Builder{}
+	}
+	s.text[index].WriteString(text)
+}
 
-	// Return streaming iterator
-	return func(yield func(ChatResponse, error) bool) {
-		defer func() {
-			if stream := output.GetStream(); stream != nil {
-				stream.Close()
-			}
-		}()
-
-		var assistantMessage types.Message
-		assistantMessage.Role = types.ConversationRoleAssistant
-		var fullContent strings.Builder
-
-		// Process streaming events
-		stream := output.GetStream()
-		for event := range stream.Events() {
-			switch v := event.(type) {
-			case *types.ConverseStreamOutputMemberContentBlockDelta:
-				// Handle text deltas
-				if textDelta, ok := v.Value.Delta.(*types.ContentBlockDeltaMemberText); ok {
-					fullContent.WriteString(textDelta.Value)
-
-					response := &bedrockStreamResponse{
-						content: textDelta.Value,
-						model:   c.model,
-						done:    false,
-					}
+func (s *streamedContent) startToolUse(index int32, start types.ToolUseBlockStart) {
+	if s.toolUses == nil {
+		s.toolUses = make(map[int32]*streamedToolUse)
+	}
+	s.see(index)
+	s.toolUses[index] = &streamedToolUse{start: start}
+}
 
-					if !yield(response, nil) {
-						return
-					}
-				}
+func (s *streamedContent) appendToolInput(index int32, input string) {
+	if toolUse := s.toolUses[index]; toolUse != nil {
+		toolUse.input.WriteString(input)
+	}
+}
 
-			case *types.ConverseStreamOutputMemberContentBlockStart:
-				// Handle content block start (for tool calls)
-				if v.Value.Start != nil {
-					klog.V(3).Infof("Content block started at index: %v", aws.ToInt32(v.Value.ContentBlockIndex))
-				}
+// finishToolUse completes the tool call at index, if there is one, parsing its accumulated input.
+func (s *streamedContent) finishToolUse(index int32) (*types.ToolUseBlock, error) {
+	toolUse := s.toolUses[index]
+	if toolUse == nil {
+		return nil, nil
+	}
 
-			case *types.ConverseStreamOutputMemberMetadata:
-				// Handle final usage metadata
-				if v.Value.Usage != nil {
-					finalResponse := &bedrockStreamResponse{
-						content: "",
-						usage:   v.Value.Usage,
-						model:   c.model,
-						done:    true,
-					}
-					yield(finalResponse, nil)
-				}
-			}
+	args := map[string]any{}
+	if input := toolUse.input.String(); input != "" {
+		if err := json.Unmarshal([]byte(input), &args); err != nil {
+			return nil, fmt.Errorf("parsing input of tool call %q: %w", aws.ToString(toolUse.start.ToolUseId), err)
 		}
+	}
+	toolUse.block = &types.ToolUseBlock{
+		ToolUseId: toolUse.start.ToolUseId,
+		Name:      toolUse.start.Name,
+		Input:     document.NewLazyDocument(args),
+	}
+	return toolUse.block, nil
+}
 
-		// Update conversation history with the full response
-		if fullContent.Len() > 0 {
-			assistantMessage.Content = append(assistantMessage.Content,
-				&types.ContentBlockMemberText{Value: fullContent.String()})
-			c.messages = append(c.messages, assistantMessage)
+// blocks returns the assembled content blocks, in stream order.
+// Tool calls that never completed are left out.
+func (s *streamedContent) blocks() []types.ContentBlock {
+	var blocks []types.ContentBlock
+	for _, index := range s.order {
+		if text, ok := s.text[index]; ok && text.Len() > 0 {
+			blocks = append(blocks, &types.ContentBlockMemberText{Value: text.String()})
 		}
-
-		// Check for stream errors
-		if err := stream.Err(); err != nil {
-			yield(nil, fmt.Errorf("stream error: %w", err))
+		if toolUse, ok := s.toolUses[index]; ok && toolUse.block != nil {
+			blocks = append(blocks, &types.ContentBlockMemberToolUse{Value: *toolUse.block})
 		}
-	}, nil
+	}
+	return blocks
 }
 
 // SetFunctionDefinitions configures the available functions for tool use
@@ -332,16 +2485,9 @@ func (c *bedrockChat) SetFunctionDefinitions(functions []*FunctionDefinition) er
 	var tools []types.Tool
 	for _, fn := range functions {
 		// Convert gollm function definition to AWS tool specification
-		inputSchema := make(map[string]interface{})
-		if fn.Parameters != nil {
-			// Convert Schema to map[string]interface{}
-			jsonData, err := json.Marshal(fn.Parameters)
-			if err != nil {
-				return fmt.Errorf("failed to marshal function parameters: %w", err)
-			}
-			if err := json.Unmarshal(jsonData, &inputSchema); err != nil {
-				return fmt.Errorf("failed to unmarshal function parameters: %w", err)
-			}
+		inputSchema, err := convertSchemaToMap(fn.Parameters)
+		if err != nil {
+			return fmt.Errorf("converting parameters of function %q: %w", fn.Name, err)
 		}
 
 		toolSpec := types.ToolSpecification{
@@ -362,21 +2508,189 @@ func (c *bedrockChat) SetFunctionDefinitions(functions []*FunctionDefinition) er
 	return nil
 }
 
+// convertSchemaToMap converts a Schema to the JSON schema map used as a Bedrock tool input schema.
+// All Schema fields, including constraints such as enum, format, array bounds and
+// additionalProperties, are carried over.
+func convertSchemaToMap(schema *Schema) (map[string]any, error) {
+	inputSchema := make(map[string]any)
+	if schema == nil {
+		return inputSchema, nil
+	}
+
+	jsonData, err := json.Marshal(schema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal schema: %w", err)
+	}
+	if err := json.Unmarshal(jsonData, &inputSchema); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal schema: %w", err)
+	}
+	return inputSchema, nil
+}
+
 // IsRetryableError determines if an error is retryable
 func (c *bedrockChat) IsRetryableError(err error) bool {
+	var (
+		throttling  *types.ThrottlingException
+		timeout     *types.ModelTimeoutException
+		internal    *types.InternalServerException
+		unavailable *types.ServiceUnavailableException
+		notReady    *types.ModelNotReadyException
+	)
+	if errors.As(err, &throttling) || errors.As(err, &timeout) || errors.As(err, &internal) || errors.As(err, &unavailable) || errors.As(err, &notReady) {
+		return true
+	}
 	return DefaultIsRetryableError(err)
 }
 
+// isModelUnavailableError returns true if err means that the requested model cannot serve
+// requests, for example because it is not ready or not offered in the region.
+func isModelUnavailableError(err error) bool {
+	var (
+		notReady *types.ModelNotReadyException
+		notFound *types.ResourceNotFoundException
+	)
+	return errors.As(err, &notReady) || errors.As(err, &notFound)
+}
+
+// classifyError wraps the errors of model that the user has to act on in ErrModelNotReady
+// or ErrThroughputRequired, with a hint on how to fix them, and explains the rejection of
+// a model that ModelSupportReason does not know. Other errors are returned unchanged.
+func (c *bedrockChat) classifyError(model string, err error) error {
+	var (
+		notReady   *types.ModelNotReadyException
+		validation *types.ValidationException
+		notFound   *types.ResourceNotFoundException
+	)
+	switch {
+	case errors.As(err, &notReady):
+		return fmt.Errorf("%w: enable access to model %q in the Bedrock console, or retry shortly if it was just enabled: %w", ErrModelNotReady, model, err)
+	case errors.As(err, &validation) && strings.Contains(validation.ErrorMessage(), "on-demand throughput"):
+		return fmt.Errorf("%w: use the inference profile %q or a provisioned throughput ARN instead of %q: %w",
+			ErrThroughputRequired, regionalInferenceProfile(stripInferenceProfilePrefix(model), c.client.region), model, err)
+	case errors.As(err, &notFound), errors.As(err, &validation) && strings.Contains(validation.ErrorMessage(), "model identifier"):
+		// The model was not found: explain why, if it is not one we know
+		if supported, reason := ModelSupportReason(model); !supported {
+			return fmt.Errorf("bedrock rejected model %q (%s): %w", model, reason, err)
+		}
+	}
+	return err
+}
+
+// bedrockTarget is a model, and the region it is requested in.
+type bedrockTarget struct {
+	model  string
+	region bedrockRegion
+}
+
+// targets returns the models and regions a request is sent to, in order, while it fails:
+// the chat's model in the client's region, then in each failover region, and then each
+// fallback model in the same way. Cross-region inference profiles are switched to the
+// geography of the failover region; models that cannot be used in a region are skipped.
+func (c *bedrockChat) targets() []bedrockTarget {
+	regions := append([]bedrockRegion{{name: c.client.region, runtime: c.client.runtime}}, c.client.failoverRegions...)
+	var targets []bedrockTarget
+	for _, model := range append([]string{c.model}, c.fallbackModels...) {
+		for i, region := range regions {
+			if i > 0 {
+				if isInferenceProfileID(model) {
+					model = applyInferenceProfilePrefix(stripInferenceProfilePrefix(model), region.name)
+				}
+				if validateModelRegion(model, region.name) != nil {
+					continue
+				}
+			}
+			targets = append(targets, bedrockTarget{model: model, region: region})
+		}
+	}
+	return targets
+}
+
+// withFallback calls send with the chat's model in the client's region and, while it fails
+// with an error that another model or region might not, with each of the other targets in turn.
+// It returns the target that served the request.
+func (c *bedrockChat) withFallback(ctx context.Context, send func(target bedrockTarget) error) (bedrockTarget, error) {
+	targets := c.targets()
+	for i := 0; ; i++ {
+		err := send(targets[i])
+		if err == nil || i == len(targets)-1 || !(c.IsRetryableError(err) || isModelUnavailableError(err)) {
+			return targets[i], err
+		}
+		c.client.logFor(ctx).Warn("Bedrock request failed, falling back",
+			"model", targets[i].model, "region", targets[i].region.name,
+			"fallback", targets[i+1].model, "fallbackRegion", targets[i+1].region.name, "error", err)
+	}
+}
+
+// Validate checks the conversation, tools and model without issuing a request.
+// Bedrock requires the conversation to start with a user message and alternate between roles.
+func (c *bedrockChat) Validate() error {
+	var problems []string
+	if c.modelErr != nil {
+		problems = append(problems, c.modelErr.Error())
+	}
+
+	for i, msg := range c.messages {
+		if i == 0 && msg.Role != types.ConversationRoleUser {
+			problems = append(problems, fmt.Sprintf("message 0: conversation must start with a user message, not %s", msg.Role))
+		}
+		if i > 0 && msg.Role == c.messages[i-1].Role {
+			problems = append(problems, fmt.Sprintf("message %d: consecutive %s messages; roles must alternate", i, msg.Role))
+		}
+		if len(msg.Content) == 0 {
+			problems = append(problems, fmt.Sprintf("message %d: no content", i))
+		}
+	}
+
+	problems = append(problems, validateFunctionDefinitions(c.functionDefs)...)
+	return validationResult(problems)
+}
+
 // bedrockResponse implements ChatResponse for regular (non-streaming) responses
 type bedrockResponse struct {
 	output *bedrockruntime.ConverseOutput
 	model  string
+	// region is the region that served the response
+	region string
+	// latency is the time the Converse call took, as seen by the client
+	latency time.Duration
+	// normalize, if set, rewrites the text of the candidate
+	normalize OutputNormalizer
+}
+
+var _ ResponseMetricsReporter = &bedrockResponse{}
+
+// responseMetrics returns the metrics of a response that Bedrock handled in latencyMs
+// and took clientLatency, or nil if Bedrock did not report its latency.
+func responseMetrics(latencyMs *int64, clientLatency time.Duration) *ResponseMetrics {
+	if latencyMs == nil {
+		return nil
+	}
+	return &ResponseMetrics{
+		ServerLatency: time.Duration(*latencyMs) * time.Millisecond,
+		ClientLatency: clientLatency,
+	}
+}
+
+// streamResponseMetrics returns the metrics of a stream that took clientLatency.
+func streamResponseMetrics(metrics *types.ConverseStreamMetrics, clientLatency time.Duration) *ResponseMetrics {
+	if metrics == nil {
+		return nil
+	}
+	return responseMetrics(metrics.LatencyMs, clientLatency)
+}
+
+// ResponseMetrics returns the latency reported by Bedrock and the one observed by the client.
+func (r *bedrockResponse) ResponseMetrics() *ResponseMetrics {
+	if r.output == nil || r.output.Metrics == nil {
+		return nil
+	}
+	return responseMetrics(r.output.Metrics.LatencyMs, r.latency)
 }
 
-// UsageMetadata returns the usage metadata from the response
+// UsageMetadata returns the normalized *Usage of the response
 func (r *bedrockResponse) UsageMetadata() any {
 	if r.output != nil && r.output.Usage != nil {
-		return r.output.Usage
+		return convertAWSUsage(r.output.Usage, r.model, r.region)
 	}
 	return nil
 }
@@ -389,8 +2703,10 @@ func (r *bedrockResponse) Candidates() []Candidate {
 
 	if msg, ok := r.output.Output.(*types.ConverseOutputMemberMessage); ok {
 		candidate := &bedrockCandidate{
-			message: &msg.Value,
-			model:   r.model,
+			message:    &msg.Value,
+			model:      r.model,
+			stopReason: r.output.StopReason,
+			normalize:  r.normalize,
 		}
 		return []Candidate{candidate}
 	}
@@ -398,36 +2714,102 @@ func (r *bedrockResponse) Candidates() []Candidate {
 	return []Candidate{}
 }
 
+func (r *bedrockResponse) RequiresToolCall() bool {
+	return requiresToolCall(r.Candidates())
+}
+
 // bedrockStreamResponse implements ChatResponse for streaming responses
 type bedrockStreamResponse struct {
 	content string
+	toolUse *types.ToolUseBlock
 	usage   *types.TokenUsage
 	model   string
+	region  string
 	done    bool
+	// stopReason and stats are set on the final response of a stream
+	stopReason types.StopReason
+	stats      *StreamStats
+	metrics    *ResponseMetrics
+}
+
+var (
+	_ StreamStatsReporter     = &bedrockStreamResponse{}
+	_ ResponseMetricsReporter = &bedrockStreamResponse{}
+)
+
+// StreamStats returns the timing of the stream, on its final response.
+func (r *bedrockStreamResponse) StreamStats() *StreamStats {
+	return r.stats
+}
+
+// ResponseMetrics returns the latency reported by Bedrock for the whole stream, on its final
+// response, and the duration of the stream as seen by the client.
+func (r *bedrockStreamResponse) ResponseMetrics() *ResponseMetrics {
+	return r.metrics
 }
 
-// UsageMetadata returns the usage metadata from the streaming response
+// UsageMetadata returns the normalized *Usage of the streaming response.
+// Usage is only reported on the final response of a stream.
 func (r *bedrockStreamResponse) UsageMetadata() any {
-	return r.usage
+	if r.usage == nil {
+		return nil
+	}
+	return convertAWSUsage(r.usage, r.model, r.region)
 }
 
 // Candidates returns the candidate responses for streaming
 func (r *bedrockStreamResponse) Candidates() []Candidate {
-	if r.content == "" && r.usage == nil {
+	if r.content == "" && r.toolUse == nil && r.usage == nil && r.stopReason == "" {
 		return []Candidate{}
 	}
 
 	candidate := &bedrockStreamCandidate{
-		content: r.content,
-		model:   r.model,
+		content:    r.content,
+		toolUse:    r.toolUse,
+		model:      r.model,
+		stopReason: r.stopReason,
 	}
 	return []Candidate{candidate}
 }
 
+func (r *bedrockStreamResponse) RequiresToolCall() bool {
+	return requiresToolCall(r.Candidates())
+}
+
 // bedrockCandidate implements Candidate for regular responses
 type bedrockCandidate struct {
-	message *types.Message
-	model   string
+	message    *types.Message
+	model      string
+	stopReason types.StopReason
+	normalize  OutputNormalizer
+}
+
+// bedrockFinishReason normalizes the stop reason of a Bedrock response.
+func bedrockFinishReason(reason types.StopReason) FinishReason {
+	switch reason {
+	case "":
+		return FinishReasonUnspecified
+	case types.StopReasonEndTurn, types.StopReasonStopSequence:
+		return FinishReasonStop
+	case types.StopReasonMaxTokens:
+		return FinishReasonMaxTokens
+	case types.StopReasonToolUse:
+		return FinishReasonToolUse
+	case types.StopReasonContentFiltered, types.StopReasonGuardrailIntervened:
+		return FinishReasonContentFiltered
+	default:
+		return FinishReasonOther
+	}
+}
+
+// FinishReason returns why the model stopped generating the candidate.
+func (c *bedrockCandidate) FinishReason() FinishReason {
+	return bedrockFinishReason(c.stopReason)
+}
+
+// IsRefusal reports whether the model refused to respond or its response was filtered.
+func (c *bedrockCandidate) IsRefusal() bool {
+	return c.FinishReason() == FinishReasonContentFiltered
 }
 
 // String returns a string representation of the candidate
@@ -439,12 +2821,20 @@ func (c *bedrockCandidate) String() string {
 	var content strings.Builder
 	for _, block := range c.message.Content {
 		if textBlock, ok := block.(*types.ContentBlockMemberText); ok {
-			content.WriteString(textBlock.Value)
+			content.WriteString(c.text(textBlock.Value))
 		}
 	}
 	return content.String()
 }
 
+// text returns the text of a text block of the candidate, normalized if needed.
+func (c *bedrockCandidate) text(text string) string {
+	if c.normalize == nil {
+		return text
+	}
+	return c.normalize(text)
+}
+
 // Parts returns the parts of the candidate
 func (c *bedrockCandidate) Parts() []Part {
 	if c.message == nil {
@@ -455,7 +2845,7 @@ func (c *bedrockCandidate) Parts() []Part {
 	for _, block := range c.message.Content {
 		switch v := block.(type) {
 		case *types.ContentBlockMemberText:
-			parts = append(parts, &bedrockTextPart{text: v.Value})
+			parts = append(parts, &bedrockTextPart{text: c.text(v.Value)})
 		case *types.ContentBlockMemberToolUse:
 			parts = append(parts, &bedrockToolPart{toolUse: &v.Value})
 		}
@@ -463,10 +2853,26 @@ func (c *bedrockCandidate) Parts() []Part {
 	return parts
 }
 
+func (c *bedrockCandidate) HasToolCalls() bool {
+	return hasToolCalls(c.Parts())
+}
+
 // bedrockStreamCandidate implements Candidate for streaming responses
 type bedrockStreamCandidate struct {
-	content string
-	model   string
+	content    string
+	toolUse    *types.ToolUseBlock
+	model      string
+	stopReason types.StopReason
+}
+
+// FinishReason returns why the model stopped generating the candidate.
+func (c *bedrockStreamCandidate) FinishReason() FinishReason {
+	return bedrockFinishReason(c.stopReason)
+}
+
+// IsRefusal reports whether the model refused to respond or its response was filtered.
+func (c *bedrockStreamCandidate) IsRefusal() bool {
+	return c.FinishReason() == FinishReasonContentFiltered
 }
 
 // String returns a string representation of the streaming candidate
@@ -476,10 +2882,18 @@ func (c *bedrockStreamCandidate) String() string {
 
 // Parts returns the parts of the streaming candidate
 func (c *bedrockStreamCandidate) Parts() []Part {
-	if c.content == "" {
-		return []Part{}
+	parts := []Part{}
+	if c.content != "" {
+		parts = append(parts, &bedrockTextPart{text: c.content})
 	}
-	return []Part{&bedrockTextPart{text: c.content}}
+	if c.toolUse != nil {
+		parts = append(parts, &bedrockToolPart{toolUse: c.toolUse})
+	}
+	return parts
+}
+
+func (c *bedrockStreamCandidate) HasToolCalls() bool {
+	return hasToolCalls(c.Parts())
 }
 
 // bedrockTextPart implements Part for text content
@@ -516,7 +2930,7 @@ func (p *bedrockToolPart) AsFunctionCalls() ([]FunctionCall, bool) {
 	// Convert AWS tool use to gollm function call
 	var args map[string]any
 	if p.toolUse.Input != nil {
-		if err := p.toolUse.Input.UnmarshalSmithyDocument(&args); err != nil {
+		if err := unmarshalDocument(p.toolUse.Input, &args); err != nil {
 			klog.Errorf("Failed to unmarshal tool input: %v", err)
 			args = make(map[string]any)
 		}
@@ -533,11 +2947,14 @@ func (p *bedrockToolPart) AsFunctionCalls() ([]FunctionCall, bool) {
 
 // Helper functions
 
+// defaultBedrockModel is the foundation model used when none is configured (Claude Sonnet 4).
+const defaultBedrockModel = "anthropic.claude-sonnet-4-20250514-v1:0"
+
 // getBedrockModel returns the model to use, checking in order:
 // 1. Explicitly provided model
 // 2. Environment variable BEDROCK_MODEL
-// 3. Default model (Claude Sonnet 4)
-func getBedrockModel(model string) string {
+// 3. Default model (Claude Sonnet 4), through the inference profile for the region's geography
+func getBedrockModel(model, region string) string {
 	if model != "" {
 		klog.V(2).Infof("Using explicitly provided model: %s", model)
 		return model
@@ -548,11 +2965,450 @@ func getBedrockModel(model string) string {
 		return envModel
 	}
 
-	defaultModel := "us.anthropic.claude-sonnet-4-20250514-v1:0"
+	defaultModel := regionalInferenceProfile(defaultBedrockModel, region)
 	klog.V(1).Infof("Using default model: %s", defaultModel)
 	return defaultModel
 }
 
+// bedrockSupportedModels are the foundation models known to work with the Converse API.
+// Cross-region inference profiles (e.g. "us.anthropic...") are supported for each of them.
+var bedrockSupportedModels = []string{
+	"anthropic.claude-opus-4-1-20250805-v1:0",
+	"anthropic.claude-opus-4-20250514-v1:0",
+	"anthropic.claude-sonnet-4-20250514-v1:0",
+	"anthropic.claude-3-7-sonnet-20250219-v1:0",
+	"anthropic.claude-3-5-sonnet-20241022-v2:0",
+	"anthropic.claude-3-5-sonnet-20240620-v1:0",
+	"anthropic.claude-3-5-haiku-20241022-v1:0",
+	"anthropic.claude-3-haiku-20240307-v1:0",
+	"amazon.nova-premier-v1:0",
+	"amazon.nova-pro-v1:0",
+	"amazon.nova-lite-v1:0",
+	"amazon.nova-micro-v1:0",
+	"cohere.command-r-plus-v1:0",
+	"cohere.command-r-v1:0",
+	"mistral.mistral-large-2407-v1:0",
+	"mistral.mistral-large-2402-v1:0",
+	"mistral.mistral-small-2402-v1:0",
+}
+
+// bedrockModelFamilies maps the model ID prefix of each supported family to a display name.
+var bedrockModelFamilies = map[string]string{
+	"anthropic.claude-": "Anthropic Claude",
+	"amazon.nova-":      "Amazon Nova",
+	"cohere.command-":   "Cohere Command",
+	"mistral.mistral-":  "Mistral",
+}
+
+// bedrockShimPromptFamilies are the model families whose system prompts get the default
+// tool-use shim enhancement, keyed by model ID prefix.
+var bedrockShimPromptFamilies = []string{"anthropic.claude-", "amazon.nova-"}
+
+// bedrockInferenceProfilePrefixes are the geographic prefixes of cross-region inference profiles.
+var bedrockInferenceProfilePrefixes = []string{"us.", "us-gov.", "eu.", "apac."}
+
+// bedrockModelPricing is the on-demand pricing of the supported models, keyed by foundation model ID.
+var bedrockModelPricing = map[string]ModelPricing{
+	"anthropic.claude-opus-4-1-20250805-v1:0":   {InputPerMillionTokens: 15, OutputPerMillionTokens: 75},
+	"anthropic.claude-opus-4-20250514-v1:0":     {InputPerMillionTokens: 15, OutputPerMillionTokens: 75},
+	"anthropic.claude-sonnet-4-20250514-v1:0":   {InputPerMillionTokens: 3, OutputPerMillionTokens: 15},
+	"anthropic.claude-3-7-sonnet-20250219-v1:0": {InputPerMillionTokens: 3, OutputPerMillionTokens: 15},
+	"anthropic.claude-3-5-sonnet-20241022-v2:0": {InputPerMillionTokens: 3, OutputPerMillionTokens: 15},
+	"anthropic.claude-3-5-sonnet-20240620-v1:0": {InputPerMillionTokens: 3, OutputPerMillionTokens: 15},
+	"anthropic.claude-3-5-haiku-20241022-v1:0":  {InputPerMillionTokens: 0.8, OutputPerMillionTokens: 4},
+	"anthropic.claude-3-haiku-20240307-v1:0":    {InputPerMillionTokens: 0.25, OutputPerMillionTokens: 1.25},
+	"amazon.nova-premier-v1:0":                  {InputPerMillionTokens: 2.5, OutputPerMillionTokens: 12.5},
+	"amazon.nova-pro-v1:0":                      {InputPerMillionTokens: 0.8, OutputPerMillionTokens: 3.2},
+	"amazon.nova-lite-v1:0":                     {InputPerMillionTokens: 0.06, OutputPerMillionTokens: 0.24},
+	"amazon.nova-micro-v1:0":                    {InputPerMillionTokens: 0.035, OutputPerMillionTokens: 0.14},
+	"cohere.command-r-plus-v1:0":                {InputPerMillionTokens: 3, OutputPerMillionTokens: 15},
+	"cohere.command-r-v1:0":                     {InputPerMillionTokens: 0.5, OutputPerMillionTokens: 1.5},
+	"mistral.mistral-large-2407-v1:0":           {InputPerMillionTokens: 2, OutputPerMillionTokens: 6},
+	"mistral.mistral-large-2402-v1:0":           {InputPerMillionTokens: 4, OutputPerMillionTokens: 12},
+	"mistral.mistral-small-2402-v1:0":           {InputPerMillionTokens: 1, OutputPerMillionTokens: 3},
+}
+
+// convertAWSUsage normalizes Bedrock token usage into a Usage, including its cost if the model's pricing is known.
+func convertAWSUsage(usage *types.TokenUsage, model, region string) *Usage {
+	if usage == nil {
+		return nil
+	}
+
+	result := &Usage{
+		InputTokens:      int(aws.ToInt32(usage.InputTokens)),
+		OutputTokens:     int(aws.ToInt32(usage.OutputTokens)),
+		TotalTokens:      int(aws.ToInt32(usage.TotalTokens)),
+		CacheReadTokens:  int(aws.ToInt32(usage.CacheReadInputTokens)),
+		CacheWriteTokens: int(aws.ToInt32(usage.CacheWriteInputTokens)),
+		Estimated:        false,
+		Source:           UsageSourceAPI,
+		Provider:         "bedrock",
+		Model:            model,
+		Region:           region,
+		Timestamp:        time.Now(),
+	}
+	if pricing, ok := bedrockModelPricing[stripInferenceProfilePrefix(model)]; ok {
+		result.applyPricing(pricing)
+	}
+	return result
+}
+
+// isModelSupported returns true if the model can be used with the Bedrock client.
+func isModelSupported(model string) bool {
+	supported, _ := ModelSupportReason(model)
+	return supported
+}
+
+// ModelSupportReason reports whether the model is supported by the Bedrock client.
+// If it is not, the returned string explains why, for example a likely typo,
+// an unknown model family, or a malformed ARN.
+func ModelSupportReason(model string) (bool, string) {
+	if model == "" {
+		return false, "model name is empty"
+	}
+
+	if strings.HasPrefix(model, "arn:") {
+		if err := validateModelARN(model); err != nil {
+			return false, err.Error()
+		}
+		return true, ""
+	}
+
+	baseModel := stripInferenceProfilePrefix(model)
+	for _, known := range bedrockSupportedModels {
+		if baseModel == known {
+			return true, ""
+		}
+	}
+
+	family := ""
+	for prefix, name := range bedrockModelFamilies {
+		if strings.HasPrefix(baseModel, prefix) {
+			family = name
+		}
+	}
+
+	if suggestion := closestBedrockModel(baseModel); suggestion != "" {
+		return false, fmt.Sprintf("unknown model ID, did you mean %q?", suggestion)
+	}
+	if family == "" {
+		return false, fmt.Sprintf("unknown model family; supported families are %s", strings.Join(supportedBedrockFamilies(), ", "))
+	}
+	return false, fmt.Sprintf("unknown %s model; supported models are %s", family, strings.Join(bedrockSupportedModels, ", "))
+}
+
+// parseBedrockARN splits a Bedrock ARN of the form
+// arn:<partition>:bedrock:<region>:<account-id>:<resource-type>/<resource-id>.
+func parseBedrockARN(arn string) (resourceType, resourceID string, err error) {
+	const format = "arn:<partition>:bedrock:<region>:<account-id>:<resource-type>/<resource-id>"
+
+	fields := strings.SplitN(arn, ":", 6)
+	if len(fields) != 6 {
+		return "", "", fmt.Errorf("malformed ARN %q, expected %s", arn, format)
+	}
+	if fields[2] != "bedrock" {
+		return "", "", fmt.Errorf("malformed ARN %q, service must be \"bedrock\" but was %q", arn, fields[2])
+	}
+	resourceType, resourceID, ok := strings.Cut(fields[5], "/")
+	if !ok || resourceType == "" || resourceID == "" {
+		return "", "", fmt.Errorf("malformed ARN %q, expected %s", arn, format)
+	}
+	return resourceType, resourceID, nil
+}
+
+// validateModelARN returns an error saying what is wrong with a Bedrock model ARN, if anything:
+// it is malformed, its region is not in its partition, or it names a model that is not supported.
+func validateModelARN(arn string) error {
+	resourceType, resourceID, err := parseBedrockARN(arn)
+	if err != nil {
+		return err
+	}
+	fields := strings.SplitN(arn, ":", 6)
+	partition, region, account := fields[1], fields[3], fields[4]
+	if !slices.Contains(awsPartitions, partition) {
+		return fmt.Errorf("malformed ARN %q, partition must be one of %q but was %q", arn, awsPartitions, partition)
+	}
+	if region == "" {
+		return fmt.Errorf("malformed ARN %q, region is missing", arn)
+	}
+	if err := validateAWSRegion(region); err != nil {
+		return fmt.Errorf("malformed ARN %q: %w", arn, err)
+	}
+	if want := awsPartition(region); partition != want {
+		return fmt.Errorf("malformed ARN %q, region %q is in partition %q, not %q", arn, region, want, partition)
+	}
+	// Foundation models belong to no account, so their ARNs have none
+	if resourceType != "foundation-model" && (len(account) != 12 || strings.Trim(account, "0123456789") != "") {
+		return fmt.Errorf("malformed ARN %q, account ID must be 12 digits but was %q", arn, account)
+	}
+
+	switch resourceType {
+	case "application-inference-profile", "provisioned-model", "custom-model":
+		// These resource IDs are opaque, so the underlying model can't be checked here
+		return nil
+	case "foundation-model", "inference-profile":
+		if supported, reason := ModelSupportReason(resourceID); !supported {
+			return fmt.Errorf("model %q of ARN %q is not supported: %s", resourceID, arn, reason)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported ARN resource type %q", resourceType)
+	}
+}
+
+// validateModelRegion returns an error if model is an ARN in another region than region,
+// which Bedrock would reject as not found. Model IDs, and an unknown region, are not checked.
+func validateModelRegion(model, region string) error {
+	fields := strings.SplitN(model, ":", 6)
+	if !strings.HasPrefix(model, "arn:") || region == "" || len(fields) < 4 {
+		return nil
+	}
+	if arnRegion := fields[3]; arnRegion != region {
+		return fmt.Errorf("bedrock model ARN %q is in region %q, but the client uses region %q", model, arnRegion, region)
+	}
+	return nil
+}
+
+// awsPartitions are the AWS partitions returned by awsPartition.
+var awsPartitions = []string{"aws", "aws-cn", "aws-us-gov", "aws-iso", "aws-iso-b", "aws-iso-e", "aws-iso-f"}
+
+// awsPartition returns the AWS partition of a region.
+func awsPartition(region string) string {
+	switch {
+	case strings.HasPrefix(region, "cn-"):
+		return "aws-cn"
+	case strings.HasPrefix(region, "us-gov-"):
+		return "aws-us-gov"
+	case strings.HasPrefix(region, "us-iso-"):
+		return "aws-iso"
+	case strings.HasPrefix(region, "us-isob-"):
+		return "aws-iso-b"
+	case strings.HasPrefix(region, "us-isof-"):
+		return "aws-iso-f"
+	case strings.HasPrefix(region, "eu-isoe-"):
+		return "aws-iso-e"
+	default:
+		return "aws"
+	}
+}
+
+// stripInferenceProfilePrefix removes a cross-region inference profile prefix, if any.
+func stripInferenceProfilePrefix(model string) string {
+	for _, prefix := range bedrockInferenceProfilePrefixes {
+		if strings.HasPrefix(model, prefix) {
+			return strings.TrimPrefix(model, prefix)
+		}
+	}
+	return model
+}
+
+// isInferenceProfileID reports whether model is the ID of a cross-region inference
+// profile, such as "us.anthropic.claude-sonnet-4-20250514-v1:0", rather than a
+// foundation model ID or an ARN.
+func isInferenceProfileID(model string) bool {
+	return !strings.HasPrefix(model, "arn:") && stripInferenceProfilePrefix(model) != model
+}
+
+// awsRegions are the known AWS regions.
+var awsRegions = []string{
+	"us-east-1", "us-east-2", "us-west-1", "us-west-2",
+	"us-gov-east-1", "us-gov-west-1",
+	"ca-central-1", "ca-west-1",
+	"sa-east-1", "mx-central-1",
+	"eu-central-1", "eu-central-2", "eu-west-1", "eu-west-2", "eu-west-3",
+	"eu-north-1", "eu-south-1", "eu-south-2",
+	"ap-east-1", "ap-south-1", "ap-south-2",
+	"ap-northeast-1", "ap-northeast-2", "ap-northeast-3",
+	"ap-southeast-1", "ap-southeast-2", "ap-southeast-3", "ap-southeast-4", "ap-southeast-5", "ap-southeast-7",
+	"me-south-1", "me-central-1", "il-central-1", "af-south-1",
+	"cn-north-1", "cn-northwest-1",
+	"us-iso-east-1", "us-iso-west-1", "us-isob-east-1", "us-isof-south-1", "us-isof-east-1",
+	"eu-isoe-west-1",
+}
+
+// awsRegionPattern matches the format of AWS region names, such as "us-east-1",
+// "us-gov-west-1" and "eu-isoe-west-1".
+var awsRegionPattern = regexp.MustCompile(`^[a-z]{2}(-[a-z]+)+-[0-9]+$`)
+
+// validateAWSRegion returns an error if region is not a well-formed AWS region name.
+// Well-formed regions that are not in awsRegions are accepted, since AWS adds regions
+// after gollm is released; see unknownAWSRegion.
+func validateAWSRegion(region string) error {
+	if slices.Contains(awsRegions, region) || awsRegionPattern.MatchString(region) {
+		return nil
+	}
+	if suggestion := closestAWSRegion(region); suggestion != "" {
+		return fmt.Errorf("invalid AWS region %q, did you mean %q?", region, suggestion)
+	}
+	return fmt.Errorf("invalid AWS region %q", region)
+}
+
+// unknownAWSRegion reports whether region is not in awsRegions, and returns the known
+// region it is most likely a typo of, if any.
+func unknownAWSRegion(region string) (unknown bool, suggestion string) {
+	if slices.Contains(awsRegions, region) {
+		return false, ""
+	}
+	return true, closestAWSRegion(region)
+}
+
+// closestAWSRegion returns the known region within a small edit distance of region,
+// or "" if there is none.
+func closestAWSRegion(region string) string {
+	const maxTypoDistance = 2
+	best := ""
+	bestDistance := maxTypoDistance + 1
+	for _, known := range awsRegions {
+		if d := editDistance(region, known); d < bestDistance {
+			best = known
+			bestDistance = d
+		}
+	}
+	return best
+}
+
+// bedrockInferenceProfileRegions maps the AWS regions that are the source region of a
+// cross-region inference profile to the prefix of the profile's geography. The US profiles
+// also serve ca-central-1. Regions of other geographies, such as sa-east-1, have no profiles.
+var bedrockInferenceProfileRegions = map[string]string{
+	"us-east-1":      "us.",
+	"us-east-2":      "us.",
+	"us-west-1":      "us.",
+	"us-west-2":      "us.",
+	"ca-central-1":   "us.",
+	"us-gov-east-1":  "us-gov.",
+	"us-gov-west-1":  "us-gov.",
+	"eu-central-1":   "eu.",
+	"eu-central-2":   "eu.",
+	"eu-north-1":     "eu.",
+	"eu-south-1":     "eu.",
+	"eu-south-2":     "eu.",
+	"eu-west-1":      "eu.",
+	"eu-west-2":      "eu.",
+	"eu-west-3":      "eu.",
+	"ap-northeast-1": "apac.",
+	"ap-northeast-2": "apac.",
+	"ap-northeast-3": "apac.",
+	"ap-south-1":     "apac.",
+	"ap-south-2":     "apac.",
+	"ap-southeast-1": "apac.",
+	"ap-southeast-2": "apac.",
+	"ap-southeast-4": "apac.",
+}
+
+// inferenceProfilePrefixForRegion returns the cross-region inference profile prefix
+// for the geography of an AWS region, or "" if the region has no inference profiles.
+func inferenceProfilePrefixForRegion(region string) string {
+	return bedrockInferenceProfileRegions[region]
+}
+
+// baseModelID returns the foundation model ID of a model, without any ARN or
+// inference profile prefix, or "" if the ARN does not name a foundation model.
+func baseModelID(model string) string {
+	if strings.HasPrefix(model, "arn:") {
+		resourceType, resourceID, err := parseBedrockARN(model)
+		if err != nil || (resourceType != "foundation-model" && resourceType != "inference-profile") {
+			return ""
+		}
+		model = resourceID
+	}
+	return stripInferenceProfilePrefix(model)
+}
+
+// defaultSystemPromptEnhancerFor returns the default SystemPromptEnhancer for a model.
+// The tool-use shim enhancement is tuned for Claude and Nova, so other known families
+// get their prompts unchanged. Models whose family can't be determined, such as
+// application inference profiles, get the enhancement.
+func defaultSystemPromptEnhancerFor(model string) SystemPromptEnhancer {
+	base := baseModelID(model)
+	if base == "" {
+		return DefaultBedrockSystemPromptEnhancer
+	}
+	for _, prefix := range bedrockShimPromptFamilies {
+		if strings.HasPrefix(base, prefix) {
+			return DefaultBedrockSystemPromptEnhancer
+		}
+	}
+	for prefix := range bedrockModelFamilies {
+		if strings.HasPrefix(base, prefix) {
+			return func(original string) string { return original }
+		}
+	}
+	return DefaultBedrockSystemPromptEnhancer
+}
+
+// regionalInferenceProfile returns the cross-region inference profile of a bare foundation
+// model ID for the geography of region, falling back to the US profile if the geography is not known.
+func regionalInferenceProfile(model, region string) string {
+	prefix := inferenceProfilePrefixForRegion(region)
+	if prefix == "" {
+		prefix = "us."
+	}
+	return prefix + model
+}
+
+// applyInferenceProfilePrefix prefixes a bare foundation model ID with the inference
+// profile prefix of the region. Model IDs that already have a prefix and ARNs are returned unchanged.
+func applyInferenceProfilePrefix(model, region string) string {
+	if strings.HasPrefix(model, "arn:") || isInferenceProfileID(model) {
+		return model
+	}
+
+	prefix := inferenceProfilePrefixForRegion(region)
+	if prefix == "" {
+		klog.V(2).Infof("No inference profile prefix known for region %q, using model %q as-is", region, model)
+		return model
+	}
+	return prefix + model
+}
+
+// supportedBedrockFamilies returns the sorted display names of the supported model families.
+func supportedBedrockFamilies() []string {
+	families := make([]string, 0, len(bedrockModelFamilies))
+	for _, name := range bedrockModelFamilies {
+		families = append(families, name)
+	}
+	sort.Strings(families)
+	return families
+}
+
+// closestBedrockModel returns the supported model closest to the given model ID,
+// if it is close enough to be a plausible typo.
+func closestBedrockModel(model string) string {
+	const maxTypoDistance = 3
+
+	best := ""
+	bestDistance := maxTypoDistance + 1
+	for _, known := range bedrockSupportedModels {
+		if d := editDistance(model, known); d < bestDistance {
+			best = known
+			bestDistance = d
+		}
+	}
+	return best
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
+
 // bedrockCompletionResponse wraps a ChatResponse to implement CompletionResponse
 type bedrockCompletionResponse struct {
 	chatResponse ChatResponse
@@ -568,13 +3424,13 @@ func (r *bedrockCompletionResponse) Response() string {
 	if len(candidates) == 0 {
 		return ""
 	}
-	parts := candidates[0].Parts()
-	for _, part := range parts {
-		if text, ok := part.AsText(); ok {
-			return text
+	var text strings.Builder
+	for _, part := range candidates[0].Parts() {
+		if s, ok := part.AsText(); ok {
+			text.WriteString(s)
 		}
 	}
-	return ""
+	return text.String()
 }
 
 func (r *bedrockCompletionResponse) UsageMetadata() any {
@@ -0,0 +1,4139 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gollm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/api"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/bedrock"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime/document"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime/types"
+	"github.com/aws/smithy-go"
+)
+
+// fakeBedrockAPI is a bedrockAPI that returns canned responses and records the requests it receives.
+type fakeBedrockAPI struct {
+	converseOutputs []*bedrockruntime.ConverseOutput
+	streams         []*fakeEventStream
+	err             error
+	// errs are returned, in order, by the first requests
+	errs []error
+
+	converseInputs []*bedrockruntime.ConverseInput
+	streamInputs   []*bedrockruntime.ConverseStreamInput
+}
+
+var _ bedrockAPI = &fakeBedrockAPI{}
+
+func (f *fakeBedrockAPI) Converse(ctx context.Context, input *bedrockruntime.ConverseInput) (*bedrockruntime.ConverseOutput, error) {
+	f.converseInputs = append(f.converseInputs, input)
+	if err := f.nextErr(); err != nil {
+		return nil, err
+	}
+	output := f.converseOutputs[0]
+	f.converseOutputs = f.converseOutputs[1:]
+	return output, nil
+}
+
+func (f *fakeBedrockAPI) ConverseStream(ctx context.Context, input *bedrockruntime.ConverseStreamInput) (bedrockEventStream, error) {
+	f.streamInputs = append(f.streamInputs, input)
+	if err := f.nextErr(); err != nil {
+		return nil, err
+	}
+	stream := f.streams[0]
+	f.streams = f.streams[1:]
+	return stream, nil
+}
+
+func (f *fakeBedrockAPI) nextErr() error {
+	if len(f.errs) > 0 {
+		err := f.errs[0]
+		f.errs = f.errs[1:]
+		return err
+	}
+	return f.err
+}
+
+// fakeEventStream is a bedrockEventStream that replays a fixed set of events.
+type fakeEventStream struct {
+	events []types.ConverseStreamOutput
+	err    error
+	closed bool
+	// keepOpen leaves the stream open after its events, as if the model were still generating
+	keepOpen bool
+}
+
+func (s *fakeEventStream) Events() <-chan types.ConverseStreamOutput {
+	ch := make(chan types.ConverseStreamOutput, len(s.events))
+	for _, event := range s.events {
+		ch <- event
+	}
+	if !s.keepOpen {
+		close(ch)
+	}
+	return ch
+}
+
+func (s *fakeEventStream) Close() error {
+	s.closed = true
+	return nil
+}
+
+func (s *fakeEventStream) Err() error {
+	return s.err
+}
+
+// textDeltaEvent returns a stream event carrying a text delta.
+func textDeltaEvent(text string) types.ConverseStreamOutput {
+	return &types.ConverseStreamOutputMemberContentBlockDelta{
+		Value: types.ContentBlockDeltaEvent{
+			ContentBlockIndex: aws.Int32(0),
+			Delta:             &types.ContentBlockDeltaMemberText{Value: text},
+		},
+	}
+}
+
+// newFakeBedrockChat starts a chat on a BedrockClient backed by the given fake.
+func newFakeBedrockChat(fake *fakeBedrockAPI) *bedrockChat {
+	client := &BedrockClient{runtime: fake}
+	return client.StartChat("", "us.anthropic.claude-sonnet-4-20250514-v1:0").(*bedrockChat)
+}
+
+func TestModelSupportReason(t *testing.T) {
+	tests := []struct {
+		name           string
+		model          string
+		wantSupported  bool
+		reasonContains string
+	}{
+		{
+			name:          "foundation model",
+			model:         "anthropic.claude-sonnet-4-20250514-v1:0",
+			wantSupported: true,
+		},
+		{
+			name:          "inference profile",
+			model:         "us.anthropic.claude-3-7-sonnet-20250219-v1:0",
+			wantSupported: true,
+		},
+		{
+			name:          "nova model",
+			model:         "amazon.nova-pro-v1:0",
+			wantSupported: true,
+		},
+		{
+			name:          "cohere model",
+			model:         "cohere.command-r-plus-v1:0",
+			wantSupported: true,
+		},
+		{
+			name:          "mistral model",
+			model:         "mistral.mistral-large-2407-v1:0",
+			wantSupported: true,
+		},
+		{
+			name:           "unknown model in newly supported family",
+			model:          "mistral.mixtral-8x7b-instruct-v0:1",
+			reasonContains: "unknown model family",
+		},
+		{
+			name:          "inference profile ARN",
+			model:         "arn:aws:bedrock:us-east-1:123456789012:inference-profile/us.anthropic.claude-sonnet-4-20250514-v1:0",
+			wantSupported: true,
+		},
+		{
+			name:          "application inference profile ARN",
+			model:         "arn:aws:bedrock:us-east-1:123456789012:application-inference-profile/a1b2c3d4e5f6",
+			wantSupported: true,
+		},
+		{
+			name:           "typo",
+			model:          "us.anthropic.claude-sonet-4-20250514-v1:0",
+			reasonContains: `did you mean "anthropic.claude-sonnet-4-20250514-v1:0"`,
+		},
+		{
+			name:           "unknown family",
+			model:          "meta.llama3-70b-instruct-v1:0",
+			reasonContains: "unknown model family",
+		},
+		{
+			name:           "unknown model in known family",
+			model:          "anthropic.claude-instant-v1",
+			reasonContains: "unknown Anthropic Claude model",
+		},
+		{
+			name:           "malformed ARN",
+			model:          "arn:aws:bedrock:us-east-1:inference-profile",
+			reasonContains: "malformed ARN",
+		},
+		{
+			name:           "ARN for another service",
+			model:          "arn:aws:sagemaker:us-east-1:123456789012:endpoint/my-endpoint",
+			reasonContains: `service must be "bedrock"`,
+		},
+		{
+			name:           "empty model",
+			model:          "",
+			reasonContains: "empty",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			supported, reason := ModelSupportReason(tt.model)
+			if supported != tt.wantSupported {
+				t.Fatalf("ModelSupportReason(%q) supported = %v, want %v (reason %q)", tt.model, supported, tt.wantSupported, reason)
+			}
+			if !strings.Contains(reason, tt.reasonContains) {
+				t.Errorf("ModelSupportReason(%q) reason = %q, want it to contain %q", tt.model, reason, tt.reasonContains)
+			}
+		})
+	}
+}
+
+func TestBedrockSendUnknownModel(t *testing.T) {
+	// Unknown models are sent to Bedrock, which may well support them
+	fake := &fakeBedrockAPI{converseOutputs: []*bedrockruntime.ConverseOutput{
+		assistantOutput(&types.ContentBlockMemberText{Value: "Hello"}),
+	}}
+	client := &BedrockClient{runtime: fake}
+	chat := client.StartChat("", "us.anthropic.claude-sonnet-5-20260101-v1:0")
+	if _, err := chat.Send(context.Background(), "hello"); err != nil {
+		t.Fatalf("expected an unknown model to be sent, got %v", err)
+	}
+	if got := aws.ToString(fake.converseInputs[0].ModelId); got != "us.anthropic.claude-sonnet-5-20260101-v1:0" {
+		t.Errorf("expected the unknown model to be requested, got %q", got)
+	}
+
+	// If Bedrock rejects it, the error explains why
+	fake = &fakeBedrockAPI{err: &types.ValidationException{Message: aws.String("The provided model identifier is invalid.")}}
+	client = &BedrockClient{runtime: fake}
+	chat = client.StartChat("", "anthropic.claude-sonet-4-20250514-v1:0")
+	_, err := chat.Send(context.Background(), "hello")
+	if err == nil {
+		t.Fatal("expected the rejection to be returned")
+	}
+	if !strings.Contains(err.Error(), "did you mean") {
+		t.Errorf("expected error to explain the rejection, got %v", err)
+	}
+}
+
+func TestValidateModelARN(t *testing.T) {
+	tests := []struct {
+		name        string
+		arn         string
+		errContains string
+	}{
+		{name: "foundation model", arn: "arn:aws:bedrock:us-east-1::foundation-model/anthropic.claude-3-5-haiku-20241022-v1:0"},
+		{name: "GovCloud inference profile", arn: "arn:aws-us-gov:bedrock:us-gov-west-1:123456789012:inference-profile/us-gov.anthropic.claude-3-5-sonnet-20240620-v1:0"},
+		{name: "ISO partition", arn: "arn:aws-iso-e:bedrock:eu-isoe-west-1:123456789012:application-inference-profile/a1b2c3d4e5f6"},
+		{name: "too few fields", arn: "arn:aws:bedrock:us-east-1:inference-profile", errContains: "expected arn:<partition>"},
+		{name: "unknown partition", arn: "arn:amazon:bedrock:us-east-1:123456789012:inference-profile/us.anthropic.claude-sonnet-4-20250514-v1:0", errContains: `partition must be one of`},
+		{name: "wrong partition", arn: "arn:aws:bedrock:cn-north-1:123456789012:custom-model/abc123", errContains: `region "cn-north-1" is in partition "aws-cn"`},
+		{name: "missing region", arn: "arn:aws:bedrock::123456789012:application-inference-profile/a1b2c3d4e5f6", errContains: "region is missing"},
+		{name: "misspelled region", arn: "arn:aws:bedrock:us-east1:123456789012:application-inference-profile/a1b2c3d4e5f6", errContains: `did you mean "us-east-1"`},
+		{name: "bad account", arn: "arn:aws:bedrock:us-east-1:12345:application-inference-profile/a1b2c3d4e5f6", errContains: "account ID must be 12 digits"},
+		{name: "missing resource ID", arn: "arn:aws:bedrock:us-east-1:123456789012:application-inference-profile", errContains: "expected arn:<partition>"},
+		{name: "unknown resource type", arn: "arn:aws:bedrock:us-east-1:123456789012:agent/abc123", errContains: `unsupported ARN resource type "agent"`},
+		{name: "unsupported family", arn: "arn:aws:bedrock:us-east-1::foundation-model/meta.llama3-70b-instruct-v1:0", errContains: "unknown model family"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateModelARN(tt.arn)
+			if tt.errContains == "" {
+				if err != nil {
+					t.Errorf("expected %q to be valid, got %v", tt.arn, err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.errContains) {
+				t.Errorf("expected an error containing %q, got %v", tt.errContains, err)
+			}
+		})
+	}
+}
+
+func TestBedrockSendModelARNInAnotherRegion(t *testing.T) {
+	client := &BedrockClient{runtime: &fakeBedrockAPI{}, region: "us-west-2", opts: BedrockOptions{StrictModel: true}}
+	chat := client.StartChat("", "arn:aws:bedrock:us-east-1:123456789012:application-inference-profile/a1b2c3d4e5f6")
+
+	_, err := chat.Send(context.Background(), "hello")
+	if err == nil || !strings.Contains(err.Error(), `is in region "us-east-1", but the client uses region "us-west-2"`) {
+		t.Errorf("expected a region mismatch error, got %v", err)
+	}
+}
+
+func TestBedrockStrictModel(t *testing.T) {
+	const (
+		otherRegionARN = "arn:aws:bedrock:us-west-2:123456789012:application-inference-profile/a1b2c3d4e5f6"
+		misspelled     = "us.anthropic.claude-sonet-4-20250514-v1:0"
+	)
+	tests := []struct {
+		name      string
+		strict    bool
+		model     string
+		wantModel string
+		wantErr   string
+	}{
+		{name: "lenient unusable model", model: otherRegionARN, wantErr: `is in region "us-west-2", but the client uses region "us-east-1"`},
+		{name: "lenient unknown model", model: misspelled, wantModel: misspelled},
+		{name: "strict unusable model", strict: true, model: otherRegionARN, wantErr: `is in region "us-west-2", but the client uses region "us-east-1"`},
+		{name: "strict unknown model", strict: true, model: misspelled, wantErr: `unsupported bedrock model "us.anthropic.claude-sonet-4-20250514-v1:0": unknown model ID, did you mean`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeBedrockAPI{converseOutputs: []*bedrockruntime.ConverseOutput{
+				assistantOutput(&types.ContentBlockMemberText{Value: "Hello"}),
+			}}
+			client := &BedrockClient{runtime: fake, region: "us-east-1", opts: BedrockOptions{StrictModel: tt.strict}}
+			chat := client.StartChat("", tt.model)
+
+			_, err := chat.Send(context.Background(), "hello")
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				if len(fake.converseInputs) != 0 {
+					t.Errorf("expected no request, got %d", len(fake.converseInputs))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Send failed: %v", err)
+			}
+			if got := aws.ToString(fake.converseInputs[0].ModelId); got != tt.wantModel {
+				t.Errorf("expected model %q, got %q", tt.wantModel, got)
+			}
+		})
+	}
+}
+
+func TestBedrockModelOverride(t *testing.T) {
+	ctx := context.Background()
+	fake := &fakeBedrockAPI{converseOutputs: []*bedrockruntime.ConverseOutput{
+		assistantOutput(&types.ContentBlockMemberText{Value: "There are 3 pods."}),
+		assistantOutput(&types.ContentBlockMemberText{Value: "The nginx pod is crash looping."}),
+		assistantOutput(&types.ContentBlockMemberText{Value: "Restart it."}),
+	}}
+	chat := newFakeBedrockChat(fake)
+	opus := "us.anthropic.claude-opus-4-20250514-v1:0"
+
+	if _, err := chat.Send(ctx, "list the pods"); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	chat.client.region = "us-east-1"
+	if _, err := chat.Send(ctx, ModelOverride("arn:aws:bedrock:us-west-2:123456789012:application-inference-profile/a1b2c3d4e5f6"), "why is nginx failing?"); err == nil || !strings.Contains(err.Error(), "overriding model") {
+		t.Fatalf("expected an invalid model error, got %v", err)
+	}
+	if _, err := chat.Send(ctx, ModelOverride(opus), "why is nginx failing?"); err != nil {
+		t.Fatalf("Send with override failed: %v", err)
+	}
+	if _, err := chat.Send(ctx, "what should I do?"); err != nil {
+		t.Fatalf("Send after override failed: %v", err)
+	}
+
+	if len(fake.converseInputs) != 3 {
+		t.Fatalf("expected 3 requests, got %d", len(fake.converseInputs))
+	}
+	wantModels := []string{"us.anthropic.claude-sonnet-4-20250514-v1:0", opus, opus}
+	for i, input := range fake.converseInputs {
+		if got := aws.ToString(input.ModelId); got != wantModels[i] {
+			t.Errorf("request %d: expected model %q, got %q", i, wantModels[i], got)
+		}
+	}
+	// The overridden model sees the whole conversation, without the override itself
+	overridden := fake.converseInputs[1].Messages
+	if len(overridden) != 3 || len(overridden[2].Content) != 1 {
+		t.Fatalf("expected the history and the new message, got %d messages", len(overridden))
+	}
+	if text := overridden[2].Content[0].(*types.ContentBlockMemberText).Value; text != "why is nginx failing?" {
+		t.Errorf("unexpected message %q", text)
+	}
+}
+
+// toolInputSchema returns the input schema of the named tool configured on the chat.
+func toolInputSchema(t *testing.T, chat *bedrockChat, name string) map[string]any {
+	t.Helper()
+	if chat.toolConfig == nil {
+		t.Fatal("expected tool configuration to be set")
+	}
+	for _, tool := range chat.toolConfig.Tools {
+		spec := tool.(*types.ToolMemberToolSpec).Value
+		if *spec.Name != name {
+			continue
+		}
+		data, err := spec.InputSchema.(*types.ToolInputSchemaMemberJson).Value.MarshalSmithyDocument()
+		if err != nil {
+			t.Fatalf("encoding input schema: %v", err)
+		}
+		var inputSchema map[string]any
+		if err := json.Unmarshal(data, &inputSchema); err != nil {
+			t.Fatalf("decoding input schema: %v", err)
+		}
+		return inputSchema
+	}
+	t.Fatalf("tool %q not found", name)
+	return nil
+}
+
+func TestBedrockToolSchemaEnumAndFormat(t *testing.T) {
+	chat := &bedrockChat{}
+	err := chat.SetFunctionDefinitions([]*FunctionDefinition{
+		{
+			Name: "get_events",
+			Parameters: &Schema{
+				Type: TypeObject,
+				Properties: map[string]*Schema{
+					"namespace": {Type: TypeString, Enum: []string{"default", "kube-system"}},
+					"since":     {Type: TypeString, Format: "date-time"},
+				},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("SetFunctionDefinitions failed: %v", err)
+	}
+
+	properties := toolInputSchema(t, chat, "get_events")["properties"].(map[string]any)
+	namespace := properties["namespace"].(map[string]any)
+	if got, want := namespace["enum"], []any{"default", "kube-system"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected enum %v, got %v", want, got)
+	}
+	since := properties["since"].(map[string]any)
+	if got := since["format"]; got != "date-time" {
+		t.Errorf("expected format %q, got %v", "date-time", got)
+	}
+}
+
+func TestBedrockSendStreamingEmptyStream(t *testing.T) {
+	stream := &fakeEventStream{}
+	chat := newFakeBedrockChat(&fakeBedrockAPI{streams: []*fakeEventStream{stream}})
+
+	iterator, err := chat.SendStreaming(context.Background(), "hello")
+	if err != nil {
+		t.Fatalf("SendStreaming failed: %v", err)
+	}
+
+	var responses int
+	var streamErr error
+	for response, err := range iterator {
+		if err != nil {
+			streamErr = err
+			break
+		}
+		if response != nil {
+			responses++
+		}
+	}
+
+	if !errors.Is(streamErr, ErrEmptyStream) {
+		t.Errorf("expected ErrEmptyStream, got %v", streamErr)
+	}
+	if responses != 0 {
+		t.Errorf("expected no responses, got %d", responses)
+	}
+	if !stream.closed {
+		t.Error("expected stream to be closed")
+	}
+	if len(chat.messages) != 0 {
+		t.Errorf("expected unanswered user message to be dropped, history has %d messages", len(chat.messages))
+	}
+}
+
+func TestBedrockSendStreamingText(t *testing.T) {
+	stream := &fakeEventStream{events: []types.ConverseStreamOutput{
+		textDeltaEvent("Hello, "),
+		textDeltaEvent("world"),
+	}}
+	chat := newFakeBedrockChat(&fakeBedrockAPI{streams: []*fakeEventStream{stream}})
+
+	iterator, err := chat.SendStreaming(context.Background(), "hello")
+	if err != nil {
+		t.Fatalf("SendStreaming failed: %v", err)
+	}
+
+	var text strings.Builder
+	for response, err := range iterator {
+		if err != nil {
+			t.Fatalf("unexpected stream error: %v", err)
+		}
+		text.WriteString(response.Candidates()[0].String())
+	}
+
+	if text.String() != "Hello, world" {
+		t.Errorf("expected %q, got %q", "Hello, world", text.String())
+	}
+	if len(chat.messages) != 2 {
+		t.Errorf("expected user and assistant messages in history, got %d", len(chat.messages))
+	}
+}
+
+func TestBedrockSendStreamingEarlyBreak(t *testing.T) {
+	ctx := context.Background()
+	stream := &fakeEventStream{events: []types.ConverseStreamOutput{
+		textDeltaEvent("Hello, "),
+		textDeltaEvent("world"),
+	}}
+	fake := &fakeBedrockAPI{
+		streams:         []*fakeEventStream{stream},
+		converseOutputs: []*bedrockruntime.ConverseOutput{assistantOutput(&types.ContentBlockMemberText{Value: "hi again"})},
+	}
+	chat := newFakeBedrockChat(fake)
+
+	iterator, err := chat.SendStreaming(ctx, "hello")
+	if err != nil {
+		t.Fatalf("SendStreaming failed: %v", err)
+	}
+	for _, err := range iterator {
+		if err != nil {
+			t.Fatalf("unexpected stream error: %v", err)
+		}
+		break
+	}
+
+	if !stream.closed {
+		t.Error("expected stream to be closed")
+	}
+	if err := chat.Validate(); err != nil {
+		t.Fatalf("expected history to be valid after an early break, got %v", err)
+	}
+	assistant := chat.messages[len(chat.messages)-1]
+	text, ok := assistant.Content[0].(*types.ContentBlockMemberText)
+	if assistant.Role != types.ConversationRoleAssistant || !ok || text.Value != "Hello, " {
+		t.Fatalf("expected the text received before the break to be recorded, got %+v", assistant)
+	}
+
+	if _, err := chat.Send(ctx, "hello again"); err != nil {
+		t.Fatalf("Send after an early break failed: %v", err)
+	}
+	if got := len(fake.converseInputs[0].Messages); got != 3 {
+		t.Errorf("expected 3 messages to be sent, got %d", got)
+	}
+}
+
+func TestBedrockCloseEndsStreams(t *testing.T) {
+	ctx := context.Background()
+	stream := &fakeEventStream{events: []types.ConverseStreamOutput{textDeltaEvent("Hello")}, keepOpen: true}
+	client := &BedrockClient{runtime: &fakeBedrockAPI{streams: []*fakeEventStream{stream}}}
+	chat := client.StartChat("", "us.anthropic.claude-sonnet-4-20250514-v1:0")
+
+	iterator, err := chat.SendStreaming(ctx, "hello")
+	if err != nil {
+		t.Fatalf("SendStreaming failed: %v", err)
+	}
+
+	ended := make(chan error, 1)
+	go func() {
+		var streamErr error
+		for _, err := range iterator {
+			if err != nil {
+				streamErr = err
+			}
+		}
+		ended <- streamErr
+	}()
+
+	if err := client.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	select {
+	case err := <-ended:
+		if !errors.Is(err, ErrClientClosed) {
+			t.Errorf("expected the stream to end with ErrClientClosed, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("stream did not end after Close")
+	}
+	if !stream.closed {
+		t.Error("expected the underlying stream to be closed")
+	}
+
+	if _, err := chat.Send(ctx, "hello"); !errors.Is(err, ErrClientClosed) {
+		t.Errorf("expected Send after Close to fail with ErrClientClosed, got %v", err)
+	}
+	if _, err := chat.SendStreaming(ctx, "hello"); !errors.Is(err, ErrClientClosed) {
+		t.Errorf("expected SendStreaming after Close to fail with ErrClientClosed, got %v", err)
+	}
+}
+
+func TestBedrockSendStreamingCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream := &fakeEventStream{events: []types.ConverseStreamOutput{textDeltaEvent("Hello")}, keepOpen: true}
+	chat := newFakeBedrockChat(&fakeBedrockAPI{streams: []*fakeEventStream{stream}})
+
+	iterator, err := chat.SendStreaming(ctx, "hello")
+	if err != nil {
+		t.Fatalf("SendStreaming failed: %v", err)
+	}
+
+	ended := make(chan error, 1)
+	go func() {
+		var streamErr error
+		for response, err := range iterator {
+			if err != nil {
+				streamErr = err
+				continue
+			}
+			// Cancel once the first delta has arrived, while the model is still generating
+			if response.Candidates()[0].String() == "Hello" {
+				cancel()
+			}
+		}
+		ended <- streamErr
+	}()
+
+	select {
+	case err := <-ended:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("expected the stream to end with context.Canceled, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("stream did not end after its context was cancelled")
+	}
+	if !stream.closed {
+		t.Error("expected the underlying stream to be closed")
+	}
+	// The text received before the cancellation is kept
+	if len(chat.messages) != 2 {
+		t.Errorf("expected user and assistant messages in history, got %d", len(chat.messages))
+	}
+}
+
+func TestBedrockSendStreamingRetry(t *testing.T) {
+	unavailable := &types.ServiceUnavailableException{Message: aws.String("connection lost")}
+	tests := []struct {
+		name        string
+		streamRetry bool
+		streams     []*fakeEventStream
+		wantText    string
+		wantErr     bool
+	}{
+		{
+			name:        "resumed",
+			streamRetry: true,
+			streams: []*fakeEventStream{
+				{events: []types.ConverseStreamOutput{textDeltaEvent("Hello, "), textDeltaEvent("the pods ")}, err: unavailable},
+				{events: []types.ConverseStreamOutput{textDeltaEvent(" are running.")}},
+			},
+			wantText: "Hello, the pods are running.",
+		},
+		{
+			name: "disabled",
+			streams: []*fakeEventStream{
+				{events: []types.ConverseStreamOutput{textDeltaEvent("Hello, ")}, err: unavailable},
+			},
+			wantText: "Hello, ",
+			wantErr:  true,
+		},
+		{
+			name:        "not retryable",
+			streamRetry: true,
+			streams: []*fakeEventStream{
+				{events: []types.ConverseStreamOutput{textDeltaEvent("Hello, ")}, err: errors.New("malformed event")},
+			},
+			wantText: "Hello, ",
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeBedrockAPI{streams: tt.streams}
+			client := &BedrockClient{runtime: fake, opts: BedrockOptions{StreamRetry: tt.streamRetry}}
+			chat := client.StartChat("", "us.anthropic.claude-sonnet-4-20250514-v1:0").(*bedrockChat)
+
+			iterator, err := chat.SendStreaming(context.Background(), "hello")
+			if err != nil {
+				t.Fatalf("SendStreaming failed: %v", err)
+			}
+			var text strings.Builder
+			var streamErr error
+			for response, err := range iterator {
+				if err != nil {
+					streamErr = err
+					continue
+				}
+				text.WriteString(response.Candidates()[0].String())
+			}
+
+			if gotErr := streamErr != nil; gotErr != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, streamErr)
+			}
+			if text.String() != tt.wantText {
+				t.Errorf("expected streamed text %q, got %q", tt.wantText, text.String())
+			}
+			assistant := chat.messages[len(chat.messages)-1].Content[0].(*types.ContentBlockMemberText).Value
+			if assistant != tt.wantText {
+				t.Errorf("expected history text %q, got %q", tt.wantText, assistant)
+			}
+			for i, stream := range tt.streams {
+				if !stream.closed {
+					t.Errorf("expected stream %d to be closed", i)
+				}
+			}
+			if len(tt.streams) == 1 {
+				return
+			}
+
+			// The continuation is requested from the text received, without its trailing space
+			if len(fake.streamInputs) != 2 {
+				t.Fatalf("expected 2 requests, got %d", len(fake.streamInputs))
+			}
+			resumed := fake.streamInputs[1].Messages
+			prefill := resumed[len(resumed)-1]
+			if prefill.Role != types.ConversationRoleAssistant || prefill.Content[0].(*types.ContentBlockMemberText).Value != "Hello, the pods" {
+				t.Errorf("expected the resumed request to end with the received text, got %+v", prefill)
+			}
+		})
+	}
+}
+
+func TestBedrockSendStreamingDiscardsIncompleteToolCall(t *testing.T) {
+	events := []types.ConverseStreamOutput{textDeltaEvent("Checking.")}
+	// The tool call's input is cut off before its block stops.
+	events = append(events, toolUseStreamEvents(1, "call-1", "kubectl", `{"command": "kubectl`)...)
+	events = events[:len(events)-1]
+	stream := &fakeEventStream{events: events, err: errors.New("connection reset")}
+	chat := newFakeBedrockChat(&fakeBedrockAPI{streams: []*fakeEventStream{stream}})
+
+	iterator, err := chat.SendStreaming(context.Background(), "hello")
+	if err != nil {
+		t.Fatalf("SendStreaming failed: %v", err)
+	}
+	var streamErr error
+	for _, err := range iterator {
+		if err != nil {
+			streamErr = err
+		}
+	}
+	if streamErr == nil {
+		t.Fatal("expected stream error")
+	}
+
+	if err := chat.Validate(); err != nil {
+		t.Fatalf("expected history to be valid after a failed stream, got %v", err)
+	}
+	assistant := chat.messages[len(chat.messages)-1]
+	if len(assistant.Content) != 1 {
+		t.Fatalf("expected only the text to be recorded, got %+v", assistant.Content)
+	}
+	if ids := chat.outstandingToolUseIDs(); len(ids) != 0 {
+		t.Errorf("expected no outstanding tool calls, got %v", ids)
+	}
+}
+
+func TestBedrockAutoInferenceProfile(t *testing.T) {
+	const bareModel = "anthropic.claude-sonnet-4-20250514-v1:0"
+
+	tests := []struct {
+		name      string
+		model     string
+		region    string
+		opts      BedrockOptions
+		wantModel string
+	}{
+		{
+			name:      "us-east-1",
+			model:     bareModel,
+			region:    "us-east-1",
+			opts:      BedrockOptions{AutoInferenceProfile: true},
+			wantModel: "us." + bareModel,
+		},
+		{
+			name:      "eu-west-1",
+			model:     bareModel,
+			region:    "eu-west-1",
+			opts:      BedrockOptions{AutoInferenceProfile: true},
+			wantModel: "eu." + bareModel,
+		},
+		{
+			name:      "ap-northeast-1",
+			model:     bareModel,
+			region:    "ap-northeast-1",
+			opts:      BedrockOptions{AutoInferenceProfile: true},
+			wantModel: "apac." + bareModel,
+		},
+		{
+			name:      "ca-central-1",
+			model:     bareModel,
+			region:    "ca-central-1",
+			opts:      BedrockOptions{AutoInferenceProfile: true},
+			wantModel: "us." + bareModel,
+		},
+		{
+			name:      "sa-east-1",
+			model:     bareModel,
+			region:    "sa-east-1",
+			opts:      BedrockOptions{AutoInferenceProfile: true},
+			wantModel: bareModel,
+		},
+		{
+			name:      "already prefixed",
+			model:     "us." + bareModel,
+			region:    "us-west-2",
+			opts:      BedrockOptions{AutoInferenceProfile: true},
+			wantModel: "us." + bareModel,
+		},
+		{
+			name:      "disabled",
+			model:     bareModel,
+			region:    "us-east-1",
+			wantModel: bareModel,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &BedrockClient{region: tt.region, opts: tt.opts}
+			chat := client.StartChat("", tt.model).(*bedrockChat)
+			if chat.model != tt.wantModel {
+				t.Errorf("expected model %q, got %q", tt.wantModel, chat.model)
+			}
+		})
+	}
+}
+
+func TestBedrockToolSchemaArrayBounds(t *testing.T) {
+	chat := &bedrockChat{}
+	err := chat.SetFunctionDefinitions([]*FunctionDefinition{
+		{
+			Name: "run_commands",
+			Parameters: &Schema{
+				Type: TypeObject,
+				Properties: map[string]*Schema{
+					"commands": {
+						Type:     TypeArray,
+						Items:    &Schema{Type: TypeString},
+						MinItems: ptrTo[int64](1),
+						MaxItems: ptrTo[int64](10),
+					},
+				},
+				AdditionalProperties: ptrTo(false),
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("SetFunctionDefinitions failed: %v", err)
+	}
+
+	want := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"commands": map[string]any{
+				"type":     "array",
+				"items":    map[string]any{"type": "string"},
+				"minItems": float64(1),
+				"maxItems": float64(10),
+			},
+		},
+		"additionalProperties": false,
+	}
+	if got := toolInputSchema(t, chat, "run_commands"); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected input schema %v, got %v", want, got)
+	}
+}
+
+func TestWithRegion(t *testing.T) {
+	var opts ClientOptions
+	WithRegion("eu-west-1")(&opts)
+	if opts.Region != "eu-west-1" {
+		t.Errorf("expected region %q, got %q", "eu-west-1", opts.Region)
+	}
+}
+
+func TestNewBedrockClientRegion(t *testing.T) {
+	client, err := NewBedrockClient(context.Background(), ClientOptions{Region: "eu-west-1"})
+	if err != nil {
+		t.Fatalf("NewBedrockClient failed: %v", err)
+	}
+	if client.region != "eu-west-1" {
+		t.Errorf("expected region %q, got %q", "eu-west-1", client.region)
+	}
+}
+
+func TestBedrockClientUnwrap(t *testing.T) {
+	for _, refresh := range []bool{false, true} {
+		client, err := NewBedrockClient(context.Background(), ClientOptions{
+			Region:  "eu-west-1",
+			Bedrock: BedrockOptions{CredentialsRefresh: refresh},
+		})
+		if err != nil {
+			t.Fatalf("NewBedrockClient failed: %v", err)
+		}
+		sdk := client.Unwrap()
+		if sdk == nil {
+			t.Fatalf("CredentialsRefresh=%v: expected the AWS SDK client", refresh)
+		}
+		if got := sdk.Options().Region; got != "eu-west-1" {
+			t.Errorf("CredentialsRefresh=%v: expected the SDK client to use region %q, got %q", refresh, "eu-west-1", got)
+		}
+		if got := client.AWSConfig().Region; got != "eu-west-1" {
+			t.Errorf("CredentialsRefresh=%v: expected the AWS config to have region %q, got %q", refresh, "eu-west-1", got)
+		}
+	}
+
+	// A client that was not created by NewBedrockClient has no SDK client.
+	if sdk := (&BedrockClient{runtime: &fakeBedrockAPI{}}).Unwrap(); sdk != nil {
+		t.Errorf("expected no SDK client, got %v", sdk)
+	}
+}
+
+func TestNewBedrockClientEndpointURL(t *testing.T) {
+	const endpoint = "https://vpce-0123456789abcdef0-abcdefgh.bedrock-runtime.us-east-1.vpce.amazonaws.com"
+	var opts ClientOptions
+	WithRegion("us-east-1")(&opts)
+	WithBedrockEndpointOverride(endpoint)(&opts)
+	WithBedrockRegions([]string{"us-east-1", "us-west-2"})(&opts)
+
+	client, err := NewBedrockClient(context.Background(), opts)
+	if err != nil {
+		t.Fatalf("NewBedrockClient failed: %v", err)
+	}
+	if got := aws.ToString(client.Unwrap().Options().BaseEndpoint); got != endpoint {
+		t.Errorf("expected the SDK client to use endpoint %q, got %q", endpoint, got)
+	}
+	failover := client.failoverRegions[0].runtime.(*bedrockRuntimeAPI).client
+	if got := failover.Options().BaseEndpoint; got != nil {
+		t.Errorf("expected the failover region to use its default endpoint, got %q", aws.ToString(got))
+	}
+
+	for _, invalid := range []string{"http://bedrock.internal", "vpce.example.com", "https://"} {
+		_, err := NewBedrockClient(context.Background(), ClientOptions{Bedrock: BedrockOptions{EndpointURL: invalid}})
+		if err == nil || !strings.Contains(err.Error(), "endpoint URL") {
+			t.Errorf("%q: expected an invalid endpoint URL error, got %v", invalid, err)
+		}
+	}
+}
+
+func TestConfigLoadContext(t *testing.T) {
+	tests := []struct {
+		name         string
+		timeout      time.Duration
+		wantDeadline time.Duration
+	}{
+		{name: "default", wantDeadline: 30 * time.Second},
+		{name: "custom", timeout: 2 * time.Minute, wantDeadline: 2 * time.Minute},
+		{name: "disabled", timeout: -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var opts ClientOptions
+			WithBedrockConfigLoadTimeout(tt.timeout)(&opts)
+			start := time.Now()
+			ctx, cancel := configLoadContext(context.Background(), opts.Bedrock.ConfigLoadTimeout)
+			defer cancel()
+
+			deadline, ok := ctx.Deadline()
+			if tt.wantDeadline == 0 {
+				if ok {
+					t.Errorf("expected no deadline, got one in %v", deadline.Sub(start))
+				}
+				return
+			}
+			if !ok {
+				t.Fatal("expected a deadline")
+			}
+			if got := deadline.Sub(start); got < tt.wantDeadline || got > tt.wantDeadline+time.Second {
+				t.Errorf("expected a deadline in %v, got one in %v", tt.wantDeadline, got)
+			}
+		})
+	}
+
+	if _, err := NewBedrockClient(context.Background(), ClientOptions{Region: "us-east-1", Bedrock: BedrockOptions{ConfigLoadTimeout: -1}}); err != nil {
+		t.Errorf("expected the client to be created without a config load timeout, got %v", err)
+	}
+}
+
+func TestNewBedrockClientRetryBackoff(t *testing.T) {
+	var opts ClientOptions
+	WithRegion("us-east-1")(&opts)
+	WithInitialBackoff(100 * time.Millisecond)(&opts)
+	WithMaxBackoff(time.Second)(&opts)
+
+	client, err := NewBedrockClient(context.Background(), opts)
+	if err != nil {
+		t.Fatalf("NewBedrockClient failed: %v", err)
+	}
+	retryer := client.AWSConfig().Retryer()
+	throttled := errors.New("throttled")
+	for attempt, wantMin := range []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond, time.Second} {
+		delay, err := retryer.RetryDelay(attempt+1, throttled)
+		if err != nil {
+			t.Fatalf("RetryDelay failed: %v", err)
+		}
+		if delay < wantMin || delay > time.Second {
+			t.Errorf("attempt %d: expected a delay between %v and %v, got %v", attempt+1, wantMin, time.Second, delay)
+		}
+	}
+}
+
+func TestBedrockEndpoint(t *testing.T) {
+	httpClient := &capturingHTTPClient{}
+	cfg := aws.Config{
+		Region:     "us-east-1",
+		HTTPClient: httpClient,
+		Credentials: aws.CredentialsProviderFunc(func(ctx context.Context) (aws.Credentials, error) {
+			return aws.Credentials{AccessKeyID: "id", SecretAccessKey: "secret"}, nil
+		}),
+	}
+	client := bedrockruntime.NewFromConfig(cfg, withBedrockEndpoint("https://bedrock-runtime-fips.us-east-1.amazonaws.com"))
+	client.Converse(context.Background(), &bedrockruntime.ConverseInput{ModelId: aws.String("model")})
+
+	if len(httpClient.requests) == 0 {
+		t.Fatal("expected a request to be sent")
+	}
+	if got := httpClient.requests[0].URL.Host; got != "bedrock-runtime-fips.us-east-1.amazonaws.com" {
+		t.Errorf("expected the request to be sent to the FIPS endpoint, got %q", got)
+	}
+}
+
+// capturingHTTPClient records the requests sent by the AWS SDK, and fails them.
+type capturingHTTPClient struct {
+	requests []*http.Request
+}
+
+func (c *capturingHTTPClient) Do(req *http.Request) (*http.Response, error) {
+	c.requests = append(c.requests, req)
+	return nil, errors.New("not sending request")
+}
+
+func TestBedrockUserAgent(t *testing.T) {
+	httpClient := &capturingHTTPClient{}
+	cfg := aws.Config{
+		Region:     "us-east-1",
+		HTTPClient: httpClient,
+		Credentials: aws.CredentialsProviderFunc(func(ctx context.Context) (aws.Credentials, error) {
+			return aws.Credentials{AccessKeyID: "id", SecretAccessKey: "secret"}, nil
+		}),
+	}
+	client := bedrockruntime.NewFromConfig(cfg, withBedrockUserAgent("kubectl-ai/v1.2.3"))
+	client.Converse(context.Background(), &bedrockruntime.ConverseInput{ModelId: aws.String("model")})
+
+	if len(httpClient.requests) == 0 {
+		t.Fatal("expected a request to be sent")
+	}
+	// The SDK's own User-Agent is kept, and kubectl-ai is appended to it
+	userAgent := httpClient.requests[0].Header.Get("User-Agent")
+	if !strings.Contains(userAgent, "aws-sdk-go-v2/") || !strings.Contains(userAgent, "kubectl-ai/v1.2.3") {
+		t.Errorf("expected User-Agent to contain the SDK and kubectl-ai, got %q", userAgent)
+	}
+}
+
+func TestNewBedrockClientRegionFromInstanceMetadata(t *testing.T) {
+	tests := []struct {
+		name   string
+		region string
+		want   string
+	}{
+		{name: "available", region: "eu-central-1", want: "eu-central-1"},
+		{name: "unavailable", want: "us-east-1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			imdsServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch {
+				case r.Method == http.MethodPut && r.URL.Path == "/latest/api/token":
+					w.Header().Set("X-Aws-Ec2-Metadata-Token-Ttl-Seconds", "21600")
+					fmt.Fprint(w, "token")
+				case r.URL.Path == "/latest/dynamic/instance-identity/document" && tt.region != "":
+					fmt.Fprintf(w, `{"region": %q}`, tt.region)
+				default:
+					http.NotFound(w, r)
+				}
+			}))
+			defer imdsServer.Close()
+
+			dir := t.TempDir()
+			t.Setenv("AWS_EC2_METADATA_SERVICE_ENDPOINT", imdsServer.URL)
+			t.Setenv("AWS_EC2_METADATA_DISABLED", "")
+			t.Setenv("AWS_REGION", "")
+			t.Setenv("AWS_DEFAULT_REGION", "")
+			t.Setenv("AWS_CONFIG_FILE", filepath.Join(dir, "config"))
+			t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(dir, "credentials"))
+
+			var opts ClientOptions
+			WithBedrockRegionFromInstanceMetadata()(&opts)
+			client, err := NewBedrockClient(context.Background(), opts)
+			if err != nil {
+				t.Fatalf("NewBedrockClient failed: %v", err)
+			}
+			if client.region != tt.want {
+				t.Errorf("expected region %q, got %q", tt.want, client.region)
+			}
+		})
+	}
+}
+
+func TestNewBedrockClientInvalidRegion(t *testing.T) {
+	tests := []struct {
+		region  string
+		wantErr string
+	}{
+		{region: "us-east1", wantErr: `invalid AWS region "us-east1", did you mean "us-east-1"?`},
+		{region: "mars-north-1", wantErr: `invalid AWS region "mars-north-1"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.region, func(t *testing.T) {
+			_, err := NewBedrockClient(context.Background(), ClientOptions{Region: tt.region})
+			if err == nil {
+				t.Fatal("expected error for invalid region")
+			}
+			if err.Error() != tt.wantErr {
+				t.Errorf("expected error %q, got %q", tt.wantErr, err.Error())
+			}
+		})
+	}
+}
+
+func TestNewBedrockClientUnknownRegion(t *testing.T) {
+	for _, region := range []string{"eu-isoe-west-1", "us-isof-south-1", "xx-newregion-1"} {
+		t.Run(region, func(t *testing.T) {
+			client, err := NewBedrockClient(context.Background(), ClientOptions{Region: region})
+			if err != nil {
+				t.Fatalf("unexpected error for well-formed region: %v", err)
+			}
+			if client.region != region {
+				t.Errorf("expected region %q, got %q", region, client.region)
+			}
+		})
+	}
+}
+
+func TestBedrockCredentialsRefresh(t *testing.T) {
+	expired := &smithy.GenericAPIError{Code: "ExpiredTokenException", Message: "The security token included in the request is expired"}
+	tests := []struct {
+		name        string
+		err         error
+		wantReloads int
+		wantErr     bool
+	}{
+		{name: "expired token", err: expired, wantReloads: 1},
+		{name: "other error", err: &types.AccessDeniedException{Message: aws.String("denied")}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stale := &fakeBedrockAPI{err: tt.err}
+			reloads := 0
+			runtime := &refreshingBedrockAPI{
+				api: stale,
+				reload: func(ctx context.Context) (bedrockAPI, error) {
+					reloads++
+					return &fakeBedrockAPI{converseOutputs: []*bedrockruntime.ConverseOutput{
+						assistantOutput(&types.ContentBlockMemberText{Value: "Hello!"}),
+					}}, nil
+				},
+			}
+			chat := newFakeBedrockChat(&fakeBedrockAPI{})
+			chat.client.runtime = runtime
+
+			_, err := chat.Send(context.Background(), "hello")
+			if gotErr := err != nil; gotErr != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if reloads != tt.wantReloads {
+				t.Errorf("expected %d config reloads, got %d", tt.wantReloads, reloads)
+			}
+			if len(stale.converseInputs) != 1 {
+				t.Errorf("expected 1 request with the stale credentials, got %d", len(stale.converseInputs))
+			}
+		})
+	}
+}
+
+// fakeBedrockControlAPI is a bedrockControlAPI that returns errs in order, after recording the inputs.
+type fakeBedrockControlAPI struct {
+	errs   []error
+	inputs []*bedrock.GetFoundationModelInput
+}
+
+func (f *fakeBedrockControlAPI) GetFoundationModel(ctx context.Context, input *bedrock.GetFoundationModelInput, optFns ...func(*bedrock.Options)) (*bedrock.GetFoundationModelOutput, error) {
+	f.inputs = append(f.inputs, input)
+	var err error
+	if len(f.errs) > 0 {
+		err, f.errs = f.errs[0], f.errs[1:]
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &bedrock.GetFoundationModelOutput{}, nil
+}
+
+func TestBedrockHealthCheck(t *testing.T) {
+	ctx := context.Background()
+	denied := &types.AccessDeniedException{Message: aws.String("invalid credentials")}
+	runtime := &fakeBedrockAPI{}
+	control := &fakeBedrockControlAPI{errs: []error{nil, denied}}
+	bedrockClient := &BedrockClient{runtime: runtime, control: control, region: "us-east-1"}
+	client := withCircuitBreaker(bedrockClient, ClientOptions{
+		CircuitBreaker: &CircuitBreakerConfig{Threshold: 1, Cooldown: time.Minute},
+	})
+
+	if err := HealthCheck(ctx, client); err != nil {
+		t.Fatalf("expected health check to pass, got %v", err)
+	}
+	if got := aws.ToString(control.inputs[0].ModelIdentifier); got != "anthropic.claude-sonnet-4-20250514-v1:0" {
+		t.Errorf("expected the default foundation model, got %q", got)
+	}
+	if len(runtime.converseInputs) != 0 {
+		t.Errorf("expected no billed Converse requests, got %d", len(runtime.converseInputs))
+	}
+
+	if err := HealthCheck(ctx, client); !errors.As(err, &denied) {
+		t.Errorf("expected access denied error, got %v", err)
+	}
+
+	bedrockClient.Close()
+	if err := bedrockClient.HealthCheck(ctx); !errors.Is(err, ErrClientClosed) {
+		t.Errorf("expected ErrClientClosed after Close, got %v", err)
+	}
+}
+
+func TestHealthCheckUnsupported(t *testing.T) {
+	if err := HealthCheck(context.Background(), &fakeChatClient{}); err != nil {
+		t.Errorf("expected nil for a client without health checks, got %v", err)
+	}
+}
+
+func TestBedrockSendUsage(t *testing.T) {
+	fake := &fakeBedrockAPI{converseOutputs: []*bedrockruntime.ConverseOutput{
+		{
+			Output: &types.ConverseOutputMemberMessage{Value: types.Message{
+				Role:    types.ConversationRoleAssistant,
+				Content: []types.ContentBlock{&types.ContentBlockMemberText{Value: "hi"}},
+			}},
+			Usage: &types.TokenUsage{
+				InputTokens:  aws.Int32(1000),
+				OutputTokens: aws.Int32(200),
+				TotalTokens:  aws.Int32(1200),
+			},
+		},
+	}}
+	chat := newFakeBedrockChat(fake)
+
+	response, err := chat.Send(context.Background(), "hello")
+	if err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+
+	usage, ok := response.UsageMetadata().(*Usage)
+	if !ok {
+		t.Fatalf("expected *Usage, got %T", response.UsageMetadata())
+	}
+	if usage.InputTokens != 1000 || usage.OutputTokens != 200 || usage.TotalTokens != 1200 {
+		t.Errorf("unexpected token counts: %+v", usage)
+	}
+	if usage.Provider != "bedrock" || usage.Model != "us.anthropic.claude-sonnet-4-20250514-v1:0" {
+		t.Errorf("unexpected provider/model: %q/%q", usage.Provider, usage.Model)
+	}
+	if usage.Estimated || usage.Source != UsageSourceAPI {
+		t.Errorf("expected usage from the API, got estimated=%v source=%q", usage.Estimated, usage.Source)
+	}
+	// Claude Sonnet 4 is $3/M input and $15/M output tokens
+	if usage.InputCost != 0.003 || usage.OutputCost != 0.003 || usage.TotalCost != 0.006 {
+		t.Errorf("unexpected costs: input=%v output=%v total=%v", usage.InputCost, usage.OutputCost, usage.TotalCost)
+	}
+}
+
+func TestBedrockSendStreamingUsageCallback(t *testing.T) {
+	stream := &fakeEventStream{events: []types.ConverseStreamOutput{
+		textDeltaEvent("Hello, "),
+		textDeltaEvent("world"),
+		&types.ConverseStreamOutputMemberMessageStop{Value: types.MessageStopEvent{StopReason: types.StopReasonEndTurn}},
+		&types.ConverseStreamOutputMemberMetadata{Value: types.ConverseStreamMetadataEvent{
+			Usage: &types.TokenUsage{
+				InputTokens:  aws.Int32(1000),
+				OutputTokens: aws.Int32(200),
+				TotalTokens:  aws.Int32(1200),
+			},
+		}},
+	}}
+	var reported []*Usage
+	client := observeClient(&BedrockClient{runtime: &fakeBedrockAPI{streams: []*fakeEventStream{stream}}}, ClientOptions{
+		UsageCallbacks: []UsageCallback{func(info RequestInfo, usage *Usage) {
+			if !info.Stream {
+				t.Errorf("expected a streaming request, got %+v", info)
+			}
+			reported = append(reported, usage)
+		}},
+	})
+	chat := client.StartChat("", "us.anthropic.claude-sonnet-4-20250514-v1:0")
+
+	iterator, err := chat.SendStreaming(context.Background(), "hello")
+	if err != nil {
+		t.Fatalf("SendStreaming failed: %v", err)
+	}
+	for _, err := range iterator {
+		if err != nil {
+			t.Fatalf("unexpected stream error: %v", err)
+		}
+	}
+
+	// Only the terminal metadata event reports usage
+	if len(reported) != 1 {
+		t.Fatalf("expected usage to be reported once, got %d", len(reported))
+	}
+	usage := reported[0]
+	if usage.InputTokens != 1000 || usage.OutputTokens != 200 || usage.TotalTokens != 1200 {
+		t.Errorf("unexpected token counts: %+v", usage)
+	}
+	if usage.TotalCost != 0.006 {
+		t.Errorf("expected total cost 0.006, got %v", usage.TotalCost)
+	}
+}
+
+func TestBedrockSendContentFiltered(t *testing.T) {
+	output := assistantOutput(&types.ContentBlockMemberText{Value: "I can't help with that."})
+	output.StopReason = types.StopReasonContentFiltered
+	chat := newFakeBedrockChat(&fakeBedrockAPI{converseOutputs: []*bedrockruntime.ConverseOutput{output}})
+
+	response, err := chat.Send(context.Background(), "hello")
+	if err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+
+	candidate := response.Candidates()[0]
+	if candidate.FinishReason() != FinishReasonContentFiltered {
+		t.Errorf("expected finish reason %q, got %q", FinishReasonContentFiltered, candidate.FinishReason())
+	}
+	if !candidate.IsRefusal() {
+		t.Error("expected candidate to be a refusal")
+	}
+}
+
+func TestBedrockSendStreamingContentFiltered(t *testing.T) {
+	stream := &fakeEventStream{events: []types.ConverseStreamOutput{
+		textDeltaEvent("I can't"),
+		&types.ConverseStreamOutputMemberMessageStop{Value: types.MessageStopEvent{
+			StopReason: types.StopReasonGuardrailIntervened,
+		}},
+		&types.ConverseStreamOutputMemberMetadata{Value: types.ConverseStreamMetadataEvent{}},
+	}}
+	chat := newFakeBedrockChat(&fakeBedrockAPI{streams: []*fakeEventStream{stream}})
+
+	iterator, err := chat.SendStreaming(context.Background(), "hello")
+	if err != nil {
+		t.Fatalf("SendStreaming failed: %v", err)
+	}
+
+	var reasons []FinishReason
+	refused := false
+	for response, err := range iterator {
+		if err != nil {
+			t.Fatalf("unexpected stream error: %v", err)
+		}
+		for _, candidate := range response.Candidates() {
+			reasons = append(reasons, candidate.FinishReason())
+			refused = refused || candidate.IsRefusal()
+		}
+	}
+
+	want := []FinishReason{FinishReasonUnspecified, FinishReasonContentFiltered}
+	if !reflect.DeepEqual(reasons, want) {
+		t.Errorf("expected finish reasons %v, got %v", want, reasons)
+	}
+	if !refused {
+		t.Error("expected the stream to end in a refusal")
+	}
+}
+
+func TestBedrockFinishReason(t *testing.T) {
+	tests := []struct {
+		stopReason types.StopReason
+		want       FinishReason
+	}{
+		{"", FinishReasonUnspecified},
+		{types.StopReasonEndTurn, FinishReasonStop},
+		{types.StopReasonStopSequence, FinishReasonStop},
+		{types.StopReasonMaxTokens, FinishReasonMaxTokens},
+		{types.StopReasonToolUse, FinishReasonToolUse},
+		{types.StopReasonContentFiltered, FinishReasonContentFiltered},
+		{types.StopReasonGuardrailIntervened, FinishReasonContentFiltered},
+		{"something_new", FinishReasonOther},
+	}
+	for _, tt := range tests {
+		if got := bedrockFinishReason(tt.stopReason); got != tt.want {
+			t.Errorf("bedrockFinishReason(%q) = %q, want %q", tt.stopReason, got, tt.want)
+		}
+	}
+}
+
+func TestBedrockGenerateCompletionToolCallsOnly(t *testing.T) {
+	fake := &fakeBedrockAPI{converseOutputs: []*bedrockruntime.ConverseOutput{
+		assistantOutput(&types.ContentBlockMemberToolUse{Value: types.ToolUseBlock{
+			ToolUseId: aws.String("call-1"),
+			Name:      aws.String("kubectl"),
+			Input:     document.NewLazyDocument(map[string]any{"command": "kubectl get pods"}),
+		}}),
+		assistantOutput(
+			&types.ContentBlockMemberText{Value: "Run "},
+			&types.ContentBlockMemberToolUse{Value: types.ToolUseBlock{ToolUseId: aws.String("call-2"), Name: aws.String("kubectl")}},
+			&types.ContentBlockMemberText{Value: "kubectl get pods."},
+		),
+	}}
+	client := &BedrockClient{runtime: fake}
+	req := &CompletionRequest{Model: "us.anthropic.claude-sonnet-4-20250514-v1:0", Prompt: "list all pods"}
+
+	_, err := client.GenerateCompletion(context.Background(), req)
+	if !errors.Is(err, ErrNoTextContent) || !strings.Contains(err.Error(), "kubectl") {
+		t.Fatalf("expected ErrNoTextContent naming the called function, got %v", err)
+	}
+
+	// Text alongside the function calls is still returned, in full.
+	response, err := client.GenerateCompletion(context.Background(), req)
+	if err != nil {
+		t.Fatalf("GenerateCompletion failed: %v", err)
+	}
+	if got := response.Response(); got != "Run kubectl get pods." {
+		t.Errorf("expected the text of the response, got %q", got)
+	}
+}
+
+func TestBedrockGenerateCompletionStream(t *testing.T) {
+	fake := &fakeBedrockAPI{
+		converseOutputs: []*bedrockruntime.ConverseOutput{
+			{
+				Output: &types.ConverseOutputMemberMessage{Value: types.Message{
+					Role:    types.ConversationRoleAssistant,
+					Content: []types.ContentBlock{&types.ContentBlockMemberText{Value: "kubectl get pods -A"}},
+				}},
+			},
+		},
+		streams: []*fakeEventStream{
+			{events: []types.ConverseStreamOutput{
+				textDeltaEvent("kubectl "),
+				textDeltaEvent("get pods"),
+				textDeltaEvent(" -A"),
+			}},
+		},
+	}
+	client := &BedrockClient{runtime: fake}
+	req := &CompletionRequest{Model: "us.anthropic.claude-sonnet-4-20250514-v1:0", Prompt: "list all pods"}
+
+	buffered, err := client.GenerateCompletion(context.Background(), req)
+	if err != nil {
+		t.Fatalf("GenerateCompletion failed: %v", err)
+	}
+
+	iterator, err := client.GenerateCompletionStream(context.Background(), req)
+	if err != nil {
+		t.Fatalf("GenerateCompletionStream failed: %v", err)
+	}
+	var streamed strings.Builder
+	chunks := 0
+	for response, err := range iterator {
+		if err != nil {
+			t.Fatalf("unexpected stream error: %v", err)
+		}
+		chunks++
+		streamed.WriteString(response.Response())
+	}
+
+	if chunks != 3 {
+		t.Errorf("expected 3 chunks, got %d", chunks)
+	}
+	if streamed.String() != buffered.Response() {
+		t.Errorf("expected streamed text %q to match buffered text %q", streamed.String(), buffered.Response())
+	}
+
+	converseInput, streamInput := fake.converseInputs[0], fake.streamInputs[0]
+	if *converseInput.ModelId != *streamInput.ModelId || !reflect.DeepEqual(converseInput.InferenceConfig, streamInput.InferenceConfig) {
+		t.Errorf("expected streaming request to reuse the inference configuration")
+	}
+}
+
+func TestNewBedrockClientFailFastOnNoCredentials(t *testing.T) {
+	// Isolate the test from any credentials on the machine
+	for _, env := range []string{
+		"AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY", "AWS_SESSION_TOKEN", "AWS_PROFILE",
+		"AWS_WEB_IDENTITY_TOKEN_FILE", "AWS_ROLE_ARN",
+		"AWS_CONTAINER_CREDENTIALS_RELATIVE_URI", "AWS_CONTAINER_CREDENTIALS_FULL_URI",
+	} {
+		t.Setenv(env, "")
+	}
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(t.TempDir(), "config"))
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(t.TempDir(), "credentials"))
+	t.Setenv("AWS_EC2_METADATA_DISABLED", "true")
+
+	start := time.Now()
+	_, err := NewBedrockClient(context.Background(), ClientOptions{
+		Region:  "us-east-1",
+		Bedrock: BedrockOptions{FailFastOnNoCredentials: true},
+	})
+	elapsed := time.Since(start)
+
+	if !errors.Is(err, ErrNoAWSCredentials) {
+		t.Fatalf("expected ErrNoAWSCredentials, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "no AWS credentials found") {
+		t.Errorf("expected a descriptive error, got %q", err.Error())
+	}
+	if elapsed > credentialsProbeTimeout {
+		t.Errorf("expected construction to fail fast, took %v", elapsed)
+	}
+}
+
+func TestBedrockSendToolResult(t *testing.T) {
+	tests := []struct {
+		name       string
+		result     FunctionCallResult
+		wantStatus types.ToolResultStatus
+	}{
+		{
+			name:       "success",
+			result:     FunctionCallResult{ID: "call-1", Name: "kubectl", Result: map[string]any{"stdout": "pod/nginx created"}},
+			wantStatus: types.ToolResultStatusSuccess,
+		},
+		{
+			name:       "error",
+			result:     FunctionCallResult{ID: "call-1", Name: "kubectl", Result: map[string]any{"error": "forbidden"}, IsError: true},
+			wantStatus: types.ToolResultStatusError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeBedrockAPI{
+				converseOutputs: []*bedrockruntime.ConverseOutput{{
+					Output: &types.ConverseOutputMemberMessage{Value: types.Message{
+						Role:    types.ConversationRoleAssistant,
+						Content: []types.ContentBlock{&types.ContentBlockMemberText{Value: "The pod was created."}},
+					}},
+				}},
+			}
+			chat := newFakeBedrockChat(fake)
+			chat.messages = []types.Message{
+				{Role: types.ConversationRoleUser, Content: []types.ContentBlock{&types.ContentBlockMemberText{Value: "create an nginx pod"}}},
+				{Role: types.ConversationRoleAssistant, Content: []types.ContentBlock{&types.ContentBlockMemberToolUse{Value: types.ToolUseBlock{
+					ToolUseId: aws.String("call-1"),
+					Name:      aws.String("kubectl"),
+					Input:     document.NewLazyDocument(map[string]any{"command": "kubectl run nginx --image=nginx"}),
+				}}}},
+			}
+
+			if _, err := chat.Send(context.Background(), tt.result); err != nil {
+				t.Fatalf("Send failed: %v", err)
+			}
+
+			messages := fake.converseInputs[0].Messages
+			block, ok := messages[len(messages)-1].Content[0].(*types.ContentBlockMemberToolResult)
+			if !ok {
+				t.Fatalf("expected a tool result block, got %T", messages[len(messages)-1].Content[0])
+			}
+			if got := aws.ToString(block.Value.ToolUseId); got != "call-1" {
+				t.Errorf("expected tool use ID %q, got %q", "call-1", got)
+			}
+			if block.Value.Status != tt.wantStatus {
+				t.Errorf("expected status %q, got %q", tt.wantStatus, block.Value.Status)
+			}
+
+			// The status survives a round trip through the history
+			history, err := chat.MarshalHistory()
+			if err != nil {
+				t.Fatalf("MarshalHistory failed: %v", err)
+			}
+			restored := newFakeBedrockChat(&fakeBedrockAPI{})
+			if err := restored.RestoreHistory(history); err != nil {
+				t.Fatalf("RestoreHistory failed: %v", err)
+			}
+			block = restored.messages[2].Content[0].(*types.ContentBlockMemberToolResult)
+			if block.Value.Status != tt.wantStatus {
+				t.Errorf("expected restored status %q, got %q", tt.wantStatus, block.Value.Status)
+			}
+		})
+	}
+}
+
+func TestBedrockSendLargeToolResult(t *testing.T) {
+	fake := &fakeBedrockAPI{converseOutputs: []*bedrockruntime.ConverseOutput{
+		assistantOutput(&types.ContentBlockMemberText{Value: "The logs are long."}),
+	}}
+	chat := newFakeBedrockChat(fake)
+	chat.client.toolResults.maxBytes = 100
+	chat.messages = []types.Message{
+		{Role: types.ConversationRoleUser, Content: []types.ContentBlock{&types.ContentBlockMemberText{Value: "show the logs"}}},
+		{Role: types.ConversationRoleAssistant, Content: []types.ContentBlock{
+			&types.ContentBlockMemberToolUse{Value: types.ToolUseBlock{ToolUseId: aws.String("call-1"), Name: aws.String("kubectl")}},
+			&types.ContentBlockMemberToolUse{Value: types.ToolUseBlock{ToolUseId: aws.String("call-2"), Name: aws.String("kubectl")}},
+		}},
+	}
+
+	results := []FunctionCallResult{
+		{ID: "call-1", Name: "kubectl", Result: map[string]any{"stdout": strings.Repeat("log line\n", 1000)}},
+		{ID: "call-2", Name: "kubectl", Result: map[string]any{"stdout": "pod/nginx"}},
+	}
+	if _, err := chat.Send(context.Background(), results); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+
+	content := fake.converseInputs[0].Messages[2].Content
+	large := content[0].(*types.ContentBlockMemberToolResult).Value.Content[0]
+	text, ok := large.(*types.ToolResultContentBlockMemberText)
+	if !ok {
+		t.Fatalf("expected the large result to be sent as text, got %T", large)
+	}
+	if !strings.HasPrefix(text.Value, `{"stdout":"log line`) || !strings.HasSuffix(text.Value, "...[truncated 9913 bytes]") {
+		t.Errorf("expected the result cut to 100 bytes with a marker, got %q", text.Value)
+	}
+
+	// Results that fit are still sent as JSON
+	small := content[1].(*types.ContentBlockMemberToolResult).Value.Content[0]
+	data, err := small.(*types.ToolResultContentBlockMemberJson).Value.MarshalSmithyDocument()
+	if err != nil || !json.Valid(data) {
+		t.Errorf("expected the small result as valid JSON, got %s (%v)", data, err)
+	}
+}
+
+func TestBedrockToolResultFormat(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   ToolResultFormat
+		wantText bool
+	}{
+		{name: "default", wantText: false},
+		{name: "json", format: ToolResultFormatJSON, wantText: false},
+		{name: "text", format: ToolResultFormatText, wantText: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var opts ClientOptions
+			if tt.format != "" {
+				WithBedrockToolResultFormat(tt.format)(&opts)
+			}
+			fake := &fakeBedrockAPI{converseOutputs: []*bedrockruntime.ConverseOutput{
+				assistantOutput(&types.ContentBlockMemberText{Value: "nginx is running."}),
+			}}
+			chat := newFakeBedrockChat(fake)
+			chat.client.toolResults.format = opts.Bedrock.ToolResultFormat
+			chat.messages = []types.Message{
+				{Role: types.ConversationRoleUser, Content: []types.ContentBlock{&types.ContentBlockMemberText{Value: "get the pods"}}},
+				{Role: types.ConversationRoleAssistant, Content: []types.ContentBlock{
+					&types.ContentBlockMemberToolUse{Value: types.ToolUseBlock{ToolUseId: aws.String("call-1"), Name: aws.String("kubectl")}},
+				}},
+			}
+
+			result := FunctionCallResult{ID: "call-1", Name: "kubectl", Result: map[string]any{"stdout": "pod/nginx"}}
+			if _, err := chat.Send(context.Background(), result); err != nil {
+				t.Fatalf("Send failed: %v", err)
+			}
+
+			content := fake.converseInputs[0].Messages[2].Content[0].(*types.ContentBlockMemberToolResult).Value.Content[0]
+			switch content := content.(type) {
+			case *types.ToolResultContentBlockMemberText:
+				if !tt.wantText {
+					t.Fatalf("expected a JSON tool result, got text %q", content.Value)
+				}
+				if content.Value != `{"stdout":"pod/nginx"}` {
+					t.Errorf("expected the JSON encoding of the result as text, got %q", content.Value)
+				}
+			case *types.ToolResultContentBlockMemberJson:
+				if tt.wantText {
+					t.Fatal("expected a text tool result, got JSON")
+				}
+			default:
+				t.Fatalf("unexpected tool result content %T", content)
+			}
+
+			// Either way, the result is restored from the history as sent.
+			history, err := chat.MarshalHistory()
+			if err != nil {
+				t.Fatalf("MarshalHistory failed: %v", err)
+			}
+			var messages []HistoryMessage
+			if err := json.Unmarshal(history, &messages); err != nil {
+				t.Fatalf("decoding history: %v", err)
+			}
+			restored := messages[2].Parts[0].FunctionCallResult
+			if restored == nil || restored.Result["stdout"] != "pod/nginx" {
+				t.Errorf("expected the result to be restored, got %+v", restored)
+			}
+		})
+	}
+
+	_, err := NewBedrockClient(context.Background(), ClientOptions{Region: "us-east-1", Bedrock: BedrockOptions{ToolResultFormat: "xml"}})
+	if err == nil || !strings.Contains(err.Error(), "tool result format") {
+		t.Errorf("expected an unknown tool result format error, got %v", err)
+	}
+}
+
+func TestBedrockConfiguredRequestMetadata(t *testing.T) {
+	var opts ClientOptions
+	WithBedrockRequestMetadata(map[string]string{"tenant": "team-a"})(&opts)
+	WithBedrockRequestMetadata(map[string]string{"feature": "kubectl-ai/diagnose"})(&opts)
+	want := map[string]string{"tenant": "team-a", "feature": "kubectl-ai/diagnose", "idempotencyKey": "key-1"}
+
+	fake := &fakeBedrockAPI{
+		converseOutputs: []*bedrockruntime.ConverseOutput{assistantOutput(&types.ContentBlockMemberText{Value: "hi"})},
+		streams:         []*fakeEventStream{{events: []types.ConverseStreamOutput{textDeltaEvent("hi")}}},
+	}
+	chat := newFakeBedrockChat(fake)
+	chat.client.opts = opts.Bedrock
+
+	ctx := WithIdempotencyKey(context.Background(), "key-1")
+	if _, err := chat.Send(ctx, "hello"); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	stream, err := chat.SendStreaming(ctx, "hello")
+	if err != nil {
+		t.Fatalf("SendStreaming failed: %v", err)
+	}
+	for _, err := range stream {
+		if err != nil {
+			t.Fatalf("stream failed: %v", err)
+		}
+	}
+	if got := fake.converseInputs[0].RequestMetadata; !maps.Equal(got, want) {
+		t.Errorf("expected Converse request metadata %v, got %v", want, got)
+	}
+	if got := fake.streamInputs[0].RequestMetadata; !maps.Equal(got, want) {
+		t.Errorf("expected ConverseStream request metadata %v, got %v", want, got)
+	}
+
+	tooMany := map[string]string{}
+	for i := range 15 {
+		tooMany[fmt.Sprintf("key-%d", i)] = "value"
+	}
+	for _, invalid := range []map[string]string{
+		{"": "team-a"},
+		{"tenant!": "team-a"},
+		{"tenant": "team<a>"},
+		{strings.Repeat("k", 257): "team-a"},
+		{"tenant": strings.Repeat("v", 257)},
+		{"requestID": "trace-123"},
+		tooMany,
+	} {
+		_, err := NewBedrockClient(context.Background(), ClientOptions{Region: "us-east-1", Bedrock: BedrockOptions{RequestMetadata: invalid}})
+		if err == nil || !strings.Contains(err.Error(), "request metadata") {
+			t.Errorf("%v: expected an invalid request metadata error, got %v", invalid, err)
+		}
+	}
+	if _, err := NewBedrockClient(context.Background(), ClientOptions{Region: "us-east-1", Bedrock: opts.Bedrock}); err != nil {
+		t.Errorf("expected valid request metadata to be accepted, got %v", err)
+	}
+}
+
+func TestBedrockSendUnsupportedContent(t *testing.T) {
+	chat := newFakeBedrockChat(&fakeBedrockAPI{})
+	if _, err := chat.Send(context.Background(), 42); err == nil {
+		t.Fatal("expected an error for unsupported content")
+	}
+	if len(chat.messages) != 0 {
+		t.Errorf("expected history to be unchanged, got %d messages", len(chat.messages))
+	}
+}
+
+func TestBedrockSendDocument(t *testing.T) {
+	pdf := []byte("%PDF-1.4\n1 0 obj << /Type /Catalog >> endobj\n%%EOF\n")
+	fake := &fakeBedrockAPI{converseOutputs: []*bedrockruntime.ConverseOutput{
+		assistantOutput(&types.ContentBlockMemberText{Value: "The report looks fine."}),
+	}}
+	chat := newFakeBedrockChat(fake)
+
+	doc := DocumentPart{Name: "cluster report", Format: "PDF", Bytes: pdf}
+	if _, err := chat.Send(context.Background(), "summarize this report", doc); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+
+	content := fake.converseInputs[0].Messages[0].Content
+	if len(content) != 2 {
+		t.Fatalf("expected text and document blocks, got %d blocks", len(content))
+	}
+	block, ok := content[1].(*types.ContentBlockMemberDocument)
+	if !ok {
+		t.Fatalf("expected a document block, got %T", content[1])
+	}
+	if aws.ToString(block.Value.Name) != "cluster report" || block.Value.Format != types.DocumentFormatPdf {
+		t.Errorf("unexpected document name/format: %q/%q", aws.ToString(block.Value.Name), block.Value.Format)
+	}
+	source, ok := block.Value.Source.(*types.DocumentSourceMemberBytes)
+	if !ok || !reflect.DeepEqual(source.Value, pdf) {
+		t.Errorf("expected the document bytes to be sent, got %v", block.Value.Source)
+	}
+
+	// Documents survive a history round trip.
+	data, err := chat.MarshalHistory()
+	if err != nil {
+		t.Fatalf("MarshalHistory failed: %v", err)
+	}
+	restored := newFakeBedrockChat(&fakeBedrockAPI{})
+	if err := restored.RestoreHistory(data); err != nil {
+		t.Fatalf("RestoreHistory failed: %v", err)
+	}
+	if !reflect.DeepEqual(restored.messages[0].Content[1], block) {
+		t.Errorf("expected the document to be restored, got %+v", restored.messages[0].Content[1])
+	}
+}
+
+func TestBedrockSendInvalidDocument(t *testing.T) {
+	tests := []struct {
+		name    string
+		docs    []any
+		wantErr string
+	}{
+		{
+			name:    "unsupported format",
+			docs:    []any{DocumentPart{Name: "logs", Format: "zip", Bytes: []byte("PK")}},
+			wantErr: `document "logs": unsupported format "zip"`,
+		},
+		{
+			name:    "invalid name",
+			docs:    []any{DocumentPart{Name: "pod.log", Format: "txt", Bytes: []byte("started")}},
+			wantErr: `invalid document name "pod.log"`,
+		},
+		{
+			name:    "empty",
+			docs:    []any{DocumentPart{Name: "logs", Format: "txt"}},
+			wantErr: `document "logs" is empty`,
+		},
+		{
+			name:    "too large",
+			docs:    []any{DocumentPart{Name: "logs", Format: "txt", Bytes: make([]byte, maxBedrockDocumentBytes+1)}},
+			wantErr: `document "logs" is 4500001 bytes`,
+		},
+		{
+			name:    "too many",
+			docs:    slices.Repeat([]any{DocumentPart{Name: "logs", Format: "txt", Bytes: []byte("started")}}, maxBedrockDocuments+1),
+			wantErr: "too many documents",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chat := newFakeBedrockChat(&fakeBedrockAPI{})
+			_, err := chat.Send(context.Background(), append([]any{"read this"}, tt.docs...)...)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+			if len(chat.messages) != 0 {
+				t.Errorf("expected history to be unchanged, got %d messages", len(chat.messages))
+			}
+		})
+	}
+}
+
+func TestBedrockInitialize(t *testing.T) {
+	// Persisted sessions are decoded from JSON, so payloads come back as generic values.
+	history := []*api.Message{
+		{ID: "1", Source: api.MessageSourceAgent, Type: api.MessageTypeText, Payload: "Hey there, what can I help you with today?"},
+		{ID: "2", Source: api.MessageSourceUser, Type: api.MessageTypeText, Payload: "create an nginx pod"},
+		{ID: "3", Source: api.MessageSourceModel, Type: api.MessageTypeText, Payload: "I'll create it."},
+		{ID: "4", Source: api.MessageSourceModel, Type: api.MessageTypeToolCallRequest, Payload: map[string]any{
+			"id":        "call-1",
+			"name":      "kubectl",
+			"arguments": map[string]any{"command": "kubectl run nginx --image=nginx"},
+		}},
+		{ID: "5", Source: api.MessageSourceAgent, Type: api.MessageTypeToolCallResponse, Payload: map[string]any{
+			"id":     "call-1",
+			"name":   "kubectl",
+			"result": map[string]any{"stdout": "pod/nginx created"},
+		}},
+		{ID: "6", Source: api.MessageSourceAgent, Type: api.MessageTypeError, Payload: "something went wrong"},
+		{ID: "7", Source: api.MessageSourceModel, Type: api.MessageTypeText, Payload: "The pod was created."},
+	}
+
+	chat := newFakeBedrockChat(&fakeBedrockAPI{})
+	if err := chat.Initialize(history); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+
+	wantRoles := []types.ConversationRole{
+		types.ConversationRoleUser,
+		types.ConversationRoleAssistant,
+		types.ConversationRoleUser,
+		types.ConversationRoleAssistant,
+	}
+	var gotRoles []types.ConversationRole
+	for _, msg := range chat.messages {
+		gotRoles = append(gotRoles, msg.Role)
+	}
+	if !reflect.DeepEqual(gotRoles, wantRoles) {
+		t.Fatalf("expected roles %v, got %v", wantRoles, gotRoles)
+	}
+
+	// The model's text and its tool call are merged into one assistant message.
+	assistant := chat.messages[1].Content
+	if len(assistant) != 2 {
+		t.Fatalf("expected 2 assistant content blocks, got %d", len(assistant))
+	}
+	toolUse, ok := assistant[1].(*types.ContentBlockMemberToolUse)
+	if !ok {
+		t.Fatalf("expected a tool use block, got %T", assistant[1])
+	}
+	if aws.ToString(toolUse.Value.ToolUseId) != "call-1" || aws.ToString(toolUse.Value.Name) != "kubectl" {
+		t.Errorf("unexpected tool use %q/%q", aws.ToString(toolUse.Value.ToolUseId), aws.ToString(toolUse.Value.Name))
+	}
+	b, err := toolUse.Value.Input.MarshalSmithyDocument()
+	if err != nil {
+		t.Fatalf("marshaling tool input: %v", err)
+	}
+	var input map[string]any
+	if err := json.Unmarshal(b, &input); err != nil {
+		t.Fatalf("unmarshaling tool input: %v", err)
+	}
+	if input["command"] != "kubectl run nginx --image=nginx" {
+		t.Errorf("unexpected tool input %v", input)
+	}
+
+	toolResult, ok := chat.messages[2].Content[0].(*types.ContentBlockMemberToolResult)
+	if !ok {
+		t.Fatalf("expected a tool result block, got %T", chat.messages[2].Content[0])
+	}
+	if got := aws.ToString(toolResult.Value.ToolUseId); got != "call-1" {
+		t.Errorf("expected tool result for %q, got %q", "call-1", got)
+	}
+}
+
+// assistantOutput returns a Converse output carrying an assistant message with the given content.
+func assistantOutput(content ...types.ContentBlock) *bedrockruntime.ConverseOutput {
+	return &bedrockruntime.ConverseOutput{
+		Output: &types.ConverseOutputMemberMessage{Value: types.Message{
+			Role:    types.ConversationRoleAssistant,
+			Content: content,
+		}},
+	}
+}
+
+func TestBedrockHistoryRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	fake := &fakeBedrockAPI{
+		converseOutputs: []*bedrockruntime.ConverseOutput{
+			assistantOutput(
+				&types.ContentBlockMemberText{Value: "Let me list the pods."},
+				&types.ContentBlockMemberToolUse{Value: types.ToolUseBlock{
+					ToolUseId: aws.String("call-1"),
+					Name:      aws.String("kubectl"),
+					Input:     document.NewLazyDocument(map[string]any{"command": "kubectl get pods"}),
+				}},
+			),
+			assistantOutput(&types.ContentBlockMemberText{Value: "There is one pod, nginx."}),
+			assistantOutput(&types.ContentBlockMemberText{Value: "It is running."}),
+		},
+	}
+	chat := newFakeBedrockChat(fake)
+	if _, err := chat.Send(ctx, "what pods are there?"); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	if _, err := chat.Send(ctx, FunctionCallResult{ID: "call-1", Name: "kubectl", Result: map[string]any{"stdout": "nginx"}}); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+
+	data, err := chat.MarshalHistory()
+	if err != nil {
+		t.Fatalf("MarshalHistory failed: %v", err)
+	}
+	var history []HistoryMessage
+	if err := json.Unmarshal(data, &history); err != nil {
+		t.Fatalf("parsing history: %v", err)
+	}
+	if len(history) != 4 {
+		t.Fatalf("expected 4 messages in history, got %d", len(history))
+	}
+	if call := history[1].Parts[1].FunctionCall; call == nil || call.ID != "call-1" || call.Arguments["command"] != "kubectl get pods" {
+		t.Errorf("expected the tool call to be serialized, got %+v", history[1].Parts[1])
+	}
+	if result := history[2].Parts[0].FunctionCallResult; result == nil || result.ID != "call-1" || result.Result["stdout"] != "nginx" {
+		t.Errorf("expected the tool result to be serialized, got %+v", history[2].Parts[0])
+	}
+
+	restoredFake := &fakeBedrockAPI{
+		converseOutputs: []*bedrockruntime.ConverseOutput{
+			assistantOutput(&types.ContentBlockMemberText{Value: "It is running."}),
+		},
+	}
+	restored := newFakeBedrockChat(restoredFake)
+	if err := restored.RestoreHistory(data); err != nil {
+		t.Fatalf("RestoreHistory failed: %v", err)
+	}
+
+	// The next Send must issue the same request from both chats.
+	if _, err := chat.Send(ctx, "is it running?"); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	if _, err := restored.Send(ctx, "is it running?"); err != nil {
+		t.Fatalf("Send on restored chat failed: %v", err)
+	}
+	want, err := (&bedrockChat{messages: fake.converseInputs[2].Messages}).MarshalHistory()
+	if err != nil {
+		t.Fatalf("MarshalHistory failed: %v", err)
+	}
+	got, err := (&bedrockChat{messages: restoredFake.converseInputs[0].Messages}).MarshalHistory()
+	if err != nil {
+		t.Fatalf("MarshalHistory failed: %v", err)
+	}
+	if string(got) != string(want) {
+		t.Errorf("restored chat sent a different conversation:\ngot:  %s\nwant: %s", got, want)
+	}
+}
+
+func TestBedrockRestoreHistoryInvalidRole(t *testing.T) {
+	chat := newFakeBedrockChat(&fakeBedrockAPI{})
+	if err := chat.RestoreHistory([]byte(`[{"role": "system", "parts": [{"text": "hi"}]}]`)); err == nil {
+		t.Fatal("expected an error for an unknown role")
+	}
+}
+
+func TestBedrockValidate(t *testing.T) {
+	chat := newFakeBedrockChat(&fakeBedrockAPI{})
+	if err := chat.Validate(); err != nil {
+		t.Fatalf("expected a new chat to be valid, got %v", err)
+	}
+
+	chat.messages = []types.Message{
+		{Role: types.ConversationRoleAssistant, Content: []types.ContentBlock{&types.ContentBlockMemberText{Value: "Hi!"}}},
+		{Role: types.ConversationRoleUser, Content: []types.ContentBlock{&types.ContentBlockMemberText{Value: "list pods"}}},
+		{Role: types.ConversationRoleUser, Content: []types.ContentBlock{&types.ContentBlockMemberText{Value: "in default"}}},
+	}
+	if err := chat.SetFunctionDefinitions([]*FunctionDefinition{
+		{Name: "kubectl", Parameters: &Schema{Type: TypeObject, Properties: map[string]*Schema{"command": {Type: TypeString}}}},
+		{Name: "run shell", Parameters: &Schema{
+			Type: TypeObject,
+			Properties: map[string]*Schema{
+				"commands": {Type: TypeArray, Items: &Schema{Type: TypeString}, MinItems: ptrTo[int64](2), MaxItems: ptrTo[int64](1)},
+			},
+			Required: []string{"cmd"},
+		}},
+	}); err != nil {
+		t.Fatalf("SetFunctionDefinitions failed: %v", err)
+	}
+
+	err := chat.Validate()
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("expected *ValidationError, got %v", err)
+	}
+	want := []string{
+		"message 0: conversation must start with a user message, not assistant",
+		"message 2: consecutive user messages; roles must alternate",
+		`function name "run shell" must be 1-64 letters, digits, underscores or hyphens`,
+		`function "run shell": required field "cmd" is not a declared property`,
+		`function "run shell": commands: minItems 2 is greater than maxItems 1`,
+	}
+	if !reflect.DeepEqual(validationErr.Problems, want) {
+		t.Errorf("expected problems:\n%q\ngot:\n%q", want, validationErr.Problems)
+	}
+}
+
+func TestInferenceProfilePrefixForRegion(t *testing.T) {
+	tests := []struct {
+		region string
+		want   string
+	}{
+		{region: "us-east-1", want: "us."},
+		{region: "us-west-1", want: "us."},
+		{region: "ca-central-1", want: "us."},
+		{region: "us-gov-east-1", want: "us-gov."},
+		{region: "eu-south-2", want: "eu."},
+		{region: "ap-south-1", want: "apac."},
+		{region: "ap-southeast-4", want: "apac."},
+		// Regions without cross-region inference profiles
+		{region: "ap-east-1", want: ""},
+		{region: "ap-southeast-5", want: ""},
+		{region: "ca-west-1", want: ""},
+		{region: "sa-east-1", want: ""},
+		{region: "me-central-1", want: ""},
+		{region: "cn-north-1", want: ""},
+		{region: "us-iso-east-1", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.region, func(t *testing.T) {
+			if got := inferenceProfilePrefixForRegion(tt.region); got != tt.want {
+				t.Errorf("expected prefix %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestBedrockDefaultModelForRegion(t *testing.T) {
+	t.Setenv("BEDROCK_MODEL", "")
+
+	tests := []struct {
+		region    string
+		model     string
+		wantModel string
+	}{
+		{region: "us-east-1", wantModel: "us." + defaultBedrockModel},
+		{region: "us-gov-west-1", wantModel: "us-gov." + defaultBedrockModel},
+		{region: "eu-west-1", wantModel: "eu." + defaultBedrockModel},
+		{region: "eu-central-1", wantModel: "eu." + defaultBedrockModel},
+		{region: "ap-southeast-2", wantModel: "apac." + defaultBedrockModel},
+		{region: "ca-central-1", wantModel: "us." + defaultBedrockModel},
+		// An explicit model ID is used as given.
+		{region: "eu-west-1", model: "us." + defaultBedrockModel, wantModel: "us." + defaultBedrockModel},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.region+"/"+tt.model, func(t *testing.T) {
+			client := &BedrockClient{region: tt.region}
+			chat := client.StartChat("", tt.model).(*bedrockChat)
+			if chat.model != tt.wantModel {
+				t.Errorf("expected model %q, got %q", tt.wantModel, chat.model)
+			}
+		})
+	}
+}
+
+func TestBedrockListModelsCache(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	cache := newModelListCache(defaultModelsCacheTTL)
+	cache.now = func() time.Time { return now }
+
+	calls := map[string]int{}
+	client := &BedrockClient{
+		region: "us-east-1",
+		models: cache,
+		fetchModels: func(ctx context.Context, region string) ([]string, error) {
+			calls[region]++
+			return builtinBedrockModels(ctx, region)
+		},
+	}
+
+	for range 3 {
+		if _, err := client.ListModels(ctx); err != nil {
+			t.Fatalf("ListModels failed: %v", err)
+		}
+	}
+	if calls["us-east-1"] != 1 {
+		t.Errorf("expected 1 fetch within the TTL, got %d", calls["us-east-1"])
+	}
+
+	// Each region is cached separately.
+	euClient := &BedrockClient{region: "eu-west-1", models: cache, fetchModels: client.fetchModels}
+	models, err := euClient.ListModels(ctx)
+	if err != nil {
+		t.Fatalf("ListModels failed: %v", err)
+	}
+	if calls["eu-west-1"] != 1 || !strings.HasPrefix(models[0], "eu.") {
+		t.Errorf("expected eu-west-1 models to be fetched, got %d fetches and %v", calls["eu-west-1"], models)
+	}
+
+	now = now.Add(defaultModelsCacheTTL)
+	if _, err := client.ListModels(ctx); err != nil {
+		t.Fatalf("ListModels failed: %v", err)
+	}
+	if calls["us-east-1"] != 2 {
+		t.Errorf("expected a fetch after the TTL expired, got %d fetches", calls["us-east-1"])
+	}
+
+	if _, err := client.RefreshModels(ctx); err != nil {
+		t.Fatalf("RefreshModels failed: %v", err)
+	}
+	if calls["us-east-1"] != 3 {
+		t.Errorf("expected RefreshModels to fetch again, got %d fetches", calls["us-east-1"])
+	}
+}
+
+func TestBedrockApplicationInferenceProfileARN(t *testing.T) {
+	const arn = "arn:aws:bedrock:eu-west-1:123456789012:application-inference-profile/a1b2c3d4e5f6"
+	t.Setenv("BEDROCK_MODEL", "")
+
+	tests := []struct {
+		name  string
+		model func() string
+	}{
+		{name: "model argument", model: func() string { return arn }},
+		{name: "BEDROCK_MODEL", model: func() string {
+			t.Setenv("BEDROCK_MODEL", arn)
+			return ""
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeBedrockAPI{
+				converseOutputs: []*bedrockruntime.ConverseOutput{
+					assistantOutput(&types.ContentBlockMemberText{Value: "Hello!"}),
+				},
+				streams: []*fakeEventStream{{events: []types.ConverseStreamOutput{textDeltaEvent("Hello!")}}},
+			}
+			client := &BedrockClient{
+				runtime: fake,
+				region:  "eu-west-1",
+				opts:    BedrockOptions{AutoInferenceProfile: true},
+			}
+			chat := client.StartChat("", tt.model())
+
+			if err := chat.Validate(); err != nil {
+				t.Fatalf("expected the ARN to be accepted, got %v", err)
+			}
+			if _, err := chat.Send(context.Background(), "hi"); err != nil {
+				t.Fatalf("Send failed: %v", err)
+			}
+			stream, err := chat.SendStreaming(context.Background(), "hi again")
+			if err != nil {
+				t.Fatalf("SendStreaming failed: %v", err)
+			}
+			for _, err := range stream {
+				if err != nil {
+					t.Fatalf("reading stream: %v", err)
+				}
+			}
+
+			if got := aws.ToString(fake.converseInputs[0].ModelId); got != arn {
+				t.Errorf("expected Converse model ID %q, got %q", arn, got)
+			}
+			if got := aws.ToString(fake.streamInputs[0].ModelId); got != arn {
+				t.Errorf("expected ConverseStream model ID %q, got %q", arn, got)
+			}
+		})
+	}
+}
+
+func TestDefaultBedrockSystemPromptEnhancer(t *testing.T) {
+	const shimPrompt = "Respond with:\n```json\n{\"thought\": \"...\", \"action\": {\"name\": \"kubectl\"}}\n```"
+	const plainPrompt = "You are a helpful Kubernetes assistant."
+
+	if got := DefaultBedrockSystemPromptEnhancer(plainPrompt); got != plainPrompt {
+		t.Errorf("expected plain prompt to be unchanged, got %q", got)
+	}
+	got := DefaultBedrockSystemPromptEnhancer(shimPrompt)
+	if !strings.HasPrefix(got, shimPrompt) || !strings.Contains(got, "CRITICAL JSON FORMATTING REQUIREMENTS") {
+		t.Errorf("expected shim prompt to be enhanced, got %q", got)
+	}
+}
+
+func TestBedrockSystemPromptEnhancer(t *testing.T) {
+	const shimPrompt = "Respond with:\n```json\n{\"thought\": \"...\", \"action\": {\"name\": \"kubectl\"}}\n```"
+
+	tests := []struct {
+		name     string
+		enhancer SystemPromptEnhancer
+		want     string
+	}{
+		{
+			name: "default",
+			want: DefaultBedrockSystemPromptEnhancer(shimPrompt),
+		},
+		{
+			name:     "disabled",
+			enhancer: func(original string) string { return original },
+			want:     shimPrompt,
+		},
+		{
+			name: "composed",
+			enhancer: func(original string) string {
+				return DefaultBedrockSystemPromptEnhancer(original) + "\nAlways answer in English."
+			},
+			want: DefaultBedrockSystemPromptEnhancer(shimPrompt) + "\nAlways answer in English.",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &BedrockClient{opts: BedrockOptions{SystemPromptEnhancer: tt.enhancer}}
+			chat := client.StartChat(shimPrompt, "us.anthropic.claude-sonnet-4-20250514-v1:0").(*bedrockChat)
+			if chat.systemPrompt != tt.want {
+				t.Errorf("expected system prompt %q, got %q", tt.want, chat.systemPrompt)
+			}
+		})
+	}
+}
+
+func TestDefaultBedrockOutputNormalizer(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{
+			name: "well-formed",
+			text: "```json\n{\"thought\": \"List the pods.\", \"action\": {\"name\": \"kubectl\", \"command\": \"kubectl get pods\"}}\n```",
+			want: "```json\n{\"action\":{\"command\":\"kubectl get pods\",\"name\":\"kubectl\"},\"thought\":\"List the pods.\"}\n```",
+		},
+		{
+			name: "prose around the block",
+			text: "Let me check.\n```json\n{\"thought\": \"List the pods.\", \"action\": {\"name\": \"kubectl\"}}\n```\nI will report back.",
+			want: "```json\n{\"action\":{\"name\":\"kubectl\"},\"thought\":\"Let me check.\\nI will report back.\\nList the pods.\"}\n```",
+		},
+		{
+			name: "other blocks before the action",
+			text: "Run:\n```bash\nkubectl get pods\n```\n```json\n{\"answer\": \"Done.\"}\n```",
+			want: "```json\n{\"answer\":\"Done.\",\"thought\":\"Run:\\n```bash\\nkubectl get pods\\n```\"}\n```",
+		},
+		{
+			name: "without fences",
+			text: "  {\"answer\": \"There are 3 pods.\"}\n",
+			want: "```json\n{\"answer\":\"There are 3 pods.\"}\n```",
+		},
+		{
+			name: "malformed JSON",
+			text: "```json\n{\"thought\": \"List the pods.\" \"action\": {\"name\": \"kubectl\"}}\n```",
+			want: "```json\n{\"thought\": \"List the pods.\" \"action\": {\"name\": \"kubectl\"}}\n```",
+		},
+		{
+			name: "unterminated block",
+			text: "```json\n{\"answer\": \"Done.\"}",
+			want: "```json\n{\"answer\": \"Done.\"}",
+		},
+		{
+			name: "plain text",
+			text: "There are 3 pods.",
+			want: "There are 3 pods.",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DefaultBedrockOutputNormalizer(tt.text); got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestBedrockOutputNormalizer(t *testing.T) {
+	const shimPrompt = "Respond with:\n```json\n{\"thought\": \"...\", \"action\": {\"name\": \"kubectl\"}}\n```"
+	const response = "Sure!\n```json\n{\"answer\": \"Done.\"}\n```"
+	const want = "```json\n{\"answer\":\"Done.\",\"thought\":\"Sure!\"}\n```"
+
+	tests := []struct {
+		name   string
+		prompt string
+		want   string
+	}{
+		{name: "shim prompt", prompt: shimPrompt, want: want},
+		{name: "other prompt", prompt: "You are a helpful Kubernetes assistant.", want: response},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeBedrockAPI{
+				converseOutputs: []*bedrockruntime.ConverseOutput{assistantOutput(&types.ContentBlockMemberText{Value: response})},
+				streams: []*fakeEventStream{{events: []types.ConverseStreamOutput{
+					textDeltaEvent("Sure!\n```json\n{\"answer\""), textDeltaEvent(": \"Done.\"}\n```"),
+				}}},
+			}
+			client := &BedrockClient{runtime: fake, opts: BedrockOptions{OutputNormalizer: DefaultBedrockOutputNormalizer}}
+			chat := client.StartChat(tt.prompt, "us.anthropic.claude-sonnet-4-20250514-v1:0")
+
+			sent, err := chat.Send(context.Background(), "hello")
+			if err != nil {
+				t.Fatalf("Send failed: %v", err)
+			}
+			if got := sent.Candidates()[0].String(); got != tt.want {
+				t.Errorf("expected Send text %q, got %q", tt.want, got)
+			}
+
+			stream, err := chat.SendStreaming(context.Background(), "hello again")
+			if err != nil {
+				t.Fatalf("SendStreaming failed: %v", err)
+			}
+			var streamed strings.Builder
+			for response, err := range stream {
+				if err != nil {
+					t.Fatalf("reading stream: %v", err)
+				}
+				for _, candidate := range response.Candidates() {
+					streamed.WriteString(candidate.String())
+				}
+			}
+			if streamed.String() != tt.want {
+				t.Errorf("expected streamed text %q, got %q", tt.want, streamed.String())
+			}
+
+			// The history keeps the text the model generated
+			if got := chat.(*bedrockChat).messages[1].Content[0].(*types.ContentBlockMemberText).Value; got != response {
+				t.Errorf("expected the original text in the history, got %q", got)
+			}
+		})
+	}
+}
+
+// toolUseStreamEvents returns the stream events of a tool call at index, with its input split into fragments.
+func toolUseStreamEvents(index int32, id, name string, inputFragments ...string) []types.ConverseStreamOutput {
+	events := []types.ConverseStreamOutput{
+		&types.ConverseStreamOutputMemberContentBlockStart{Value: types.ContentBlockStartEvent{
+			ContentBlockIndex: aws.Int32(index),
+			Start: &types.ContentBlockStartMemberToolUse{Value: types.ToolUseBlockStart{
+				ToolUseId: aws.String(id),
+				Name:      aws.String(name),
+			}},
+		}},
+	}
+	for _, fragment := range inputFragments {
+		events = append(events, &types.ConverseStreamOutputMemberContentBlockDelta{Value: types.ContentBlockDeltaEvent{
+			ContentBlockIndex: aws.Int32(index),
+			Delta:             &types.ContentBlockDeltaMemberToolUse{Value: types.ToolUseBlockDelta{Input: aws.String(fragment)}},
+		}})
+	}
+	return append(events, &types.ConverseStreamOutputMemberContentBlockStop{Value: types.ContentBlockStopEvent{
+		ContentBlockIndex: aws.Int32(index),
+	}})
+}
+
+func TestBedrockStreamingFragmentedToolInput(t *testing.T) {
+	// The input is split inside a key, an escaped string and a nested object
+	events := toolUseStreamEvents(0, "call-1", "kubectl",
+		`{"comm`, `and": "kubectl get pods -l app=\"ng`, `inx\"", "opts": {"wi`, `de": true, "limit": 1`, `0}}`)
+	fake := &fakeBedrockAPI{streams: []*fakeEventStream{{events: events}}}
+	chat := newFakeBedrockChat(fake)
+
+	stream, err := chat.SendStreaming(context.Background(), "list the nginx pods")
+	if err != nil {
+		t.Fatalf("SendStreaming failed: %v", err)
+	}
+	var calls []FunctionCall
+	for response, err := range stream {
+		if err != nil {
+			t.Fatalf("reading stream: %v", err)
+		}
+		for _, part := range response.Candidates()[0].Parts() {
+			if partCalls, ok := part.AsFunctionCalls(); ok {
+				calls = append(calls, partCalls...)
+			}
+		}
+	}
+
+	want := []FunctionCall{{
+		ID:   "call-1",
+		Name: "kubectl",
+		Arguments: map[string]any{
+			"command": `kubectl get pods -l app="nginx"`,
+			"opts":    map[string]any{"wide": true, "limit": float64(10)},
+		},
+	}}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("expected the tool call to be assembled from its fragments, got %+v", calls)
+	}
+}
+
+// assertToolResults checks that the last message sent to Bedrock holds tool results for the given IDs, in order.
+func assertToolResults(t *testing.T, messages []types.Message, wantIDs ...string) {
+	t.Helper()
+	last := messages[len(messages)-1]
+	if last.Role != types.ConversationRoleUser {
+		t.Fatalf("expected the results in a user message, got %s", last.Role)
+	}
+	var gotIDs []string
+	for _, block := range last.Content {
+		if result, ok := block.(*types.ContentBlockMemberToolResult); ok {
+			gotIDs = append(gotIDs, aws.ToString(result.Value.ToolUseId))
+		}
+	}
+	if !reflect.DeepEqual(gotIDs, wantIDs) {
+		t.Errorf("expected tool results for %v, got %v", wantIDs, gotIDs)
+	}
+}
+
+func TestBedrockParallelToolCalls(t *testing.T) {
+	ctx := context.Background()
+	fake := &fakeBedrockAPI{
+		converseOutputs: []*bedrockruntime.ConverseOutput{
+			assistantOutput(
+				&types.ContentBlockMemberText{Value: "I'll check both namespaces."},
+				&types.ContentBlockMemberToolUse{Value: types.ToolUseBlock{
+					ToolUseId: aws.String("call-1"),
+					Name:      aws.String("kubectl"),
+					Input:     document.NewLazyDocument(map[string]any{"command": "kubectl get pods -n default"}),
+				}},
+				&types.ContentBlockMemberToolUse{Value: types.ToolUseBlock{
+					ToolUseId: aws.String("call-2"),
+					Name:      aws.String("kubectl"),
+					Input:     document.NewLazyDocument(map[string]any{"command": "kubectl get pods -n kube-system"}),
+				}},
+			),
+			assistantOutput(&types.ContentBlockMemberText{Value: "Both namespaces are healthy."}),
+		},
+	}
+	chat := newFakeBedrockChat(fake)
+
+	response, err := chat.Send(ctx, "are my pods healthy?")
+	if err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	if !response.RequiresToolCall() {
+		t.Fatal("expected the response to require tool calls")
+	}
+	var calls []FunctionCall
+	for _, part := range response.Candidates()[0].Parts() {
+		if partCalls, ok := part.AsFunctionCalls(); ok {
+			calls = append(calls, partCalls...)
+		}
+	}
+	if len(calls) != 2 || calls[1].ID != "call-2" || calls[1].Arguments["command"] != "kubectl get pods -n kube-system" {
+		t.Fatalf("expected two tool calls with their arguments, got %+v", calls)
+	}
+
+	if _, err := chat.Send(ctx,
+		FunctionCallResult{ID: "call-1", Name: "kubectl", Result: map[string]any{"stdout": "nginx Running"}},
+		FunctionCallResult{ID: "call-2", Name: "kubectl", Result: map[string]any{"stdout": "coredns Running"}},
+	); err != nil {
+		t.Fatalf("Send with tool results failed: %v", err)
+	}
+	if len(fake.converseInputs[1].Messages) != 3 {
+		t.Fatalf("expected both results in a single message, got %d messages", len(fake.converseInputs[1].Messages))
+	}
+	assertToolResults(t, fake.converseInputs[1].Messages, "call-1", "call-2")
+}
+
+func TestBedrockParallelToolCallsStreaming(t *testing.T) {
+	ctx := context.Background()
+	events := []types.ConverseStreamOutput{textDeltaEvent("Checking both namespaces.")}
+	events = append(events, toolUseStreamEvents(1, "call-1", "kubectl", `{"command": "kubectl get`, ` pods -n default"}`)...)
+	events = append(events, toolUseStreamEvents(2, "call-2", "kubectl", `{"command": "kubectl get pods -n kube-system"}`)...)
+	fake := &fakeBedrockAPI{
+		streams: []*fakeEventStream{
+			{events: events},
+			{events: []types.ConverseStreamOutput{textDeltaEvent("Both namespaces are healthy.")}},
+		},
+	}
+	chat := newFakeBedrockChat(fake)
+
+	stream, err := chat.SendStreaming(ctx, "are my pods healthy?")
+	if err != nil {
+		t.Fatalf("SendStreaming failed: %v", err)
+	}
+	var calls []FunctionCall
+	for response, err := range stream {
+		if err != nil {
+			t.Fatalf("reading stream: %v", err)
+		}
+		for _, part := range response.Candidates()[0].Parts() {
+			if partCalls, ok := part.AsFunctionCalls(); ok {
+				calls = append(calls, partCalls...)
+			}
+		}
+	}
+	want := []FunctionCall{
+		{ID: "call-1", Name: "kubectl", Arguments: map[string]any{"command": "kubectl get pods -n default"}},
+		{ID: "call-2", Name: "kubectl", Arguments: map[string]any{"command": "kubectl get pods -n kube-system"}},
+	}
+	if !reflect.DeepEqual(calls, want) {
+		t.Fatalf("expected streamed tool calls %+v, got %+v", want, calls)
+	}
+
+	assistant := chat.messages[len(chat.messages)-1]
+	if assistant.Role != types.ConversationRoleAssistant || len(assistant.Content) != 3 {
+		t.Fatalf("expected the assistant turn to record text and both tool calls, got %+v", assistant)
+	}
+
+	// Results are placed before any text in the same message.
+	stream, err = chat.SendStreaming(ctx,
+		"Here are the results.",
+		[]FunctionCallResult{
+			{ID: "call-1", Name: "kubectl", Result: map[string]any{"stdout": "nginx Running"}},
+			{ID: "call-2", Name: "kubectl", Result: map[string]any{"stdout": "coredns Running"}},
+		},
+	)
+	if err != nil {
+		t.Fatalf("SendStreaming with tool results failed: %v", err)
+	}
+	for _, err := range stream {
+		if err != nil {
+			t.Fatalf("reading stream: %v", err)
+		}
+	}
+	messages := fake.streamInputs[1].Messages
+	assertToolResults(t, messages, "call-1", "call-2")
+	if _, ok := messages[len(messages)-1].Content[2].(*types.ContentBlockMemberText); !ok {
+		t.Errorf("expected the text to follow the tool results")
+	}
+}
+
+func TestBedrockToolResultMismatch(t *testing.T) {
+	toolCallTurn := []types.Message{
+		{Role: types.ConversationRoleUser, Content: []types.ContentBlock{&types.ContentBlockMemberText{Value: "are my pods healthy?"}}},
+		{Role: types.ConversationRoleAssistant, Content: []types.ContentBlock{
+			&types.ContentBlockMemberToolUse{Value: types.ToolUseBlock{ToolUseId: aws.String("call-1"), Name: aws.String("kubectl")}},
+			&types.ContentBlockMemberToolUse{Value: types.ToolUseBlock{ToolUseId: aws.String("call-2"), Name: aws.String("kubectl")}},
+		}},
+	}
+	result := func(id string) FunctionCallResult {
+		return FunctionCallResult{ID: id, Name: "kubectl", Result: map[string]any{"stdout": "ok"}}
+	}
+
+	tests := []struct {
+		name     string
+		history  []types.Message
+		contents []any
+		wantErr  error
+	}{
+		{
+			name:     "unknown ID",
+			history:  toolCallTurn,
+			contents: []any{result("call-1"), result("call-3")},
+			wantErr:  ErrUnmatchedToolResult,
+		},
+		{
+			name:     "no outstanding tool calls",
+			history:  toolCallTurn[:1],
+			contents: []any{result("call-1")},
+			wantErr:  ErrUnmatchedToolResult,
+		},
+		{
+			name:     "missing result",
+			history:  toolCallTurn,
+			contents: []any{result("call-1")},
+			wantErr:  ErrMissingToolResult,
+		},
+		{
+			name:     "text instead of results",
+			history:  toolCallTurn,
+			contents: []any{"never mind"},
+			wantErr:  ErrMissingToolResult,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeBedrockAPI{}
+			chat := newFakeBedrockChat(fake)
+			chat.messages = slices.Clone(tt.history)
+
+			if _, err := chat.Send(context.Background(), tt.contents...); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Send: expected %v, got %v", tt.wantErr, err)
+			}
+			if _, err := chat.SendStreaming(context.Background(), tt.contents...); !errors.Is(err, tt.wantErr) {
+				t.Errorf("SendStreaming: expected %v, got %v", tt.wantErr, err)
+			}
+			if len(fake.converseInputs) != 0 || len(fake.streamInputs) != 0 {
+				t.Error("expected no request to be sent")
+			}
+			if len(chat.messages) != len(tt.history) {
+				t.Errorf("expected history to be unchanged, got %d messages", len(chat.messages))
+			}
+		})
+	}
+}
+
+func TestBedrockToolResultsOutOfOrder(t *testing.T) {
+	history := []types.Message{
+		{Role: types.ConversationRoleUser, Content: []types.ContentBlock{&types.ContentBlockMemberText{Value: "are my pods healthy?"}}},
+		{Role: types.ConversationRoleAssistant, Content: []types.ContentBlock{
+			&types.ContentBlockMemberToolUse{Value: types.ToolUseBlock{ToolUseId: aws.String("call-1"), Name: aws.String("kubectl")}},
+			&types.ContentBlockMemberToolUse{Value: types.ToolUseBlock{ToolUseId: aws.String("call-2"), Name: aws.String("kubectl")}},
+		}},
+	}
+	// The second tool call finished first.
+	contents := []any{
+		FunctionCallResult{ID: "call-2", Name: "kubectl", Result: map[string]any{"stdout": "pod/web Running"}},
+		FunctionCallResult{ID: "call-1", Name: "kubectl", Result: map[string]any{"stdout": "pod/db Running"}},
+		"anything else?",
+	}
+
+	fake := &fakeBedrockAPI{
+		converseOutputs: []*bedrockruntime.ConverseOutput{assistantOutput(&types.ContentBlockMemberText{Value: "All healthy."})},
+		streams:         []*fakeEventStream{{events: []types.ConverseStreamOutput{textDeltaEvent("All healthy.")}}},
+	}
+
+	chat := newFakeBedrockChat(fake)
+	chat.messages = slices.Clone(history)
+	if _, err := chat.Send(context.Background(), contents...); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+
+	chat = newFakeBedrockChat(fake)
+	chat.messages = slices.Clone(history)
+	stream, err := chat.SendStreaming(context.Background(), contents...)
+	if err != nil {
+		t.Fatalf("SendStreaming failed: %v", err)
+	}
+	for _, err := range stream {
+		if err != nil {
+			t.Fatalf("stream failed: %v", err)
+		}
+	}
+
+	requests := [][]types.Message{fake.converseInputs[0].Messages, fake.streamInputs[0].Messages}
+	for i, messages := range requests {
+		assertToolResults(t, messages, "call-1", "call-2")
+		last := messages[len(messages)-1].Content
+		if _, ok := last[len(last)-1].(*types.ContentBlockMemberText); !ok {
+			t.Errorf("request %d: expected the text to follow the tool results, got %T", i, last[len(last)-1])
+		}
+	}
+}
+
+func TestBedrockCohereAndMistralModels(t *testing.T) {
+	const shimPrompt = "Respond with:\n```json\n{\"thought\": \"...\", \"action\": {\"name\": \"kubectl\"}}\n```"
+
+	tests := []struct {
+		model        string
+		wantEnhanced bool
+	}{
+		{model: "cohere.command-r-plus-v1:0"},
+		{model: "cohere.command-r-v1:0"},
+		{model: "mistral.mistral-large-2407-v1:0"},
+		{model: "mistral.mistral-small-2402-v1:0"},
+		{model: "us.anthropic.claude-sonnet-4-20250514-v1:0", wantEnhanced: true},
+		{model: "amazon.nova-pro-v1:0", wantEnhanced: true},
+		{model: "arn:aws:bedrock:us-east-1:123456789012:application-inference-profile/a1b2c3d4e5f6", wantEnhanced: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.model, func(t *testing.T) {
+			fake := &fakeBedrockAPI{
+				converseOutputs: []*bedrockruntime.ConverseOutput{
+					assistantOutput(&types.ContentBlockMemberText{Value: "Hello!"}),
+				},
+			}
+			client := &BedrockClient{runtime: fake}
+			chat := client.StartChat(shimPrompt, tt.model).(*bedrockChat)
+			if err := chat.SetFunctionDefinitions([]*FunctionDefinition{{
+				Name:       "kubectl",
+				Parameters: &Schema{Type: TypeObject, Properties: map[string]*Schema{"command": {Type: TypeString}}},
+			}}); err != nil {
+				t.Fatalf("SetFunctionDefinitions failed: %v", err)
+			}
+
+			if _, err := chat.Send(context.Background(), "hi"); err != nil {
+				t.Fatalf("Send failed: %v", err)
+			}
+			input := fake.converseInputs[0]
+			if got := aws.ToString(input.ModelId); got != tt.model {
+				t.Errorf("expected model ID %q, got %q", tt.model, got)
+			}
+			if input.ToolConfig == nil || len(input.ToolConfig.Tools) != 1 {
+				t.Errorf("expected the tool configuration to be sent")
+			}
+
+			system := input.System[0].(*types.SystemContentBlockMemberText).Value
+			if enhanced := system != shimPrompt; enhanced != tt.wantEnhanced {
+				t.Errorf("expected system prompt enhancement %v, got %q", tt.wantEnhanced, system)
+			}
+		})
+	}
+}
+
+func TestBedrockModelFallback(t *testing.T) {
+	const (
+		primary  = "us.anthropic.claude-sonnet-4-20250514-v1:0"
+		fallback = "us.anthropic.claude-3-5-haiku-20241022-v1:0"
+	)
+	throttled := &types.ThrottlingException{Message: aws.String("Too many requests")}
+	usage := &types.TokenUsage{InputTokens: aws.Int32(10), OutputTokens: aws.Int32(5), TotalTokens: aws.Int32(15)}
+
+	tests := []struct {
+		name      string
+		errs      []error
+		wantModel string
+		wantErr   bool
+	}{
+		{
+			name:      "primary succeeds",
+			wantModel: primary,
+		},
+		{
+			name:      "throttled",
+			errs:      []error{throttled},
+			wantModel: fallback,
+		},
+		{
+			name:      "model not ready",
+			errs:      []error{&types.ModelNotReadyException{Message: aws.String("Model is not ready")}},
+			wantModel: fallback,
+		},
+		{
+			name:    "not retryable",
+			errs:    []error{&types.ValidationException{Message: aws.String("Malformed input")}},
+			wantErr: true,
+		},
+		{
+			name:    "all models fail",
+			errs:    []error{throttled, throttled},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output := assistantOutput(&types.ContentBlockMemberText{Value: "hi"})
+			output.Usage = usage
+			fake := &fakeBedrockAPI{errs: tt.errs, converseOutputs: []*bedrockruntime.ConverseOutput{output}}
+			client := &BedrockClient{runtime: fake, opts: BedrockOptions{ModelFallback: []string{fallback}}}
+			chat := client.StartChat("", primary)
+
+			response, err := chat.Send(context.Background(), "hello")
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Send failed: %v", err)
+			}
+
+			if got := aws.ToString(fake.converseInputs[len(fake.converseInputs)-1].ModelId); got != tt.wantModel {
+				t.Errorf("expected the request to be served by %q, got %q", tt.wantModel, got)
+			}
+			if got := response.UsageMetadata().(*Usage).Model; got != tt.wantModel {
+				t.Errorf("expected usage to record model %q, got %q", tt.wantModel, got)
+			}
+			// The same turn is replayed, not duplicated.
+			if got := len(fake.converseInputs[len(fake.converseInputs)-1].Messages); got != 1 {
+				t.Errorf("expected 1 message to be sent, got %d", got)
+			}
+		})
+	}
+}
+
+func TestBedrockModelFallbackStreaming(t *testing.T) {
+	const fallback = "us.anthropic.claude-3-5-haiku-20241022-v1:0"
+	fake := &fakeBedrockAPI{
+		errs:    []error{&types.ServiceUnavailableException{Message: aws.String("Service unavailable")}},
+		streams: []*fakeEventStream{{events: []types.ConverseStreamOutput{textDeltaEvent("hi")}}},
+	}
+	client := &BedrockClient{runtime: fake, opts: BedrockOptions{ModelFallback: []string{fallback}}}
+	chat := client.StartChat("", "us.anthropic.claude-sonnet-4-20250514-v1:0")
+
+	stream, err := chat.SendStreaming(context.Background(), "hello")
+	if err != nil {
+		t.Fatalf("SendStreaming failed: %v", err)
+	}
+	for _, err := range stream {
+		if err != nil {
+			t.Fatalf("unexpected stream error: %v", err)
+		}
+	}
+	if got := aws.ToString(fake.streamInputs[1].ModelId); got != fallback {
+		t.Errorf("expected the stream to be served by %q, got %q", fallback, got)
+	}
+}
+
+func TestBedrockModelAccessErrors(t *testing.T) {
+	const model = "anthropic.claude-3-5-sonnet-20241022-v2:0"
+
+	tests := []struct {
+		name          string
+		err           error
+		want          error
+		wantHint      string
+		wantRetryable bool
+	}{
+		{
+			name:          "model not ready",
+			err:           &types.ModelNotReadyException{Message: aws.String("Model is not ready to serve inference requests. The model might be getting deployed.")},
+			want:          ErrModelNotReady,
+			wantHint:      "enable access to model",
+			wantRetryable: true,
+		},
+		{
+			name:     "on-demand throughput",
+			err:      &types.ValidationException{Message: aws.String("Invocation of model ID " + model + " with on-demand throughput isn't supported. Retry your request with the ID or ARN of an inference profile that contains this model.")},
+			want:     ErrThroughputRequired,
+			wantHint: `use the inference profile "eu.` + model + `"`,
+		},
+		{
+			name: "other validation error",
+			err:  &types.ValidationException{Message: aws.String("Malformed input request")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, streaming := range []bool{false, true} {
+				fake := &fakeBedrockAPI{err: tt.err}
+				client := &BedrockClient{runtime: fake, region: "eu-west-1"}
+				chat := client.StartChat("", model)
+
+				var err error
+				if streaming {
+					_, err = chat.SendStreaming(context.Background(), "hello")
+				} else {
+					_, err = chat.Send(context.Background(), "hello")
+				}
+				if err == nil {
+					t.Fatalf("streaming=%v: expected an error", streaming)
+				}
+				if !errors.Is(err, tt.err) {
+					t.Errorf("streaming=%v: expected the AWS error to be wrapped, got %v", streaming, err)
+				}
+				if tt.want != nil && !errors.Is(err, tt.want) {
+					t.Errorf("streaming=%v: expected %v, got %v", streaming, tt.want, err)
+				}
+				for _, sentinel := range []error{ErrModelNotReady, ErrThroughputRequired} {
+					if sentinel != tt.want && errors.Is(err, sentinel) {
+						t.Errorf("streaming=%v: did not expect %v, got %v", streaming, sentinel, err)
+					}
+				}
+				if !strings.Contains(err.Error(), tt.wantHint) {
+					t.Errorf("streaming=%v: expected the error to contain %q, got %v", streaming, tt.wantHint, err)
+				}
+				if got := chat.IsRetryableError(err); got != tt.wantRetryable {
+					t.Errorf("streaming=%v: expected IsRetryableError %v, got %v", streaming, tt.wantRetryable, got)
+				}
+			}
+		})
+	}
+}
+
+// recordingHandler is a slog.Handler that keeps the records it handles.
+type recordingHandler struct {
+	records []slog.Record
+
+	// root keeps the records of the handlers derived with WithAttrs, which add attrs to them
+	root  *recordingHandler
+	attrs []slog.Attr
+}
+
+func (h *recordingHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return true
+}
+
+func (h *recordingHandler) Handle(ctx context.Context, record slog.Record) error {
+	root := h
+	if h.root != nil {
+		root = h.root
+	}
+	record = record.Clone()
+	record.AddAttrs(h.attrs...)
+	root.records = append(root.records, record)
+	return nil
+}
+
+func (h *recordingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	root := h
+	if h.root != nil {
+		root = h.root
+	}
+	return &recordingHandler{root: root, attrs: append(slices.Clone(h.attrs), attrs...)}
+}
+
+func (h *recordingHandler) WithGroup(name string) slog.Handler {
+	return h
+}
+
+// find returns the attributes of the first record with the given message.
+func (h *recordingHandler) find(message string) (map[string]any, bool) {
+	for _, record := range h.records {
+		if record.Message != message {
+			continue
+		}
+		attrs := map[string]any{}
+		record.Attrs(func(attr slog.Attr) bool {
+			attrs[attr.Key] = attr.Value.Any()
+			return true
+		})
+		return attrs, true
+	}
+	return nil, false
+}
+
+func TestBedrockStructuredLogging(t *testing.T) {
+	const model = "us.anthropic.claude-sonnet-4-20250514-v1:0"
+	output := assistantOutput(&types.ContentBlockMemberToolUse{Value: types.ToolUseBlock{
+		ToolUseId: aws.String("call-1"),
+		Name:      aws.String("kubectl"),
+		Input:     document.NewLazyDocument(map[string]any{"command": "kubectl get pods"}),
+	}})
+	output.StopReason = types.StopReasonToolUse
+	output.Usage = &types.TokenUsage{InputTokens: aws.Int32(100), OutputTokens: aws.Int32(20), TotalTokens: aws.Int32(120)}
+
+	handler := &recordingHandler{}
+	client := &BedrockClient{
+		runtime: &fakeBedrockAPI{converseOutputs: []*bedrockruntime.ConverseOutput{output}},
+		logger:  slog.New(handler),
+	}
+	chat := client.StartChat("", model)
+	if _, err := chat.Send(context.Background(), "list pods"); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+
+	attrs, ok := handler.find("Bedrock response")
+	if !ok {
+		t.Fatalf("expected a response event, got %d records", len(handler.records))
+	}
+	want := map[string]any{
+		"model":        model,
+		"stopReason":   "tool_use",
+		"tools":        []string{"kubectl"},
+		"inputTokens":  int64(100),
+		"outputTokens": int64(20),
+	}
+	if !reflect.DeepEqual(attrs, want) {
+		t.Errorf("expected response event %v, got %v", want, attrs)
+	}
+}
+
+func TestBedrockLogsRequestID(t *testing.T) {
+	handler := &recordingHandler{}
+	client := &BedrockClient{
+		runtime: &fakeBedrockAPI{converseOutputs: []*bedrockruntime.ConverseOutput{
+			assistantOutput(&types.ContentBlockMemberText{Value: "hi"}),
+		}},
+		logger: slog.New(handler),
+	}
+	chat := client.StartChat("", "us.anthropic.claude-sonnet-4-20250514-v1:0")
+
+	ctx := WithRequestID(context.Background(), "trace-123")
+	if _, err := chat.Send(ctx, "hello"); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+
+	attrs, ok := handler.find("Bedrock response")
+	if !ok {
+		t.Fatalf("expected a response event, got %d records", len(handler.records))
+	}
+	if attrs["requestID"] != "trace-123" {
+		t.Errorf("expected requestID %q, got %v", "trace-123", attrs["requestID"])
+	}
+}
+
+func TestBedrockRequestMetadata(t *testing.T) {
+	fake := &fakeBedrockAPI{converseOutputs: []*bedrockruntime.ConverseOutput{
+		assistantOutput(&types.ContentBlockMemberText{Value: "hi"}),
+		assistantOutput(&types.ContentBlockMemberText{Value: "hi"}),
+	}}
+	chat := newFakeBedrockChat(fake)
+
+	ctx := WithRequestID(WithIdempotencyKey(context.Background(), "key-1"), "trace-123")
+	if _, err := chat.Send(ctx, "hello"); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	want := map[string]string{"idempotencyKey": "key-1", "requestID": "trace-123"}
+	if got := fake.converseInputs[0].RequestMetadata; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected request metadata %v, got %v", want, got)
+	}
+
+	// Without a key in the context, every request gets its own
+	if _, err := chat.Send(context.Background(), "hello"); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	if key := fake.converseInputs[1].RequestMetadata["idempotencyKey"]; key == "" || key == "key-1" {
+		t.Errorf("expected a new idempotency key, got %q", key)
+	}
+}
+
+func TestBedrockDryRun(t *testing.T) {
+	const model = "us.anthropic.claude-sonnet-4-20250514-v1:0"
+	fake := &fakeBedrockAPI{err: errors.New("unexpected call to Bedrock")}
+	client := &BedrockClient{runtime: fake, opts: BedrockOptions{DryRun: true}}
+	chat := client.StartChat("You are a Kubernetes assistant.", model)
+	if err := chat.SetFunctionDefinitions([]*FunctionDefinition{{
+		Name:        "kubectl",
+		Description: "Runs kubectl",
+		Parameters:  &Schema{Type: TypeObject, Properties: map[string]*Schema{"command": {Type: TypeString}}},
+	}}); err != nil {
+		t.Fatalf("SetFunctionDefinitions failed: %v", err)
+	}
+
+	want := `{
+		"modelId": "us.anthropic.claude-sonnet-4-20250514-v1:0",
+		"system": "You are a Kubernetes assistant.",
+		"messages": [{"role": "user", "parts": [{"text": "list pods"}]}],
+		"tools": [{"name": "kubectl", "description": "Runs kubectl", "parameters": {"type": "object", "properties": {"command": {"type": "string"}}}}],
+		"maxTokens": 64000,
+		"stream": %v
+	}`
+
+	tests := []struct {
+		name   string
+		stream bool
+		send   func() (ChatResponse, error)
+	}{
+		{
+			name: "Send",
+			send: func() (ChatResponse, error) { return chat.Send(context.Background(), "list pods") },
+		},
+		{
+			name:   "SendStreaming",
+			stream: true,
+			send: func() (ChatResponse, error) {
+				stream, err := chat.SendStreaming(context.Background(), "list pods")
+				if err != nil {
+					return nil, err
+				}
+				var last ChatResponse
+				for response, err := range stream {
+					if err != nil {
+						return nil, err
+					}
+					last = response
+				}
+				return last, nil
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response, err := tt.send()
+			if err != nil {
+				t.Fatalf("dry run failed: %v", err)
+			}
+			request, ok := response.UsageMetadata().(*DryRunRequest)
+			if !ok {
+				t.Fatalf("expected *DryRunRequest, got %T", response.UsageMetadata())
+			}
+			if request.Provider != "bedrock" || request.Model != model {
+				t.Errorf("unexpected provider/model: %q/%q", request.Provider, request.Model)
+			}
+			if request.EstimatedInputTokens != (len(request.Request)+3)/4 {
+				t.Errorf("expected about a token per 4 bytes of request, got %d for %d bytes", request.EstimatedInputTokens, len(request.Request))
+			}
+
+			var got, wantRequest any
+			if err := json.Unmarshal(request.Request, &got); err != nil {
+				t.Fatalf("parsing request: %v", err)
+			}
+			if err := json.Unmarshal([]byte(fmt.Sprintf(want, tt.stream)), &wantRequest); err != nil {
+				t.Fatalf("parsing expected request: %v", err)
+			}
+			if !reflect.DeepEqual(got, wantRequest) {
+				t.Errorf("expected request %v, got %v", wantRequest, got)
+			}
+		})
+	}
+
+	if len(fake.converseInputs) != 0 || len(fake.streamInputs) != 0 {
+		t.Errorf("expected no calls to Bedrock, got %d Converse and %d ConverseStream", len(fake.converseInputs), len(fake.streamInputs))
+	}
+	if len(chat.(*bedrockChat).messages) != 0 {
+		t.Errorf("expected history to be unchanged, got %d messages", len(chat.(*bedrockChat).messages))
+	}
+}
+
+// runeTokenizer is a precise Tokenizer for tests, counting a token per rune.
+type runeTokenizer struct{}
+
+func (runeTokenizer) Count(text string) int {
+	return utf8.RuneCountInString(text)
+}
+
+func TestBedrockDryRunTokenizer(t *testing.T) {
+	const prompt = "列出 kube-system 命名空间中的所有 Pod"
+	tests := []struct {
+		name      string
+		tokenizer Tokenizer
+		count     func(request string) int
+	}{
+		{name: "heuristic", count: func(request string) int { return (len(request) + 3) / 4 }},
+		{name: "custom", tokenizer: runeTokenizer{}, count: utf8.RuneCountInString},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var opts ClientOptions
+			if tt.tokenizer != nil {
+				WithTokenizer(tt.tokenizer)(&opts)
+			}
+			client := &BedrockClient{runtime: &fakeBedrockAPI{}, opts: BedrockOptions{DryRun: true}, tokenizer: opts.Tokenizer}
+			chat := client.StartChat("", "us.anthropic.claude-sonnet-4-20250514-v1:0")
+			response, err := chat.Send(context.Background(), prompt)
+			if err != nil {
+				t.Fatalf("dry run failed: %v", err)
+			}
+			request := response.UsageMetadata().(*DryRunRequest)
+			if want := tt.count(string(request.Request)); request.EstimatedInputTokens != want {
+				t.Errorf("expected %d estimated tokens, got %d", want, request.EstimatedInputTokens)
+			}
+		})
+	}
+}
+
+func TestBedrockAdditionalModelRequestFields(t *testing.T) {
+	ctx := context.Background()
+	fake := &fakeBedrockAPI{
+		converseOutputs: []*bedrockruntime.ConverseOutput{assistantOutput(&types.ContentBlockMemberText{Value: "hi"})},
+		streams:         []*fakeEventStream{{events: []types.ConverseStreamOutput{textDeltaEvent("hi again")}}},
+	}
+	client := &BedrockClient{runtime: fake, opts: BedrockOptions{
+		AdditionalModelRequestFields: map[string]any{"top_k": 50},
+	}}
+	chat := client.StartChat("", "us.anthropic.claude-sonnet-4-20250514-v1:0")
+
+	if _, err := chat.Send(ctx, "hello"); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	stream, err := chat.SendStreaming(ctx, "hello again")
+	if err != nil {
+		t.Fatalf("SendStreaming failed: %v", err)
+	}
+	for _, err := range stream {
+		if err != nil {
+			t.Fatalf("unexpected stream error: %v", err)
+		}
+	}
+
+	want := map[string]any{"top_k": float64(50)}
+	for name, fields := range map[string]document.Interface{
+		"Converse":       fake.converseInputs[0].AdditionalModelRequestFields,
+		"ConverseStream": fake.streamInputs[0].AdditionalModelRequestFields,
+	} {
+		if fields == nil {
+			t.Errorf("%s: expected additional model request fields to be attached", name)
+			continue
+		}
+		var got map[string]any
+		if err := unmarshalDocument(fields, &got); err != nil {
+			t.Fatalf("%s: decoding additional model request fields: %v", name, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: expected additional model request fields %v, got %v", name, want, got)
+		}
+	}
+}
+
+func TestBedrockTopK(t *testing.T) {
+	tests := []struct {
+		name       string
+		model      string
+		opts       BedrockOptions
+		wantFields map[string]any
+	}{
+		{
+			name:       "claude",
+			model:      "us.anthropic.claude-sonnet-4-20250514-v1:0",
+			opts:       BedrockOptions{TopK: 1},
+			wantFields: map[string]any{"top_k": float64(1)},
+		},
+		{
+			name:  "nova",
+			model: "us.amazon.nova-pro-v1:0",
+			opts:  BedrockOptions{TopK: 1},
+		},
+		{
+			name:  "unset",
+			model: "us.anthropic.claude-sonnet-4-20250514-v1:0",
+		},
+		{
+			name:       "explicit field wins",
+			model:      "us.anthropic.claude-sonnet-4-20250514-v1:0",
+			opts:       BedrockOptions{TopK: 1, AdditionalModelRequestFields: map[string]any{"top_k": 5, "top_p": 0.9}},
+			wantFields: map[string]any{"top_k": float64(5), "top_p": 0.9},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeBedrockAPI{converseOutputs: []*bedrockruntime.ConverseOutput{
+				assistantOutput(&types.ContentBlockMemberText{Value: "hi"}),
+			}}
+			client := &BedrockClient{runtime: fake, opts: tt.opts}
+			chat := client.StartChat("", tt.model)
+			if _, err := chat.Send(context.Background(), "hello"); err != nil {
+				t.Fatalf("Send failed: %v", err)
+			}
+
+			fields := fake.converseInputs[0].AdditionalModelRequestFields
+			if tt.wantFields == nil {
+				if fields != nil {
+					t.Errorf("expected no additional model request fields, got %v", fields)
+				}
+				return
+			}
+			var got map[string]any
+			if err := unmarshalDocument(fields, &got); err != nil {
+				t.Fatalf("decoding additional model request fields: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.wantFields) {
+				t.Errorf("expected additional model request fields %v, got %v", tt.wantFields, got)
+			}
+		})
+	}
+}
+
+func TestBedrockInferenceParameters(t *testing.T) {
+	const claude = "us.anthropic.claude-sonnet-4-20250514-v1:0"
+	tests := []struct {
+		name    string
+		model   string
+		opts    []Option
+		wantErr bool
+	}{
+		{name: "valid", model: claude, opts: []Option{WithBedrockTemperature(0.2), WithBedrockTopP(0.9)}},
+		{name: "bounds", model: claude, opts: []Option{WithBedrockTemperature(1), WithBedrockTopP(0)}},
+		{name: "temperature too high", model: claude, opts: []Option{WithBedrockTemperature(1.5)}, wantErr: true},
+		{name: "negative temperature", model: claude, opts: []Option{WithBedrockTemperature(-0.1)}, wantErr: true},
+		{name: "top P too high", model: claude, opts: []Option{WithBedrockTopP(1.1)}, wantErr: true},
+		{name: "cohere top P of 1", model: "cohere.command-r-v1:0", opts: []Option{WithBedrockTopP(1), WithBedrockStrictModel()}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var opts ClientOptions
+			for _, opt := range tt.opts {
+				opt(&opts)
+			}
+			fake := &fakeBedrockAPI{converseOutputs: []*bedrockruntime.ConverseOutput{
+				assistantOutput(&types.ContentBlockMemberText{Value: "hi"}),
+			}}
+			client := &BedrockClient{runtime: fake, opts: opts.Bedrock}
+			chat := client.StartChat("", tt.model)
+
+			_, err := chat.Send(context.Background(), "hello")
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidInferenceParameter) {
+					t.Fatalf("expected ErrInvalidInferenceParameter, got %v", err)
+				}
+				if len(fake.converseInputs) != 0 {
+					t.Errorf("expected no request to be sent, got %d", len(fake.converseInputs))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Send failed: %v", err)
+			}
+			config := fake.converseInputs[0].InferenceConfig
+			if !reflect.DeepEqual(config.Temperature, opts.Bedrock.Temperature) || !reflect.DeepEqual(config.TopP, opts.Bedrock.TopP) {
+				t.Errorf("expected temperature %v and top P %v to be sent, got %v and %v",
+					aws.ToFloat32(opts.Bedrock.Temperature), aws.ToFloat32(opts.Bedrock.TopP), aws.ToFloat32(config.Temperature), aws.ToFloat32(config.TopP))
+			}
+		})
+	}
+}
+
+func TestBedrockStreamStats(t *testing.T) {
+	stream := &fakeEventStream{events: []types.ConverseStreamOutput{
+		textDeltaEvent("Hello, "),
+		textDeltaEvent("world"),
+		&types.ConverseStreamOutputMemberMessageStop{Value: types.MessageStopEvent{StopReason: types.StopReasonEndTurn}},
+		&types.ConverseStreamOutputMemberMetadata{Value: types.ConverseStreamMetadataEvent{}},
+	}}
+	// Every reading of the clock is 100ms after the previous one
+	now := time.Now()
+	client := &BedrockClient{
+		runtime: &fakeBedrockAPI{streams: []*fakeEventStream{stream}},
+		now: func() time.Time {
+			now = now.Add(100 * time.Millisecond)
+			return now
+		},
+	}
+	chat := client.StartChat("", "us.anthropic.claude-sonnet-4-20250514-v1:0")
+
+	iterator, err := chat.SendStreaming(context.Background(), "hello")
+	if err != nil {
+		t.Fatalf("SendStreaming failed: %v", err)
+	}
+	var stats []*StreamStats
+	for response, err := range iterator {
+		if err != nil {
+			t.Fatalf("unexpected stream error: %v", err)
+		}
+		if s := response.(StreamStatsReporter).StreamStats(); s != nil {
+			stats = append(stats, s)
+		}
+	}
+
+	if len(stats) != 1 {
+		t.Fatalf("expected stats on the final response only, got %d", len(stats))
+	}
+	want := StreamStats{TimeToFirstToken: 100 * time.Millisecond, Duration: 200 * time.Millisecond, Chunks: 2}
+	if *stats[0] != want {
+		t.Errorf("expected stats %+v, got %+v", want, *stats[0])
+	}
+}
+
+func TestBedrockResponseMetrics(t *testing.T) {
+	output := assistantOutput(&types.ContentBlockMemberText{Value: "Hello"})
+	output.Metrics = &types.ConverseMetrics{LatencyMs: aws.Int64(850)}
+	stream := &fakeEventStream{events: []types.ConverseStreamOutput{
+		textDeltaEvent("Hello"),
+		&types.ConverseStreamOutputMemberMessageStop{Value: types.MessageStopEvent{StopReason: types.StopReasonEndTurn}},
+		&types.ConverseStreamOutputMemberMetadata{Value: types.ConverseStreamMetadataEvent{
+			Metrics: &types.ConverseStreamMetrics{LatencyMs: aws.Int64(150)},
+		}},
+	}}
+	// Every reading of the clock is one second after the previous one
+	now := time.Now()
+	client := &BedrockClient{
+		runtime: &fakeBedrockAPI{
+			converseOutputs: []*bedrockruntime.ConverseOutput{output},
+			streams:         []*fakeEventStream{stream},
+		},
+		now: func() time.Time {
+			now = now.Add(time.Second)
+			return now
+		},
+	}
+	chat := client.StartChat("", "us.anthropic.claude-sonnet-4-20250514-v1:0")
+
+	response, err := chat.Send(context.Background(), "hello")
+	if err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	want := ResponseMetrics{ServerLatency: 850 * time.Millisecond, ClientLatency: time.Second}
+	if got := response.(ResponseMetricsReporter).ResponseMetrics(); got == nil || *got != want {
+		t.Errorf("expected metrics %+v, got %+v", want, got)
+	}
+
+	iterator, err := chat.SendStreaming(context.Background(), "hello again")
+	if err != nil {
+		t.Fatalf("SendStreaming failed: %v", err)
+	}
+	var metrics []*ResponseMetrics
+	for response, err := range iterator {
+		if err != nil {
+			t.Fatalf("unexpected stream error: %v", err)
+		}
+		if m := response.(ResponseMetricsReporter).ResponseMetrics(); m != nil {
+			metrics = append(metrics, m)
+		}
+	}
+	want = ResponseMetrics{ServerLatency: 150 * time.Millisecond, ClientLatency: 2 * time.Second}
+	if len(metrics) != 1 || *metrics[0] != want {
+		t.Errorf("expected metrics %+v on the final response only, got %v", want, metrics)
+	}
+}
+
+func TestBedrockMaxTokens(t *testing.T) {
+	tests := []struct {
+		name  string
+		model string
+		opts  BedrockOptions
+		want  int32
+	}{
+		{name: "Claude Sonnet 4", model: "us.anthropic.claude-sonnet-4-20250514-v1:0", want: 64000},
+		{name: "Claude 3.5 Haiku", model: "anthropic.claude-3-5-haiku-20241022-v1:0", want: 8192},
+		{name: "Nova Pro", model: "us.amazon.nova-pro-v1:0", want: 10000},
+		{name: "Nova Premier", model: "us.amazon.nova-premier-v1:0", want: 32000},
+		{name: "unknown model", model: "cohere.command-r-v1:0", want: 4096},
+		{name: "explicit", model: "us.anthropic.claude-sonnet-4-20250514-v1:0", opts: BedrockOptions{MaxTokens: 1000}, want: 1000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeBedrockAPI{converseOutputs: []*bedrockruntime.ConverseOutput{
+				assistantOutput(&types.ContentBlockMemberText{Value: "hi"}),
+			}}
+			client := &BedrockClient{runtime: fake, opts: tt.opts}
+			chat := client.StartChat("", tt.model)
+			if _, err := chat.Send(context.Background(), "hello"); err != nil {
+				t.Fatalf("Send failed: %v", err)
+			}
+			if got := aws.ToInt32(fake.converseInputs[0].InferenceConfig.MaxTokens); got != tt.want {
+				t.Errorf("expected max tokens %d, got %d", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestNewBedrockClientCandidateCount(t *testing.T) {
+	_, err := NewBedrockClient(context.Background(), ClientOptions{CandidateCount: 2})
+	if err == nil || !strings.Contains(err.Error(), "single candidate") {
+		t.Errorf("expected an error about multiple candidates, got %v", err)
+	}
+}
+
+func TestBedrockFailedTurnRestoresHistory(t *testing.T) {
+	history := func() []types.Message {
+		var messages []types.Message
+		for i, role := range []types.ConversationRole{types.ConversationRoleUser, types.ConversationRoleAssistant, types.ConversationRoleUser, types.ConversationRoleAssistant} {
+			messages = append(messages, types.Message{Role: role, Content: []types.ContentBlock{&types.ContentBlockMemberText{Value: fmt.Sprintf("m%d", i)}}})
+		}
+		return messages
+	}
+	invalid := &smithy.GenericAPIError{Code: "ValidationException", Message: "malformed input"}
+
+	tests := []struct {
+		name string
+		fake *fakeBedrockAPI
+		send func(chat *bedrockChat) error
+	}{
+		{
+			name: "Send fails",
+			fake: &fakeBedrockAPI{errs: []error{invalid}},
+			send: func(chat *bedrockChat) error {
+				_, err := chat.Send(context.Background(), "hello")
+				return err
+			},
+		},
+		{
+			name: "stream fails to open",
+			fake: &fakeBedrockAPI{errs: []error{invalid}},
+			send: func(chat *bedrockChat) error {
+				_, err := chat.SendStreaming(context.Background(), "hello")
+				return err
+			},
+		},
+		{
+			name: "stream fails immediately",
+			fake: &fakeBedrockAPI{streams: []*fakeEventStream{{err: errors.New("connection reset")}}},
+			send: func(chat *bedrockChat) error {
+				stream, err := chat.SendStreaming(context.Background(), "hello")
+				if err != nil {
+					t.Fatalf("SendStreaming failed: %v", err)
+				}
+				for _, err := range stream {
+					if err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chat := newFakeBedrockChat(tt.fake)
+			// The turn trims the history to make room for its message.
+			chat.client.opts.MaxHistoryMessages = 2
+			chat.messages = history()
+
+			if err := tt.send(chat); err == nil {
+				t.Fatal("expected the turn to fail")
+			}
+			if !reflect.DeepEqual(chat.messages, history()) {
+				t.Errorf("expected the history to be restored, got %d messages", len(chat.messages))
+			}
+		})
+	}
+}
+
+func TestBedrockHistoryLimit(t *testing.T) {
+	text := func(role types.ConversationRole, value string) types.Message {
+		return types.Message{Role: role, Content: []types.ContentBlock{&types.ContentBlockMemberText{Value: value}}}
+	}
+	toolUse := func(id string) types.Message {
+		return types.Message{Role: types.ConversationRoleAssistant, Content: []types.ContentBlock{&types.ContentBlockMemberToolUse{Value: types.ToolUseBlock{
+			ToolUseId: aws.String(id),
+			Name:      aws.String("kubectl"),
+			Input:     document.NewLazyDocument(map[string]any{}),
+		}}}}
+	}
+	toolResult := func(id string) types.Message {
+		return types.Message{Role: types.ConversationRoleUser, Content: []types.ContentBlock{&types.ContentBlockMemberToolResult{Value: types.ToolResultBlock{
+			ToolUseId: aws.String(id),
+		}}}}
+	}
+	// describe summarizes a message as its role and its text or tool use ID.
+	describe := func(msg types.Message) string {
+		switch block := msg.Content[0].(type) {
+		case *types.ContentBlockMemberText:
+			return string(msg.Role) + ":" + block.Value
+		case *types.ContentBlockMemberToolUse:
+			return string(msg.Role) + ":use " + aws.ToString(block.Value.ToolUseId)
+		case *types.ContentBlockMemberToolResult:
+			return string(msg.Role) + ":result " + aws.ToString(block.Value.ToolUseId)
+		}
+		return string(msg.Role)
+	}
+	user := types.ConversationRoleUser
+	assistant := types.ConversationRoleAssistant
+
+	tests := []struct {
+		name    string
+		limit   int
+		history []types.Message
+		send    any
+		want    []string
+	}{
+		{
+			name:    "under the limit",
+			limit:   4,
+			history: []types.Message{text(user, "u1"), text(assistant, "a1")},
+			send:    "u2",
+			want:    []string{"user:u1", "assistant:a1", "user:u2"},
+		},
+		{
+			name:    "oldest dropped",
+			limit:   4,
+			history: []types.Message{text(user, "u1"), text(assistant, "a1"), text(user, "u2"), text(assistant, "a2")},
+			send:    "u3",
+			want:    []string{"user:u2", "assistant:a2", "user:u3"},
+		},
+		{
+			name:    "tool results kept with their calls",
+			limit:   4,
+			history: []types.Message{text(user, "u1"), toolUse("t1"), toolResult("t1"), text(assistant, "a1")},
+			send:    "u2",
+			want:    []string{"user:u2"},
+		},
+		{
+			name:    "tool exchange longer than the limit",
+			limit:   3,
+			history: []types.Message{text(user, "u0"), text(assistant, "a0"), text(user, "u1"), toolUse("t1"), toolResult("t1"), toolUse("t2")},
+			send:    FunctionCallResult{ID: "t2", Name: "kubectl", Result: map[string]any{}},
+			want:    []string{"user:u1", "assistant:use t1", "user:result t1", "assistant:use t2", "user:result t2"},
+		},
+		{
+			name:    "no limit",
+			history: []types.Message{text(user, "u1"), text(assistant, "a1"), text(user, "u2"), text(assistant, "a2")},
+			send:    "u3",
+			want:    []string{"user:u1", "assistant:a1", "user:u2", "assistant:a2", "user:u3"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeBedrockAPI{converseOutputs: []*bedrockruntime.ConverseOutput{assistantOutput(&types.ContentBlockMemberText{Value: "done"})}}
+			client := &BedrockClient{runtime: fake, opts: BedrockOptions{MaxHistoryMessages: tt.limit}}
+			chat := client.StartChat("You are a Kubernetes assistant.", "us.anthropic.claude-sonnet-4-20250514-v1:0").(*bedrockChat)
+			chat.messages = tt.history
+
+			if _, err := chat.Send(context.Background(), tt.send); err != nil {
+				t.Fatalf("Send failed: %v", err)
+			}
+
+			input := fake.converseInputs[0]
+			var got []string
+			for _, msg := range input.Messages {
+				got = append(got, describe(msg))
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("expected messages %q, got %q", tt.want, got)
+			}
+			if len(input.System) == 0 {
+				t.Error("expected the system prompt to be kept")
+			}
+		})
+	}
+}
+
+func TestBedrockStartChatWithSystemBlocks(t *testing.T) {
+	tests := []struct {
+		name  string
+		start func(client *BedrockClient) Chat
+		want  []string
+	}{
+		{
+			name: "single prompt",
+			start: func(client *BedrockClient) Chat {
+				return client.StartChat("You are a Kubernetes assistant.", "us.anthropic.claude-sonnet-4-20250514-v1:0")
+			},
+			want: []string{"You are a Kubernetes assistant."},
+		},
+		{
+			name: "several blocks",
+			start: func(client *BedrockClient) Chat {
+				return client.StartChatWithSystemBlocks([]string{
+					"You are a Kubernetes assistant.",
+					"",
+					"The current context is prod-cluster.",
+				}, "us.anthropic.claude-sonnet-4-20250514-v1:0")
+			},
+			want: []string{"You are a Kubernetes assistant.", "The current context is prod-cluster."},
+		},
+		{
+			name: "no blocks",
+			start: func(client *BedrockClient) Chat {
+				return client.StartChatWithSystemBlocks(nil, "us.anthropic.claude-sonnet-4-20250514-v1:0")
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeBedrockAPI{
+				converseOutputs: []*bedrockruntime.ConverseOutput{assistantOutput(&types.ContentBlockMemberText{Value: "Hello!"})},
+				streams:         []*fakeEventStream{{events: []types.ConverseStreamOutput{textDeltaEvent("Hello!")}}},
+			}
+			chat := tt.start(&BedrockClient{runtime: fake})
+
+			if _, err := chat.Send(context.Background(), "hi"); err != nil {
+				t.Fatalf("Send failed: %v", err)
+			}
+			stream, err := chat.SendStreaming(context.Background(), "hi again")
+			if err != nil {
+				t.Fatalf("SendStreaming failed: %v", err)
+			}
+			for _, err := range stream {
+				if err != nil {
+					t.Fatalf("stream failed: %v", err)
+				}
+			}
+
+			for name, system := range map[string][]types.SystemContentBlock{
+				"Converse":       fake.converseInputs[0].System,
+				"ConverseStream": fake.streamInputs[0].System,
+			} {
+				var got []string
+				for _, block := range system {
+					got = append(got, block.(*types.SystemContentBlockMemberText).Value)
+				}
+				if !slices.Equal(got, tt.want) {
+					t.Errorf("%s: expected system blocks %q, got %q", name, tt.want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestBedrockAutoContinue(t *testing.T) {
+	truncated := func(text string, content ...types.ContentBlock) *bedrockruntime.ConverseOutput {
+		output := assistantOutput(append([]types.ContentBlock{&types.ContentBlockMemberText{Value: text}}, content...)...)
+		output.StopReason = types.StopReasonMaxTokens
+		output.Usage = &types.TokenUsage{InputTokens: aws.Int32(10), OutputTokens: aws.Int32(5), TotalTokens: aws.Int32(15)}
+		return output
+	}
+	finished := func(text string) *bedrockruntime.ConverseOutput {
+		output := assistantOutput(&types.ContentBlockMemberText{Value: text})
+		output.StopReason = types.StopReasonEndTurn
+		output.Usage = &types.TokenUsage{InputTokens: aws.Int32(15), OutputTokens: aws.Int32(2), TotalTokens: aws.Int32(17)}
+		return output
+	}
+	toolUse := &types.ContentBlockMemberToolUse{Value: types.ToolUseBlock{
+		ToolUseId: aws.String("call-1"),
+		Name:      aws.String("kubectl"),
+		Input:     document.NewLazyDocument(map[string]any{}),
+	}}
+
+	tests := []struct {
+		name         string
+		autoContinue bool
+		outputs      []*bedrockruntime.ConverseOutput
+		errs         []error
+		want         string
+		wantReason   FinishReason
+		wantPrefills []string
+		wantTokens   int
+	}{
+		{
+			name:       "disabled",
+			outputs:    []*bedrockruntime.ConverseOutput{truncated(`{"action": "kub`)},
+			want:       `{"action": "kub`,
+			wantReason: FinishReasonMaxTokens,
+			wantTokens: 15,
+		},
+		{
+			name:         "continued",
+			autoContinue: true,
+			outputs:      []*bedrockruntime.ConverseOutput{truncated(`{"action": "kub`), finished(`ectl"}`)},
+			want:         `{"action": "kubectl"}`,
+			wantReason:   FinishReasonStop,
+			wantPrefills: []string{`{"action": "kub`},
+			wantTokens:   32,
+		},
+		{
+			name:         "trailing whitespace",
+			autoContinue: true,
+			outputs:      []*bedrockruntime.ConverseOutput{truncated("Listing the pods \n"), finished(" now.")},
+			want:         "Listing the pods now.",
+			wantReason:   FinishReasonStop,
+			wantPrefills: []string{"Listing the pods"},
+			wantTokens:   32,
+		},
+		{
+			name:         "continued more than once",
+			autoContinue: true,
+			outputs:      []*bedrockruntime.ConverseOutput{truncated("one"), truncated(" two"), finished(" three")},
+			want:         "one two three",
+			wantReason:   FinishReasonStop,
+			wantPrefills: []string{"one", "one two"},
+			wantTokens:   47,
+		},
+		{
+			name:         "gives up after the limit",
+			autoContinue: true,
+			outputs:      []*bedrockruntime.ConverseOutput{truncated("a"), truncated("b"), truncated("c"), truncated("d")},
+			want:         "abcd",
+			wantReason:   FinishReasonMaxTokens,
+			wantPrefills: []string{"a", "ab", "abc"},
+			wantTokens:   60,
+		},
+		{
+			name:         "tool calls",
+			autoContinue: true,
+			outputs:      []*bedrockruntime.ConverseOutput{truncated("Let me check.", toolUse)},
+			want:         "Let me check.",
+			wantReason:   FinishReasonMaxTokens,
+			wantTokens:   15,
+		},
+		{
+			name:         "continuation fails",
+			autoContinue: true,
+			outputs:      []*bedrockruntime.ConverseOutput{truncated(`{"action": "kub`)},
+			errs:         []error{nil, &types.ValidationException{Message: aws.String("Malformed input")}},
+			want:         `{"action": "kub`,
+			wantReason:   FinishReasonMaxTokens,
+			wantPrefills: []string{`{"action": "kub`},
+			wantTokens:   15,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeBedrockAPI{converseOutputs: tt.outputs, errs: tt.errs}
+			client := &BedrockClient{runtime: fake, opts: BedrockOptions{AutoContinue: tt.autoContinue}}
+			chat := client.StartChat("", "us.anthropic.claude-sonnet-4-20250514-v1:0").(*bedrockChat)
+
+			response, err := chat.Send(context.Background(), "what is the next step?")
+			if err != nil {
+				t.Fatalf("Send failed: %v", err)
+			}
+
+			candidate := response.Candidates()[0]
+			if got := candidate.String(); got != tt.want {
+				t.Errorf("expected response %q, got %q", tt.want, got)
+			}
+			if got := candidate.FinishReason(); got != tt.wantReason {
+				t.Errorf("expected finish reason %q, got %q", tt.wantReason, got)
+			}
+			if got := response.UsageMetadata().(*Usage).TotalTokens; got != tt.wantTokens {
+				t.Errorf("expected %d total tokens, got %d", tt.wantTokens, got)
+			}
+
+			// Continuations prefill the assistant message with the text generated so far.
+			var prefills []string
+			for _, input := range fake.converseInputs[1:] {
+				last := input.Messages[len(input.Messages)-1]
+				if last.Role != types.ConversationRoleAssistant {
+					t.Fatalf("expected the continuation to end with an assistant message, got %s", last.Role)
+				}
+				prefills = append(prefills, last.Content[0].(*types.ContentBlockMemberText).Value)
+			}
+			if !slices.Equal(prefills, tt.wantPrefills) {
+				t.Errorf("expected prefills %q, got %q", tt.wantPrefills, prefills)
+			}
+
+			// The history holds the stitched response as a single assistant message.
+			if len(chat.messages) != 2 {
+				t.Fatalf("expected 2 messages in history, got %d", len(chat.messages))
+			}
+			if got := chat.messages[1].Content[0].(*types.ContentBlockMemberText).Value; got != tt.want {
+				t.Errorf("expected history to hold %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestBedrockRegionFailover(t *testing.T) {
+	const model = "us.anthropic.claude-sonnet-4-20250514-v1:0"
+	unavailable := &types.ServiceUnavailableException{Message: aws.String("Service unavailable")}
+	throttled := &types.ThrottlingException{Message: aws.String("Too many requests")}
+
+	tests := []struct {
+		name          string
+		primaryErrs   []error
+		failoverErrs  []error
+		failover      string
+		wantRegion    string
+		wantModel     string
+		wantErr       bool
+		wantFailovers int
+	}{
+		{
+			name:       "primary region serves",
+			failover:   "us-west-2",
+			wantRegion: "us-east-1",
+			wantModel:  model,
+		},
+		{
+			name:          "primary region unavailable",
+			primaryErrs:   []error{unavailable},
+			failover:      "us-west-2",
+			wantRegion:    "us-west-2",
+			wantModel:     model,
+			wantFailovers: 1,
+		},
+		{
+			name:          "throttled in another geography",
+			primaryErrs:   []error{throttled},
+			failover:      "eu-west-1",
+			wantRegion:    "eu-west-1",
+			wantModel:     "eu.anthropic.claude-sonnet-4-20250514-v1:0",
+			wantFailovers: 1,
+		},
+		{
+			name:          "all regions fail",
+			primaryErrs:   []error{unavailable},
+			failoverErrs:  []error{unavailable},
+			failover:      "us-west-2",
+			wantErr:       true,
+			wantFailovers: 1,
+		},
+		{
+			name:        "not a region failure",
+			primaryErrs: []error{&types.ValidationException{Message: aws.String("Malformed input")}},
+			failover:    "us-west-2",
+			wantErr:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		for _, streaming := range []bool{false, true} {
+			t.Run(fmt.Sprintf("%s/streaming=%v", tt.name, streaming), func(t *testing.T) {
+				newFake := func(errs []error) *fakeBedrockAPI {
+					output := assistantOutput(&types.ContentBlockMemberText{Value: "hi"})
+					output.Usage = &types.TokenUsage{InputTokens: aws.Int32(10), OutputTokens: aws.Int32(5), TotalTokens: aws.Int32(15)}
+					return &fakeBedrockAPI{
+						errs:            errs,
+						converseOutputs: []*bedrockruntime.ConverseOutput{output},
+						streams: []*fakeEventStream{{events: []types.ConverseStreamOutput{
+							textDeltaEvent("hi"),
+							&types.ConverseStreamOutputMemberMetadata{Value: types.ConverseStreamMetadataEvent{
+								Usage: &types.TokenUsage{InputTokens: aws.Int32(10), OutputTokens: aws.Int32(5), TotalTokens: aws.Int32(15)},
+							}},
+						}}},
+					}
+				}
+				primary, failover := newFake(tt.primaryErrs), newFake(tt.failoverErrs)
+				client := &BedrockClient{
+					runtime:         primary,
+					region:          "us-east-1",
+					failoverRegions: []bedrockRegion{{name: tt.failover, runtime: failover}},
+				}
+				chat := client.StartChat("", model)
+
+				var usage *Usage
+				var err error
+				if streaming {
+					var stream ChatResponseIterator
+					stream, err = chat.SendStreaming(context.Background(), "hello")
+					if err == nil {
+						for response, streamErr := range stream {
+							if streamErr != nil {
+								t.Fatalf("stream failed: %v", streamErr)
+							}
+							if u, ok := response.UsageMetadata().(*Usage); ok {
+								usage = u
+							}
+						}
+					}
+				} else {
+					var response ChatResponse
+					response, err = chat.Send(context.Background(), "hello")
+					if err == nil {
+						usage = response.UsageMetadata().(*Usage)
+					}
+				}
+
+				failovers := len(failover.converseInputs) + len(failover.streamInputs)
+				if failovers != tt.wantFailovers {
+					t.Errorf("expected %d requests to the failover region, got %d", tt.wantFailovers, failovers)
+				}
+				if tt.wantErr {
+					if err == nil {
+						t.Fatal("expected an error")
+					}
+					return
+				}
+				if err != nil {
+					t.Fatalf("request failed: %v", err)
+				}
+				if usage == nil {
+					t.Fatal("expected usage to be reported")
+				}
+				if usage.Region != tt.wantRegion {
+					t.Errorf("expected usage to record region %q, got %q", tt.wantRegion, usage.Region)
+				}
+				if usage.Model != tt.wantModel {
+					t.Errorf("expected usage to record model %q, got %q", tt.wantModel, usage.Model)
+				}
+			})
+		}
+	}
+}
+
+func TestBedrockRegionFailoverOrder(t *testing.T) {
+	client := &BedrockClient{
+		region: "us-east-1",
+		failoverRegions: []bedrockRegion{
+			{name: "eu-west-1"},
+			{name: "us-west-2"},
+		},
+		opts: BedrockOptions{ModelFallback: []string{
+			"us.anthropic.claude-3-5-haiku-20241022-v1:0",
+			"arn:aws:bedrock:us-east-1:123456789012:application-inference-profile/a1b2c3d4e5f6",
+		}},
+	}
+	chat := client.StartChat("", "us.anthropic.claude-sonnet-4-20250514-v1:0").(*bedrockChat)
+
+	var got []string
+	for _, target := range chat.targets() {
+		got = append(got, target.region.name+" "+target.model)
+	}
+	// Every region is tried with a model before falling back to the next model;
+	// model ARNs are regional, and only used in their own region.
+	want := []string{
+		"us-east-1 us.anthropic.claude-sonnet-4-20250514-v1:0",
+		"eu-west-1 eu.anthropic.claude-sonnet-4-20250514-v1:0",
+		"us-west-2 us.anthropic.claude-sonnet-4-20250514-v1:0",
+		"us-east-1 us.anthropic.claude-3-5-haiku-20241022-v1:0",
+		"eu-west-1 eu.anthropic.claude-3-5-haiku-20241022-v1:0",
+		"us-west-2 us.anthropic.claude-3-5-haiku-20241022-v1:0",
+		"us-east-1 arn:aws:bedrock:us-east-1:123456789012:application-inference-profile/a1b2c3d4e5f6",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("expected targets\n%s\ngot\n%s", strings.Join(want, "\n"), strings.Join(got, "\n"))
+	}
+}
+
+func TestBedrockFailoverRewritesOnlyInferenceProfiles(t *testing.T) {
+	tests := []struct {
+		name  string
+		model string
+		want  []string
+	}{
+		{
+			name:  "inference profile",
+			model: "us.anthropic.claude-sonnet-4-20250514-v1:0",
+			want: []string{
+				"us-east-1 us.anthropic.claude-sonnet-4-20250514-v1:0",
+				"eu-west-1 eu.anthropic.claude-sonnet-4-20250514-v1:0",
+			},
+		},
+		{
+			name:  "foundation model",
+			model: "amazon.nova-pro-v1:0",
+			want: []string{
+				"us-east-1 amazon.nova-pro-v1:0",
+				"eu-west-1 amazon.nova-pro-v1:0",
+			},
+		},
+		{
+			name:  "ARN",
+			model: "arn:aws:bedrock:us-east-1:123456789012:inference-profile/us.anthropic.claude-sonnet-4-20250514-v1:0",
+			want: []string{
+				"us-east-1 arn:aws:bedrock:us-east-1:123456789012:inference-profile/us.anthropic.claude-sonnet-4-20250514-v1:0",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &BedrockClient{
+				region:          "us-east-1",
+				failoverRegions: []bedrockRegion{{name: "eu-west-1"}},
+			}
+			chat := client.StartChat("", tt.model).(*bedrockChat)
+
+			var got []string
+			for _, target := range chat.targets() {
+				got = append(got, target.region.name+" "+target.model)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("expected targets\n%s\ngot\n%s", strings.Join(tt.want, "\n"), strings.Join(got, "\n"))
+			}
+		})
+	}
+}
+
+func TestBedrockChatSchemas(t *testing.T) {
+	podSchema := &Schema{Type: TypeObject, Properties: map[string]*Schema{"pods": {Type: TypeInteger}}}
+	nodeSchema := &Schema{Type: TypeObject, Properties: map[string]*Schema{"nodes": {Type: TypeInteger}}}
+	respond := func(id, input string) *types.ContentBlockMemberToolUse {
+		var args map[string]any
+		if err := json.Unmarshal([]byte(input), &args); err != nil {
+			t.Fatalf("parsing %s: %v", input, err)
+		}
+		return &types.ContentBlockMemberToolUse{Value: types.ToolUseBlock{
+			ToolUseId: aws.String(id),
+			Name:      aws.String(bedrockResponseToolName),
+			Input:     document.NewLazyDocument(args),
+		}}
+	}
+	podOutput := assistantOutput(respond("call-1", `{"pods":3}`))
+	podOutput.StopReason = types.StopReasonToolUse
+	events := append(toolUseStreamEvents(0, "call-2", bedrockResponseToolName, `{"no`, `des":2}`),
+		&types.ConverseStreamOutputMemberMessageStop{Value: types.MessageStopEvent{StopReason: types.StopReasonToolUse}},
+		&types.ConverseStreamOutputMemberMetadata{Value: types.ConverseStreamMetadataEvent{}})
+	fake := &fakeBedrockAPI{
+		converseOutputs: []*bedrockruntime.ConverseOutput{podOutput, assistantOutput(&types.ContentBlockMemberText{Value: "hello"})},
+		streams:         []*fakeEventStream{{events: events}},
+	}
+	client := &BedrockClient{runtime: fake}
+	const model = "us.anthropic.claude-sonnet-4-20250514-v1:0"
+
+	podChat, err := StartChatWithOptions(client, "", model, WithChatSchema(podSchema))
+	if err != nil {
+		t.Fatalf("starting pod chat: %v", err)
+	}
+	nodeChat, err := StartChatWithOptions(client, "", model, WithChatSchema(nodeSchema))
+	if err != nil {
+		t.Fatalf("starting node chat: %v", err)
+	}
+	plainChat := client.StartChat("", model)
+
+	podResponse, err := podChat.Send(context.Background(), "how many pods?")
+	if err != nil {
+		t.Fatalf("pod chat Send failed: %v", err)
+	}
+	stream, err := nodeChat.SendStreaming(context.Background(), "how many nodes?")
+	if err != nil {
+		t.Fatalf("node chat SendStreaming failed: %v", err)
+	}
+	nodeResponse, err := CollectStream(stream)
+	if err != nil {
+		t.Fatalf("node chat SendStreaming failed: %v", err)
+	}
+	if _, err := plainChat.Send(context.Background(), "hi"); err != nil {
+		t.Fatalf("plain chat Send failed: %v", err)
+	}
+
+	// Each chat forces the response tool with its own schema
+	responseToolProperties := func(config *types.ToolConfiguration) []string {
+		t.Helper()
+		if config == nil || len(config.Tools) != 1 {
+			t.Fatalf("expected only the response tool, got %+v", config)
+		}
+		if choice, ok := config.ToolChoice.(*types.ToolChoiceMemberTool); !ok || aws.ToString(choice.Value.Name) != bedrockResponseToolName {
+			t.Errorf("expected the response tool to be forced, got %+v", config.ToolChoice)
+		}
+		var inputSchema struct {
+			Properties map[string]any `json:"properties"`
+		}
+		spec := config.Tools[0].(*types.ToolMemberToolSpec).Value
+		if err := unmarshalDocument(spec.InputSchema.(*types.ToolInputSchemaMemberJson).Value, &inputSchema); err != nil {
+			t.Fatalf("decoding input schema: %v", err)
+		}
+		return slices.Sorted(maps.Keys(inputSchema.Properties))
+	}
+	if got := responseToolProperties(fake.converseInputs[0].ToolConfig); !slices.Equal(got, []string{"pods"}) {
+		t.Errorf("expected the pod chat to use its schema, got properties %v", got)
+	}
+	if got := responseToolProperties(fake.streamInputs[0].ToolConfig); !slices.Equal(got, []string{"nodes"}) {
+		t.Errorf("expected the node chat to use its schema, got properties %v", got)
+	}
+	if config := fake.converseInputs[1].ToolConfig; config != nil {
+		t.Errorf("expected a chat without a schema to send no tools, got %+v", config)
+	}
+
+	// The structured responses are returned, and kept in the history, as text
+	for _, tt := range []struct {
+		response ChatResponse
+		chat     Chat
+		want     string
+	}{
+		{response: podResponse, chat: podChat, want: `{"pods":3}`},
+		{response: nodeResponse, chat: nodeChat, want: `{"nodes":2}`},
+	} {
+		if text, calls := responseText(tt.response); calls || text != tt.want {
+			t.Errorf("expected response %s, got %q (calls: %v)", tt.want, text, calls)
+		}
+		if reason := tt.response.Candidates()[0].FinishReason(); reason != FinishReasonStop {
+			t.Errorf("expected the structured response to finish the turn, got %v", reason)
+		}
+		messages := tt.chat.(*bedrockChat).messages
+		if got, ok := messages[len(messages)-1].Content[0].(*types.ContentBlockMemberText); !ok || got.Value != tt.want {
+			t.Errorf("expected %s in the history, got %+v", tt.want, messages[len(messages)-1].Content)
+		}
+	}
+}
+
+func TestBedrockChatSchemaMustBeObject(t *testing.T) {
+	client := &BedrockClient{runtime: &fakeBedrockAPI{}}
+	_, err := StartChatWithOptions(client, "", "us.anthropic.claude-sonnet-4-20250514-v1:0", WithChatSchema(&Schema{Type: TypeString}))
+	if err == nil {
+		t.Error("expected an error for a schema that is not an object")
+	}
+}
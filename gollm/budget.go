@@ -0,0 +1,105 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gollm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrBudgetExceeded is returned, without calling the provider, by the requests of a
+// chat that has used up its token or cost budget.
+var ErrBudgetExceeded = errors.New("chat budget exceeded")
+
+// WithMaxTotalTokens caps the tokens a single chat may use across its requests. Once a
+// chat has used n tokens, its further requests fail with ErrBudgetExceeded.
+// Only usage reported as a *Usage, as Bedrock does, counts against the budget.
+func WithMaxTotalTokens(n int) Option {
+	return func(o *ClientOptions) {
+		o.MaxTotalTokens = n
+	}
+}
+
+// WithMaxTotalCost caps the cost, in US dollars, of a single chat's requests. Once a chat
+// has cost usd, its further requests fail with ErrBudgetExceeded. Only requests to
+// models whose pricing is known count against the budget.
+func WithMaxTotalCost(usd float64) Option {
+	return func(o *ClientOptions) {
+		o.MaxTotalCost = usd
+	}
+}
+
+// withBudget wraps client so that its chats are limited by opts.MaxTotalTokens and
+// opts.MaxTotalCost, or returns it unchanged if there is no limit.
+func withBudget(client Client, opts ClientOptions) Client {
+	if opts.MaxTotalTokens <= 0 && opts.MaxTotalCost <= 0 {
+		return client
+	}
+	return decorateClient(client, nil, func() turnStarter {
+		budget := &chatBudget{maxTokens: opts.MaxTotalTokens, maxCost: opts.MaxTotalCost}
+		return budget.startTurn
+	})
+}
+
+// chatBudget tracks the usage of a chat, and fails its requests once it exceeds its budget.
+type chatBudget struct {
+	maxTokens int
+	maxCost   float64
+
+	mu   sync.Mutex
+	used Usage
+}
+
+// check returns ErrBudgetExceeded if the chat has used up its budget.
+func (b *chatBudget) check() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.maxTokens > 0 && b.used.TotalTokens >= b.maxTokens {
+		return fmt.Errorf("%w: used %d of %d tokens", ErrBudgetExceeded, b.used.TotalTokens, b.maxTokens)
+	}
+	if b.maxCost > 0 && b.used.TotalCost >= b.maxCost {
+		return fmt.Errorf("%w: cost $%.4f of $%.4f", ErrBudgetExceeded, b.used.TotalCost, b.maxCost)
+	}
+	return nil
+}
+
+// record adds the usage of a response, if it reports any, to the chat's usage.
+func (b *chatBudget) record(response ChatResponse) {
+	if response == nil {
+		return
+	}
+	usage, ok := response.UsageMetadata().(*Usage)
+	if !ok || usage == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.used.add(*usage)
+}
+
+// startTurn starts a request if the chat has budget left, and records the usage of its responses.
+func (b *chatBudget) startTurn(ctx context.Context, _ func(error) bool) (*turn, error) {
+	if err := b.check(); err != nil {
+		return nil, err
+	}
+	return &turn{ctx: ctx, observe: func(response any, err error) error {
+		if response, ok := response.(ChatResponse); ok && err == nil {
+			b.record(response)
+		}
+		return err
+	}}, nil
+}
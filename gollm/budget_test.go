@@ -0,0 +1,103 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gollm
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime/types"
+)
+
+// outputWithUsage returns a Converse output with text, which reports the given token usage.
+func outputWithUsage(text string, input, output int32) *bedrockruntime.ConverseOutput {
+	result := assistantOutput(&types.ContentBlockMemberText{Value: text})
+	result.Usage = &types.TokenUsage{
+		InputTokens:  aws.Int32(input),
+		OutputTokens: aws.Int32(output),
+		TotalTokens:  aws.Int32(input + output),
+	}
+	return result
+}
+
+func TestBudget(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+		// allowed is the number of requests sent before the budget is exceeded
+		allowed int
+	}{
+		// Every request uses 1000 input and 200 output tokens, costing $0.006
+		{name: "tokens", opts: []Option{WithMaxTotalTokens(2000)}, allowed: 2},
+		{name: "cost", opts: []Option{WithMaxTotalCost(0.015)}, allowed: 3},
+		{name: "both", opts: []Option{WithMaxTotalTokens(10000), WithMaxTotalCost(0.005)}, allowed: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeBedrockAPI{}
+			for range 5 {
+				fake.converseOutputs = append(fake.converseOutputs, outputWithUsage("ok", 1000, 200))
+			}
+			var opts ClientOptions
+			for _, opt := range tt.opts {
+				opt(&opts)
+			}
+			chat := withBudget(&BedrockClient{runtime: fake}, opts).StartChat("", "us.anthropic.claude-sonnet-4-20250514-v1:0")
+
+			for i := range tt.allowed {
+				if _, err := chat.Send(context.Background(), "hello"); err != nil {
+					t.Fatalf("request %d: expected to be within budget, got %v", i, err)
+				}
+			}
+			if _, err := chat.Send(context.Background(), "hello"); !errors.Is(err, ErrBudgetExceeded) {
+				t.Fatalf("expected ErrBudgetExceeded, got %v", err)
+			}
+			if _, err := chat.SendStreaming(context.Background(), "hello"); !errors.Is(err, ErrBudgetExceeded) {
+				t.Fatalf("expected ErrBudgetExceeded from SendStreaming, got %v", err)
+			}
+			if len(fake.converseInputs) != tt.allowed || len(fake.streamInputs) != 0 {
+				t.Errorf("expected %d requests to reach Bedrock, got %d", tt.allowed, len(fake.converseInputs)+len(fake.streamInputs))
+			}
+		})
+	}
+}
+
+func TestBudgetStreaming(t *testing.T) {
+	stream := &fakeEventStream{events: []types.ConverseStreamOutput{
+		textDeltaEvent("Hello"),
+		&types.ConverseStreamOutputMemberMetadata{Value: types.ConverseStreamMetadataEvent{
+			Usage: &types.TokenUsage{InputTokens: aws.Int32(900), OutputTokens: aws.Int32(100), TotalTokens: aws.Int32(1000)},
+		}},
+	}}
+	fake := &fakeBedrockAPI{streams: []*fakeEventStream{stream}}
+	chat := withBudget(&BedrockClient{runtime: fake}, ClientOptions{MaxTotalTokens: 1000}).StartChat("", "us.anthropic.claude-sonnet-4-20250514-v1:0")
+
+	iterator, err := chat.SendStreaming(context.Background(), "hello")
+	if err != nil {
+		t.Fatalf("SendStreaming failed: %v", err)
+	}
+	for _, err := range iterator {
+		if err != nil {
+			t.Fatalf("stream error: %v", err)
+		}
+	}
+	if _, err := chat.Send(context.Background(), "hello"); !errors.Is(err, ErrBudgetExceeded) {
+		t.Errorf("expected the streamed usage to exhaust the budget, got %v", err)
+	}
+}
@@ -0,0 +1,69 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gollm
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrChatOptionsNotSupported is returned by StartChatWithOptions for a client that
+// cannot start chats with options.
+var ErrChatOptionsNotSupported = errors.New("chat options not supported")
+
+// ChatOptions configures a single chat started with StartChatWithOptions.
+type ChatOptions struct {
+	// Schema, if set, constrains the responses of the chat in place of the response
+	// schema of the client.
+	Schema *Schema
+}
+
+// ChatOption sets a field of ChatOptions.
+type ChatOption func(*ChatOptions)
+
+// WithChatSchema constrains the responses of a single chat to match schema, without
+// changing the response schema of the client or of its other chats.
+func WithChatSchema(schema *Schema) ChatOption {
+	return func(o *ChatOptions) {
+		o.Schema = schema
+	}
+}
+
+func newChatOptions(opts []ChatOption) ChatOptions {
+	var options ChatOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return options
+}
+
+// ChatOptionsStarter is implemented by clients that can start chats configured with ChatOptions.
+type ChatOptionsStarter interface {
+	StartChatWithOptions(systemPrompt, model string, opts ...ChatOption) (Chat, error)
+}
+
+// StartChatWithOptions starts a chat of client configured with opts. Without options,
+// it is the same as client.StartChat; with options, it fails with ErrChatOptionsNotSupported
+// if client cannot apply them.
+func StartChatWithOptions(client Client, systemPrompt, model string, opts ...ChatOption) (Chat, error) {
+	if len(opts) == 0 {
+		return client.StartChat(systemPrompt, model), nil
+	}
+	starter, ok := client.(ChatOptionsStarter)
+	if !ok {
+		return nil, fmt.Errorf("%w by %s", ErrChatOptionsNotSupported, client.Name())
+	}
+	return starter.StartChatWithOptions(systemPrompt, model, opts...)
+}
@@ -0,0 +1,145 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gollm
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned instead of calling the provider while the circuit breaker is open.
+var ErrCircuitOpen = errors.New("circuit breaker is open: provider is failing, not sending request")
+
+// CircuitBreakerConfig configures the circuit breaker installed by WithCircuitBreaker.
+type CircuitBreakerConfig struct {
+	// Threshold is the number of consecutive non-retryable failures that opens the breaker.
+	Threshold int
+	// Cooldown is how long the breaker stays open before allowing a trial request.
+	Cooldown time.Duration
+}
+
+// WithCircuitBreaker stops requests to a failing provider. After threshold consecutive
+// non-retryable failures, requests fail fast with ErrCircuitOpen until cooldown has
+// elapsed; then a single trial request is let through, and its outcome decides whether
+// the breaker closes again or stays open for another cooldown. A streaming request lasts
+// until its stream has been consumed or its context is done.
+func WithCircuitBreaker(threshold int, cooldown time.Duration) Option {
+	return func(o *ClientOptions) {
+		o.CircuitBreaker = &CircuitBreakerConfig{Threshold: threshold, Cooldown: cooldown}
+	}
+}
+
+type circuitState int
+
+const (
+	circuitClosed circuitState = iota
+	circuitOpen
+	circuitHalfOpen
+)
+
+// circuitBreaker tracks the failures of a provider and decides whether requests may be sent.
+type circuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+
+	mu       sync.Mutex
+	state    circuitState
+	failures int
+	openedAt time.Time
+	// trialInFlight is set while the single half-open trial request is outstanding.
+	trialInFlight bool
+}
+
+func newCircuitBreaker(config CircuitBreakerConfig) *circuitBreaker {
+	return &circuitBreaker{
+		threshold: max(config.Threshold, 1),
+		cooldown:  config.Cooldown,
+		now:       time.Now,
+	}
+}
+
+// allow returns ErrCircuitOpen if a request may not be sent now.
+// Every request that is allowed must be followed by a call to record.
+func (b *circuitBreaker) allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case circuitOpen:
+		if b.now().Sub(b.openedAt) < b.cooldown {
+			return ErrCircuitOpen
+		}
+		b.state = circuitHalfOpen
+	case circuitHalfOpen:
+		if b.trialInFlight {
+			return ErrCircuitOpen
+		}
+	default:
+		return nil
+	}
+	b.trialInFlight = true
+	return nil
+}
+
+// record reports the outcome of an allowed request. Retryable errors and
+// cancellations say nothing about the health of the provider, and are ignored.
+func (b *circuitBreaker) record(err error, isRetryable func(error) bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	trial := b.state == circuitHalfOpen
+	if trial {
+		b.trialInFlight = false
+	}
+
+	switch {
+	case err == nil:
+		b.state = circuitClosed
+		b.failures = 0
+	case isRetryable(err), errors.Is(err, context.Canceled):
+		return
+	default:
+		b.failures++
+		if trial || b.failures >= b.threshold {
+			b.state = circuitOpen
+			b.openedAt = b.now()
+		}
+	}
+}
+
+// withCircuitBreaker wraps client with the circuit breaker configured in opts,
+// or returns it unchanged if there is none.
+func withCircuitBreaker(client Client, opts ClientOptions) Client {
+	if opts.CircuitBreaker == nil {
+		return client
+	}
+	return withBreaker(client, newCircuitBreaker(*opts.CircuitBreaker))
+}
+
+// withBreaker wraps client so that its requests, and those of its chats, go through breaker.
+func withBreaker(client Client, breaker *circuitBreaker) Client {
+	return decorateClient(client, breaker.startTurn, func() turnStarter { return breaker.startTurn })
+}
+
+// startTurn starts a request if the breaker allows it, and records its outcome once it is over.
+func (b *circuitBreaker) startTurn(ctx context.Context, isRetryable func(error) bool) (*turn, error) {
+	if err := b.allow(); err != nil {
+		return nil, err
+	}
+	return &turn{ctx: ctx, end: func(err error) { b.record(err, isRetryable) }}, nil
+}
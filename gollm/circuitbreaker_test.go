@@ -0,0 +1,168 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gollm
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// fakeChatClient is a Client whose chats are the given fakeChat.
+type fakeChatClient struct {
+	Client
+
+	chat *fakeChat
+}
+
+func (c *fakeChatClient) StartChat(systemPrompt, model string) Chat {
+	return c.chat
+}
+
+// newBreakerChat returns a chat behind a circuit breaker with the given threshold
+// and a one minute cooldown, and a function that advances the breaker's clock.
+func newBreakerChat(underlying *fakeChat, threshold int) (Chat, func(time.Duration)) {
+	breaker := newCircuitBreaker(CircuitBreakerConfig{Threshold: threshold, Cooldown: time.Minute})
+	now := time.Now()
+	breaker.now = func() time.Time { return now }
+	advance := func(d time.Duration) { now = now.Add(d) }
+	return withBreaker(&fakeChatClient{chat: underlying}, breaker).StartChat("", "model"), advance
+}
+
+func TestCircuitBreakerOpensAndRecovers(t *testing.T) {
+	ctx := context.Background()
+	expired := errors.New("credentials expired")
+	underlying := &fakeChat{errs: []error{expired, expired, expired}}
+	chat, advance := newBreakerChat(underlying, 3)
+
+	for i := range 3 {
+		if _, err := chat.Send(ctx, "hello"); !errors.Is(err, expired) {
+			t.Fatalf("request %d: expected provider error, got %v", i, err)
+		}
+	}
+
+	// The breaker is now open, and fails fast without calling the provider.
+	if _, err := chat.Send(ctx, "hello"); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected ErrCircuitOpen, got %v", err)
+	}
+	if _, err := chat.SendStreaming(ctx, "hello"); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected ErrCircuitOpen from SendStreaming, got %v", err)
+	}
+	if len(underlying.attempts) != 3 {
+		t.Fatalf("expected 3 provider requests, got %d", len(underlying.attempts))
+	}
+
+	// After the cooldown, a trial request is let through and closes the breaker.
+	advance(time.Minute)
+	if _, err := chat.Send(ctx, "hello"); err != nil {
+		t.Fatalf("expected trial request to succeed, got %v", err)
+	}
+	if _, err := chat.Send(ctx, "hello"); err != nil {
+		t.Fatalf("expected breaker to be closed, got %v", err)
+	}
+	if len(underlying.attempts) != 5 {
+		t.Errorf("expected 5 provider requests, got %d", len(underlying.attempts))
+	}
+}
+
+func TestCircuitBreakerFailedTrialReopens(t *testing.T) {
+	ctx := context.Background()
+	expired := errors.New("credentials expired")
+	underlying := &fakeChat{errs: []error{expired, expired}}
+	chat, advance := newBreakerChat(underlying, 1)
+
+	if _, err := chat.Send(ctx, "hello"); !errors.Is(err, expired) {
+		t.Fatalf("expected provider error, got %v", err)
+	}
+	advance(time.Minute)
+	if _, err := chat.Send(ctx, "hello"); !errors.Is(err, expired) {
+		t.Fatalf("expected trial request to reach the provider, got %v", err)
+	}
+
+	// The failed trial starts a new cooldown.
+	advance(30 * time.Second)
+	if _, err := chat.Send(ctx, "hello"); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected ErrCircuitOpen, got %v", err)
+	}
+	advance(30 * time.Second)
+	if _, err := chat.Send(ctx, "hello"); err != nil {
+		t.Fatalf("expected second trial request to succeed, got %v", err)
+	}
+}
+
+func TestCircuitBreakerAllowsOneTrial(t *testing.T) {
+	ctx := context.Background()
+	underlying := &fakeChat{errs: []error{errors.New("credentials expired")}}
+	chat, advance := newBreakerChat(underlying, 1)
+
+	if _, err := chat.Send(ctx, "hello"); err == nil {
+		t.Fatal("expected provider error")
+	}
+	advance(time.Minute)
+
+	// The trial stream is outstanding until it has been consumed.
+	stream, err := chat.SendStreaming(ctx, "hello")
+	if err != nil {
+		t.Fatalf("expected trial request to be allowed, got %v", err)
+	}
+	if _, err := chat.Send(ctx, "hello"); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected a second request during the trial to be rejected, got %v", err)
+	}
+	for _, err := range stream {
+		if err != nil {
+			t.Fatalf("unexpected stream error: %v", err)
+		}
+	}
+	if _, err := chat.Send(ctx, "hello"); err != nil {
+		t.Fatalf("expected breaker to be closed after the trial, got %v", err)
+	}
+}
+
+func TestCircuitBreakerIgnoresRetryableErrors(t *testing.T) {
+	ctx := context.Background()
+	expired := errors.New("credentials expired")
+	unavailable := &APIError{StatusCode: http.StatusServiceUnavailable, Message: "unavailable"}
+	underlying := &fakeChat{errs: []error{expired, unavailable, context.Canceled, unavailable}}
+	chat, _ := newBreakerChat(underlying, 2)
+
+	for range 4 {
+		chat.Send(ctx, "hello")
+	}
+	// Only one of the failures counts, so the breaker is still closed.
+	if _, err := chat.Send(ctx, "hello"); err != nil {
+		t.Fatalf("expected breaker to be closed, got %v", err)
+	}
+}
+
+func TestCircuitBreakerCancelledTrialStream(t *testing.T) {
+	expired := errors.New("credentials expired")
+	chat, advance := newBreakerChat(&fakeChat{errs: []error{expired}}, 1)
+	if _, err := chat.Send(context.Background(), "hello"); !errors.Is(err, expired) {
+		t.Fatalf("expected provider error, got %v", err)
+	}
+	advance(2 * time.Minute)
+
+	// The trial stream is dropped and its context cancelled, which says nothing about
+	// the provider, so another trial is let through.
+	ctx, cancel := context.WithCancel(context.Background())
+	dropStream(t, ctx, chat)
+	cancel()
+	waitFor(t, func() bool {
+		_, err := chat.Send(context.Background(), "hello")
+		return !errors.Is(err, ErrCircuitOpen)
+	})
+}
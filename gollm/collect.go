@@ -0,0 +1,163 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gollm
+
+import (
+	"maps"
+	"strings"
+)
+
+// CollectStream drains stream and assembles its chunks into a single response: the
+// text of each candidate is concatenated, its function calls are merged, and the
+// usage is that of the last chunk that reports any. It returns the first error of
+// the stream, if there is one.
+func CollectStream(stream ChatResponseIterator) (ChatResponse, error) {
+	collected := &collectedResponse{}
+	for response, err := range stream {
+		if err != nil {
+			return nil, err
+		}
+		if response == nil {
+			continue
+		}
+		if usage := response.UsageMetadata(); usage != nil {
+			collected.usage = usage
+		}
+		for i, candidate := range response.Candidates() {
+			if i == len(collected.candidates) {
+				collected.candidates = append(collected.candidates, &collectedCandidate{})
+			}
+			collected.candidates[i].add(candidate)
+		}
+	}
+	return collected, nil
+}
+
+// collectedResponse is the ChatResponse assembled by CollectStream.
+type collectedResponse struct {
+	usage      any
+	candidates []*collectedCandidate
+}
+
+var _ ChatResponse = &collectedResponse{}
+
+func (r *collectedResponse) UsageMetadata() any {
+	return r.usage
+}
+
+func (r *collectedResponse) Candidates() []Candidate {
+	candidates := make([]Candidate, len(r.candidates))
+	for i, candidate := range r.candidates {
+		candidates[i] = candidate
+	}
+	return candidates
+}
+
+func (r *collectedResponse) RequiresToolCall() bool {
+	return requiresToolCall(r.Candidates())
+}
+
+// collectedCandidate is a candidate assembled from the chunks of a stream.
+type collectedCandidate struct {
+	text         strings.Builder
+	calls        []FunctionCall
+	finishReason FinishReason
+	refusal      bool
+}
+
+var _ Candidate = &collectedCandidate{}
+
+// add appends a chunk of the candidate. A function call with the ID of one already
+// seen continues it, for providers that stream the arguments of a call in pieces.
+func (c *collectedCandidate) add(chunk Candidate) {
+	for _, part := range chunk.Parts() {
+		if text, ok := part.AsText(); ok {
+			c.text.WriteString(text)
+		}
+		if calls, ok := part.AsFunctionCalls(); ok {
+			for _, call := range calls {
+				c.addCall(call)
+			}
+		}
+	}
+	if reason := chunk.FinishReason(); reason != FinishReasonUnspecified {
+		c.finishReason = reason
+	}
+	c.refusal = c.refusal || chunk.IsRefusal()
+}
+
+func (c *collectedCandidate) addCall(call FunctionCall) {
+	if call.ID != "" {
+		for i := range c.calls {
+			if c.calls[i].ID != call.ID {
+				continue
+			}
+			if c.calls[i].Name == "" {
+				c.calls[i].Name = call.Name
+			}
+			for name, value := range call.Arguments {
+				if c.calls[i].Arguments == nil {
+					c.calls[i].Arguments = make(map[string]any)
+				}
+				c.calls[i].Arguments[name] = value
+			}
+			return
+		}
+	}
+	// The arguments are copied, so that merging later pieces does not modify the chunk.
+	call.Arguments = maps.Clone(call.Arguments)
+	c.calls = append(c.calls, call)
+}
+
+func (c *collectedCandidate) String() string {
+	return c.text.String()
+}
+
+func (c *collectedCandidate) Parts() []Part {
+	var parts []Part
+	if c.text.Len() != 0 {
+		parts = append(parts, &collectedPart{text: c.text.String()})
+	}
+	if len(c.calls) != 0 {
+		parts = append(parts, &collectedPart{calls: c.calls})
+	}
+	return parts
+}
+
+func (c *collectedCandidate) HasToolCalls() bool {
+	return hasToolCalls(c.Parts())
+}
+
+func (c *collectedCandidate) FinishReason() FinishReason {
+	return c.finishReason
+}
+
+func (c *collectedCandidate) IsRefusal() bool {
+	return c.refusal
+}
+
+// collectedPart is a part of a collectedCandidate: its text, or its function calls.
+type collectedPart struct {
+	text  string
+	calls []FunctionCall
+}
+
+func (p *collectedPart) AsText() (string, bool) {
+	return p.text, p.calls == nil
+}
+
+func (p *collectedPart) AsFunctionCalls() ([]FunctionCall, bool) {
+	return p.calls, p.calls != nil
+}
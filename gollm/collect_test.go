@@ -0,0 +1,155 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gollm
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// streamChunk is a streamed ChatResponse with a single candidate.
+type streamChunk struct {
+	parts        []Part
+	usage        any
+	finishReason FinishReason
+}
+
+func (c *streamChunk) UsageMetadata() any         { return c.usage }
+func (c *streamChunk) Candidates() []Candidate    { return []Candidate{c} }
+func (c *streamChunk) RequiresToolCall() bool     { return hasToolCalls(c.parts) }
+func (c *streamChunk) String() string             { return "" }
+func (c *streamChunk) Parts() []Part              { return c.parts }
+func (c *streamChunk) HasToolCalls() bool         { return hasToolCalls(c.parts) }
+func (c *streamChunk) FinishReason() FinishReason { return c.finishReason }
+func (c *streamChunk) IsRefusal() bool            { return c.finishReason == FinishReasonContentFiltered }
+
+func streamOf(chunks ...ChatResponse) ChatResponseIterator {
+	return func(yield func(ChatResponse, error) bool) {
+		for _, chunk := range chunks {
+			if !yield(chunk, nil) {
+				return
+			}
+		}
+	}
+}
+
+func TestCollectStream(t *testing.T) {
+	usage := &Usage{InputTokens: 12, OutputTokens: 7, TotalTokens: 19}
+
+	tests := []struct {
+		name       string
+		stream     ChatResponseIterator
+		wantText   string
+		wantCalls  []FunctionCall
+		wantUsage  any
+		wantReason FinishReason
+	}{
+		{
+			name: "text only",
+			stream: streamOf(
+				&streamChunk{parts: []Part{&fakePart{text: "All pods "}}},
+				&streamChunk{parts: []Part{&fakePart{text: "are running."}}},
+				&streamChunk{usage: usage, finishReason: FinishReasonStop},
+			),
+			wantText:   "All pods are running.",
+			wantUsage:  usage,
+			wantReason: FinishReasonStop,
+		},
+		{
+			name: "tool call",
+			stream: streamOf(
+				&streamChunk{parts: []Part{&fakePart{calls: []FunctionCall{{ID: "call-1", Name: "kubectl", Arguments: map[string]any{"command": "kubectl get pods"}}}}}},
+				&streamChunk{parts: []Part{&fakePart{calls: []FunctionCall{{ID: "call-1", Arguments: map[string]any{"modifies_resource": "no"}}}}}},
+				&streamChunk{usage: usage, finishReason: FinishReasonToolUse},
+			),
+			wantCalls: []FunctionCall{
+				{ID: "call-1", Name: "kubectl", Arguments: map[string]any{"command": "kubectl get pods", "modifies_resource": "no"}},
+			},
+			wantUsage:  usage,
+			wantReason: FinishReasonToolUse,
+		},
+		{
+			name: "mixed",
+			stream: streamOf(
+				&streamChunk{parts: []Part{&fakePart{text: "Let me check "}}, usage: &Usage{InputTokens: 12}},
+				&streamChunk{parts: []Part{&fakePart{text: "the pods."}, &fakePart{calls: []FunctionCall{{ID: "call-1", Name: "kubectl"}}}}},
+				&streamChunk{parts: []Part{&fakePart{calls: []FunctionCall{{ID: "call-2", Name: "bash"}}}}},
+				nil,
+				&streamChunk{usage: usage, finishReason: FinishReasonToolUse},
+			),
+			wantText:   "Let me check the pods.",
+			wantCalls:  []FunctionCall{{ID: "call-1", Name: "kubectl"}, {ID: "call-2", Name: "bash"}},
+			wantUsage:  usage,
+			wantReason: FinishReasonToolUse,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response, err := CollectStream(tt.stream)
+			if err != nil {
+				t.Fatalf("CollectStream failed: %v", err)
+			}
+			if response.UsageMetadata() != tt.wantUsage {
+				t.Errorf("expected usage %v, got %v", tt.wantUsage, response.UsageMetadata())
+			}
+			candidates := response.Candidates()
+			if len(candidates) != 1 {
+				t.Fatalf("expected 1 candidate, got %d", len(candidates))
+			}
+			if got := candidates[0].String(); got != tt.wantText {
+				t.Errorf("expected text %q, got %q", tt.wantText, got)
+			}
+			if got := candidates[0].FinishReason(); got != tt.wantReason {
+				t.Errorf("expected finish reason %q, got %q", tt.wantReason, got)
+			}
+
+			var text string
+			var calls []FunctionCall
+			for _, part := range candidates[0].Parts() {
+				if s, ok := part.AsText(); ok {
+					text += s
+				}
+				if c, ok := part.AsFunctionCalls(); ok {
+					calls = append(calls, c...)
+				}
+			}
+			if text != tt.wantText {
+				t.Errorf("expected text parts %q, got %q", tt.wantText, text)
+			}
+			if !reflect.DeepEqual(calls, tt.wantCalls) {
+				t.Errorf("expected function calls %+v, got %+v", tt.wantCalls, calls)
+			}
+			if response.RequiresToolCall() != (tt.wantCalls != nil) {
+				t.Errorf("expected RequiresToolCall to be %v", tt.wantCalls != nil)
+			}
+		})
+	}
+}
+
+func TestCollectStreamError(t *testing.T) {
+	broken := errors.New("connection reset")
+	stream := func(yield func(ChatResponse, error) bool) {
+		if !yield(&streamChunk{parts: []Part{&fakePart{text: "All pods "}}}, nil) {
+			return
+		}
+		yield(nil, broken)
+	}
+
+	if _, err := CollectStream(stream); !errors.Is(err, broken) {
+		t.Errorf("expected the stream error, got %v", err)
+	}
+}
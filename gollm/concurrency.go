@@ -0,0 +1,47 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gollm
+
+import (
+	"context"
+
+	"golang.org/x/sync/semaphore"
+)
+
+// WithMaxConcurrentRequests limits the number of requests the client has in flight at once.
+// Further requests wait, until their context is done, for a slot to become free.
+// A streaming request holds its slot until the stream has been consumed or its context is
+// done, so a caller that drops a stream must cancel its context.
+func WithMaxConcurrentRequests(n int) Option {
+	return func(o *ClientOptions) {
+		o.MaxConcurrent = n
+	}
+}
+
+// withConcurrencyLimit wraps client so that it has at most opts.MaxConcurrent
+// requests in flight, or returns it unchanged if there is no limit.
+func withConcurrencyLimit(client Client, opts ClientOptions) Client {
+	if opts.MaxConcurrent <= 0 {
+		return client
+	}
+	slots := semaphore.NewWeighted(int64(opts.MaxConcurrent))
+	start := func(ctx context.Context, _ func(error) bool) (*turn, error) {
+		if err := slots.Acquire(ctx, 1); err != nil {
+			return nil, err
+		}
+		return &turn{ctx: ctx, end: func(error) { slots.Release(1) }}, nil
+	}
+	return decorateClient(client, start, func() turnStarter { return start })
+}
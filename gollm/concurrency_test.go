@@ -0,0 +1,116 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gollm
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// slowChat is a Chat whose Send takes a while, and which tracks how many Sends are in flight.
+type slowChat struct {
+	fakeChat
+
+	inFlight    atomic.Int32
+	maxInFlight atomic.Int32
+}
+
+func (c *slowChat) Send(ctx context.Context, contents ...any) (ChatResponse, error) {
+	n := c.inFlight.Add(1)
+	defer c.inFlight.Add(-1)
+	for {
+		highest := c.maxInFlight.Load()
+		if n <= highest || c.maxInFlight.CompareAndSwap(highest, n) {
+			break
+		}
+	}
+	time.Sleep(10 * time.Millisecond)
+	return nil, nil
+}
+
+// slowChatClient is a Client whose chats share the given slowChat.
+type slowChatClient struct {
+	Client
+
+	chat *slowChat
+}
+
+func (c *slowChatClient) StartChat(systemPrompt, model string) Chat {
+	return c.chat
+}
+
+func TestMaxConcurrentRequests(t *testing.T) {
+	const limit = 3
+	underlying := &slowChat{}
+	client := withConcurrencyLimit(&slowChatClient{chat: underlying}, ClientOptions{MaxConcurrent: limit})
+
+	var wg sync.WaitGroup
+	for range 4 * limit {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			chat := client.StartChat("", "model")
+			if _, err := chat.Send(context.Background(), "hello"); err != nil {
+				t.Errorf("Send failed: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := underlying.maxInFlight.Load(); got > limit {
+		t.Errorf("expected at most %d requests in flight, got %d", limit, got)
+	}
+}
+
+func TestMaxConcurrentRequestsHonorsContext(t *testing.T) {
+	client := withConcurrencyLimit(&fakeChatClient{chat: &fakeChat{}}, ClientOptions{MaxConcurrent: 1})
+	chat := client.StartChat("", "model")
+
+	// An unconsumed stream holds the only slot.
+	stream, err := chat.SendStreaming(context.Background(), "hello")
+	if err != nil {
+		t.Fatalf("SendStreaming failed: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := chat.Send(ctx, "hello"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected Send to give up waiting for a slot, got %v", err)
+	}
+
+	for range stream {
+	}
+	if _, err := chat.Send(context.Background(), "hello"); err != nil {
+		t.Fatalf("expected the slot to be released once the stream was consumed, got %v", err)
+	}
+}
+
+func TestMaxConcurrentRequestsReleasesCancelledStream(t *testing.T) {
+	chat := withConcurrencyLimit(&fakeChatClient{chat: &fakeChat{}}, ClientOptions{MaxConcurrent: 1}).StartChat("", "model")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	dropStream(t, ctx, chat)
+	cancel()
+
+	sendCtx, sendCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer sendCancel()
+	if _, err := chat.Send(sendCtx, "hello"); err != nil {
+		t.Fatalf("expected the slot of the cancelled stream to be released, got %v", err)
+	}
+}
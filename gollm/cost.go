@@ -0,0 +1,79 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gollm
+
+import "maps"
+
+// CostCalculator computes the cost of the usage of a request.
+type CostCalculator interface {
+	// Calculate fills in the costs of usage, for a request to model of provider.
+	// It returns false, leaving usage unchanged, if the model's pricing is not known.
+	Calculate(provider, model string, usage *Usage) bool
+}
+
+// WithCostCalculator fills in, with calculator, the costs of the usage passed to the
+// usage callbacks when the provider has not reported them.
+func WithCostCalculator(calculator CostCalculator) Option {
+	return func(o *ClientOptions) {
+		o.CostCalculator = calculator
+	}
+}
+
+// PricingTable is a CostCalculator backed by the prices of models, keyed by provider and model.
+// Bedrock models can be priced by their foundation model ID, which also prices their inference profiles.
+type PricingTable map[string]map[string]ModelPricing
+
+var _ CostCalculator = PricingTable{}
+
+// Calculate implements CostCalculator.
+func (t PricingTable) Calculate(provider, model string, usage *Usage) bool {
+	pricing, ok := t[provider][model]
+	if !ok {
+		pricing, ok = t[provider][stripInferenceProfilePrefix(model)]
+	}
+	if !ok {
+		return false
+	}
+	usage.applyPricing(pricing)
+	return true
+}
+
+// DefaultPricingTable returns the on-demand prices of commonly used models.
+// The table is a copy, which can be edited before it is passed to WithCostCalculator.
+func DefaultPricingTable() PricingTable {
+	return PricingTable{
+		"bedrock": maps.Clone(bedrockModelPricing),
+		"anthropic": {
+			"claude-opus-4-1-20250805":   {InputPerMillionTokens: 15, OutputPerMillionTokens: 75},
+			"claude-opus-4-20250514":     {InputPerMillionTokens: 15, OutputPerMillionTokens: 75},
+			"claude-sonnet-4-20250514":   {InputPerMillionTokens: 3, OutputPerMillionTokens: 15},
+			"claude-3-7-sonnet-20250219": {InputPerMillionTokens: 3, OutputPerMillionTokens: 15},
+			"claude-3-5-haiku-20241022":  {InputPerMillionTokens: 0.8, OutputPerMillionTokens: 4},
+		},
+		"openai": {
+			"gpt-4.1":      {InputPerMillionTokens: 2, OutputPerMillionTokens: 8},
+			"gpt-4.1-mini": {InputPerMillionTokens: 0.4, OutputPerMillionTokens: 1.6},
+			"gpt-4o":       {InputPerMillionTokens: 2.5, OutputPerMillionTokens: 10},
+			"gpt-4o-mini":  {InputPerMillionTokens: 0.15, OutputPerMillionTokens: 0.6},
+			"o3":           {InputPerMillionTokens: 2, OutputPerMillionTokens: 8},
+			"o4-mini":      {InputPerMillionTokens: 1.1, OutputPerMillionTokens: 4.4},
+		},
+		"gemini": {
+			"gemini-2.5-pro":        {InputPerMillionTokens: 1.25, OutputPerMillionTokens: 10},
+			"gemini-2.5-flash":      {InputPerMillionTokens: 0.3, OutputPerMillionTokens: 2.5},
+			"gemini-2.5-flash-lite": {InputPerMillionTokens: 0.1, OutputPerMillionTokens: 0.4},
+		},
+	}
+}
@@ -0,0 +1,129 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gollm
+
+import (
+	"context"
+	"math"
+	"testing"
+)
+
+func TestPricingTableCalculate(t *testing.T) {
+	table := DefaultPricingTable()
+	table["openai"]["my-fine-tune"] = ModelPricing{InputPerMillionTokens: 1, OutputPerMillionTokens: 2}
+
+	tests := []struct {
+		provider string
+		model    string
+		want     float64
+		wantOK   bool
+	}{
+		{provider: "bedrock", model: "anthropic.claude-sonnet-4-20250514-v1:0", want: 0.003 + 0.0075, wantOK: true},
+		{provider: "bedrock", model: "eu.amazon.nova-pro-v1:0", want: 0.0008 + 0.0016, wantOK: true},
+		{provider: "anthropic", model: "claude-3-5-haiku-20241022", want: 0.0008 + 0.002, wantOK: true},
+		{provider: "openai", model: "gpt-4o-mini", want: 0.00015 + 0.0003, wantOK: true},
+		{provider: "openai", model: "my-fine-tune", want: 0.001 + 0.001, wantOK: true},
+		{provider: "gemini", model: "gemini-2.5-pro", want: 0.00125 + 0.005, wantOK: true},
+		{provider: "openai", model: "unknown-model"},
+		{provider: "ollama", model: "llama3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.provider+"/"+tt.model, func(t *testing.T) {
+			usage := &Usage{InputTokens: 1000, OutputTokens: 500, TotalTokens: 1500}
+			if ok := table.Calculate(tt.provider, tt.model, usage); ok != tt.wantOK {
+				t.Fatalf("expected Calculate to return %v, got %v", tt.wantOK, ok)
+			}
+			if math.Abs(usage.TotalCost-tt.want) > 1e-12 {
+				t.Errorf("expected total cost %v, got %v", tt.want, usage.TotalCost)
+			}
+			if math.Abs(usage.InputCost+usage.OutputCost-usage.TotalCost) > 1e-12 {
+				t.Errorf("expected input and output costs to add up to the total, got %+v", usage)
+			}
+		})
+	}
+
+	// Editing the returned table does not change the defaults.
+	if _, ok := DefaultPricingTable()["openai"]["my-fine-tune"]; ok {
+		t.Error("expected DefaultPricingTable to return a copy")
+	}
+}
+
+// usageCompletionClient is a Client whose completions report the given usage.
+type usageCompletionClient struct {
+	Client
+
+	usage Usage
+}
+
+func (c *usageCompletionClient) Name() string {
+	return "openai"
+}
+
+func (c *usageCompletionClient) GenerateCompletion(ctx context.Context, req *CompletionRequest) (CompletionResponse, error) {
+	usage := c.usage
+	return &usageCompletionResponse{usage: &usage}, nil
+}
+
+type usageCompletionResponse struct {
+	usage *Usage
+}
+
+func (r *usageCompletionResponse) Response() string   { return "" }
+func (r *usageCompletionResponse) UsageMetadata() any { return r.usage }
+
+func TestCostCalculatorFillsUsageCallbacks(t *testing.T) {
+	tests := []struct {
+		name  string
+		usage Usage
+		want  float64
+	}{
+		{
+			name:  "cost left zero",
+			usage: Usage{InputTokens: 1_000_000, OutputTokens: 1_000_000},
+			want:  2.5 + 10,
+		},
+		{
+			name:  "cost reported",
+			usage: Usage{InputTokens: 1_000_000, OutputTokens: 1_000_000, TotalCost: 1},
+			want:  1,
+		},
+		{
+			name:  "model reported",
+			usage: Usage{InputTokens: 1_000_000, OutputTokens: 1_000_000, Model: "gpt-4o-mini"},
+			want:  0.15 + 0.6,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got *Usage
+			var opts ClientOptions
+			WithUsageCallback(func(info RequestInfo, usage *Usage) { got = usage })(&opts)
+			WithCostCalculator(DefaultPricingTable())(&opts)
+			client := observeClient(&usageCompletionClient{usage: tt.usage}, opts)
+
+			if _, err := client.GenerateCompletion(context.Background(), &CompletionRequest{Model: "gpt-4o"}); err != nil {
+				t.Fatalf("GenerateCompletion failed: %v", err)
+			}
+			if got == nil {
+				t.Fatal("expected the usage callback to be called")
+			}
+			if math.Abs(got.TotalCost-tt.want) > 1e-9 {
+				t.Errorf("expected total cost %v, got %v", tt.want, got.TotalCost)
+			}
+		})
+	}
+}
@@ -0,0 +1,200 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gollm
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sync"
+)
+
+// errStreamAbandoned ends the turn of a stream that was garbage collected without being
+// consumed, while its context was still live. It wraps context.Canceled since, like a
+// cancelled request, it says nothing about the provider.
+var errStreamAbandoned = fmt.Errorf("stream was dropped without being consumed: %w", context.Canceled)
+
+// turn is a request made through a decorated client or chat, from when it is sent
+// until its response, or its stream, is over.
+type turn struct {
+	// ctx is the context the request is sent with.
+	ctx context.Context
+	// observe, if set, is called with each response, or each streamed response, and its
+	// error, and returns the error passed on to the caller.
+	observe func(response any, err error) error
+	// end, if set, is called once the request is over, with its last error. The turn of a
+	// stream ends when the stream has been consumed or its context is done, whichever is first.
+	end func(err error)
+
+	once sync.Once
+}
+
+func (t *turn) observed(response any, err error) error {
+	if t.observe == nil {
+		return err
+	}
+	return t.observe(response, err)
+}
+
+func (t *turn) finish(err error) {
+	t.once.Do(func() {
+		if t.end != nil {
+			t.end(err)
+		}
+	})
+}
+
+// turnStarter starts the turn of a request made with ctx, or returns an error to fail
+// the request without sending it. isRetryable reports whether an error of the request
+// is retryable.
+type turnStarter func(ctx context.Context, isRetryable func(error) bool) (*turn, error)
+
+// sendTurn makes a request with send, as a turn started by start.
+func sendTurn[T any](ctx context.Context, start turnStarter, isRetryable func(error) bool, send func(context.Context) (T, error)) (T, error) {
+	t, err := start(ctx, isRetryable)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	response, err := send(t.ctx)
+	err = t.observed(response, err)
+	t.finish(err)
+	return response, err
+}
+
+// streamHandle is referenced by the stream returned by streamTurn alone, so that it
+// becomes unreachable, and its cleanup ends the turn, if the stream is dropped.
+// The cleanup is only a backstop, as it runs whenever the garbage collector gets to it.
+type streamHandle struct {
+	turn *turn
+}
+
+// streamTurn opens a stream with open, as a turn started by start. The turn lasts until
+// the stream has been consumed or the context of the request is done, so callers that
+// drop a stream must cancel its context. As a last resort, the turn of a dropped stream
+// ends when the stream is garbage collected.
+func streamTurn[T any, I ~func(yield func(T, error) bool)](ctx context.Context, start turnStarter, isRetryable func(error) bool, open func(context.Context) (I, error)) (I, error) {
+	t, err := start(ctx, isRetryable)
+	if err != nil {
+		return nil, err
+	}
+	stream, err := open(t.ctx)
+	if err != nil {
+		var zero T
+		err = t.observed(zero, err)
+		t.finish(err)
+		return nil, err
+	}
+
+	stop := context.AfterFunc(t.ctx, func() { t.finish(context.Cause(t.ctx)) })
+	handle := &streamHandle{turn: t}
+	runtime.AddCleanup(handle, func(t *turn) { t.finish(errStreamAbandoned) }, t)
+	return func(yield func(T, error) bool) {
+		var lastErr error
+		defer func() {
+			stop()
+			handle.turn.finish(lastErr)
+		}()
+		for response, err := range stream {
+			if err = t.observed(response, err); err != nil {
+				lastErr = err
+			}
+			if !yield(response, err) {
+				return
+			}
+		}
+	}, nil
+}
+
+// decoratedClient is a Client whose chats, and optionally completions, make their
+// requests as turns, for decorators such as the concurrency limit or the circuit breaker.
+type decoratedClient struct {
+	Client
+
+	// completions starts the turns of completions. Completions are passed through if it is nil.
+	completions turnStarter
+	// newChat returns the turnStarter of a new chat.
+	newChat func() turnStarter
+}
+
+// decorateClient wraps client so that its chats make their requests through the
+// turnStarter returned by newChat, and its completions through completions, if set.
+func decorateClient(client Client, completions turnStarter, newChat func() turnStarter) Client {
+	return &decoratedClient{
+		Client:      client,
+		completions: completions,
+		newChat:     newChat,
+	}
+}
+
+func (c *decoratedClient) GenerateCompletion(ctx context.Context, req *CompletionRequest) (CompletionResponse, error) {
+	if c.completions == nil {
+		return c.Client.GenerateCompletion(ctx, req)
+	}
+	return sendTurn(ctx, c.completions, DefaultIsRetryableError, func(ctx context.Context) (CompletionResponse, error) {
+		return c.Client.GenerateCompletion(ctx, req)
+	})
+}
+
+func (c *decoratedClient) GenerateCompletionStream(ctx context.Context, req *CompletionRequest) (CompletionResponseIterator, error) {
+	if c.completions == nil {
+		return c.Client.GenerateCompletionStream(ctx, req)
+	}
+	return streamTurn(ctx, c.completions, DefaultIsRetryableError, func(ctx context.Context) (CompletionResponseIterator, error) {
+		return c.Client.GenerateCompletionStream(ctx, req)
+	})
+}
+
+func (c *decoratedClient) HealthCheck(ctx context.Context) error {
+	return HealthCheck(ctx, c.Client)
+}
+
+func (c *decoratedClient) StartChat(systemPrompt, model string) Chat {
+	return c.wrapChat(c.Client.StartChat(systemPrompt, model))
+}
+
+func (c *decoratedClient) StartChatWithOptions(systemPrompt, model string, opts ...ChatOption) (Chat, error) {
+	underlying, err := StartChatWithOptions(c.Client, systemPrompt, model, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return c.wrapChat(underlying), nil
+}
+
+func (c *decoratedClient) wrapChat(underlying Chat) Chat {
+	return preserveSerializable(&decoratedChat{
+		Chat:  underlying,
+		start: c.newChat(),
+	}, underlying)
+}
+
+// decoratedChat is a Chat that makes its requests as turns started by start.
+type decoratedChat struct {
+	Chat
+
+	start turnStarter
+}
+
+func (c *decoratedChat) Send(ctx context.Context, contents ...any) (ChatResponse, error) {
+	return sendTurn(ctx, c.start, c.Chat.IsRetryableError, func(ctx context.Context) (ChatResponse, error) {
+		return c.Chat.Send(ctx, contents...)
+	})
+}
+
+func (c *decoratedChat) SendStreaming(ctx context.Context, contents ...any) (ChatResponseIterator, error) {
+	return streamTurn(ctx, c.start, c.Chat.IsRetryableError, func(ctx context.Context) (ChatResponseIterator, error) {
+		return c.Chat.SendStreaming(ctx, contents...)
+	})
+}
@@ -0,0 +1,56 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gollm
+
+import (
+	"context"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// waitFor polls done until it reports true, failing the test after a few seconds.
+func waitFor(t *testing.T, done func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !done() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the stream's turn to end")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// dropStream opens a stream on chat with ctx, and drops it without consuming it.
+func dropStream(t *testing.T, ctx context.Context, chat Chat) {
+	t.Helper()
+	if _, err := chat.SendStreaming(ctx, "dropped"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestDroppedStreamCleanup(t *testing.T) {
+	chat := withConcurrencyLimit(&fakeChatClient{chat: &fakeChat{}}, ClientOptions{MaxConcurrent: 1}).StartChat("", "model")
+	// The stream's context is never done, so only its cleanup can free the slot.
+	dropStream(t, context.Background(), chat)
+
+	waitFor(t, func() bool {
+		runtime.GC()
+		ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+		defer cancel()
+		_, err := chat.Send(ctx, "hello")
+		return err == nil
+	})
+}
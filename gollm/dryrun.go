@@ -0,0 +1,47 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gollm
+
+import "encoding/json"
+
+// DryRunRequest describes a request that was not sent because the client is in dry-run mode.
+// It is the UsageMetadata of the response returned in its place.
+type DryRunRequest struct {
+	Provider string `json:"provider"`
+	Model    string `json:"model"`
+	// Request is the serialized request.
+	Request json.RawMessage `json:"request"`
+	// EstimatedInputTokens is a rough estimate of the input tokens the request would use.
+	EstimatedInputTokens int `json:"estimatedInputTokens"`
+}
+
+// dryRunResponse is the ChatResponse returned in dry-run mode. It has no candidates.
+type dryRunResponse struct {
+	request *DryRunRequest
+}
+
+var _ ChatResponse = &dryRunResponse{}
+
+func (r *dryRunResponse) UsageMetadata() any {
+	return r.request
+}
+
+func (r *dryRunResponse) Candidates() []Candidate {
+	return nil
+}
+
+func (r *dryRunResponse) RequiresToolCall() bool {
+	return false
+}
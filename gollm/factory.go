@@ -19,11 +19,16 @@ import (
 	"crypto/tls"
 	"errors"
 	"fmt"
+	"io"
+	"log/slog"
+	"math"
 	"math/rand/v2"
 	"net"
 	"net/http"
 	"net/url"
 	"os"
+	"runtime/debug"
+	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -53,6 +58,60 @@ func (r *registry) listProviders() []string {
 type ClientOptions struct {
 	URL           *url.URL
 	SkipVerifySSL bool
+	// ExtraHeaders are added to every request made by HTTP-based providers.
+	ExtraHeaders map[string]string
+	// ProxyURL, if set, is the proxy used by HTTP-based providers and Bedrock, in place of
+	// the one configured by the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables.
+	ProxyURL string
+	// UserAgent identifies kubectl-ai in the requests made by the providers.
+	UserAgent string
+	// Region is the cloud region to use, for providers that are regional.
+	Region string
+	// Bedrock holds options that only apply to the Bedrock provider.
+	Bedrock BedrockOptions
+	// Interceptors and UsageCallbacks observe the requests made by the client.
+	Interceptors   []Interceptor
+	UsageCallbacks []UsageCallback
+	// CostCalculator, if set, fills in the costs of the usage passed to UsageCallbacks
+	// when the provider has not reported them.
+	CostCalculator CostCalculator
+	// CircuitBreaker, if set, stops requests to the provider while it is failing.
+	CircuitBreaker *CircuitBreakerConfig
+	// MaxTotalTokens and MaxTotalCost, if positive, cap the usage of every chat.
+	MaxTotalTokens int
+	MaxTotalCost   float64
+	// TurnTimeout, if positive, bounds the time each chat turn may take.
+	TurnTimeout time.Duration
+	// MaxConcurrent, if positive, limits the number of requests in flight at once.
+	MaxConcurrent int
+	// AdaptiveLimiter, if set, limits the number of requests in flight, lowering the
+	// limit while the provider throttles requests.
+	AdaptiveLimiter *AdaptiveLimiter
+	// StrictSchema, if set, fails responses that do not conform to the response schema.
+	StrictSchema bool
+	// ResponseValidator, if set, fails the completed responses it rejects, after generating
+	// them once more if RegenerateOnValidationError is set.
+	ResponseValidator           ResponseValidator
+	RegenerateOnValidationError bool
+	// DefaultSystemPrompt, if set, is prepended to the system prompt of every chat.
+	DefaultSystemPrompt string
+	// TranscriptWriter, if set, receives the messages of every chat as JSON lines.
+	TranscriptWriter io.Writer
+	// Tokenizer, if set, counts tokens in place of HeuristicTokenizer.
+	Tokenizer Tokenizer
+	// CandidateCount, if greater than one, is the number of candidates to request per response.
+	CandidateCount int
+	// MaxToolResultBytes, if positive, truncates the JSON of function call results to this size.
+	MaxToolResultBytes int
+	// RetryableStatusCodes are HTTP status codes that chats retry in addition to the default ones.
+	RetryableStatusCodes []int
+	// RetryInitialBackoff and RetryMaxBackoff bound the backoff between the retries of providers
+	// that retry failed requests themselves, such as Bedrock. They default to 200ms and 30s.
+	RetryInitialBackoff time.Duration
+	RetryMaxBackoff     time.Duration
+	// Logger, if set, receives the diagnostic events of providers that support structured logging.
+	// They log to klog otherwise.
+	Logger *slog.Logger
 	// Extend with more options as needed
 }
 
@@ -66,6 +125,280 @@ func WithSkipVerifySSL() Option {
 	}
 }
 
+// WithHTTPHeaders adds headers to every request made by HTTP-based providers,
+// for example a tenant header required by a gateway. The Authorization and
+// Content-Type headers are set by the provider and cannot be overridden.
+func WithHTTPHeaders(headers map[string]string) Option {
+	return func(o *ClientOptions) {
+		if o.ExtraHeaders == nil {
+			o.ExtraHeaders = make(map[string]string, len(headers))
+		}
+		for k, v := range headers {
+			o.ExtraHeaders[k] = v
+		}
+	}
+}
+
+// WithProxy routes the requests of the providers through the proxy at proxyURL, for
+// example http://proxy.example.com:3128. Without it, the HTTP_PROXY, HTTPS_PROXY
+// and NO_PROXY environment variables are honored.
+func WithProxy(proxyURL string) Option {
+	return func(o *ClientOptions) {
+		o.ProxyURL = proxyURL
+	}
+}
+
+// proxyFunc returns the function selecting the proxy of a request for opts,
+// or an error if opts.ProxyURL is not a valid proxy URL.
+func proxyFunc(opts ClientOptions) (func(*http.Request) (*url.URL, error), error) {
+	if opts.ProxyURL == "" {
+		return http.ProxyFromEnvironment, nil
+	}
+	u, err := url.Parse(opts.ProxyURL)
+	if err != nil {
+		return nil, fmt.Errorf("parsing proxy URL %q: %w", opts.ProxyURL, err)
+	}
+	if !slices.Contains([]string{"http", "https", "socks5"}, u.Scheme) || u.Host == "" {
+		return nil, fmt.Errorf("invalid proxy URL %q: expected http://, https:// or socks5:// followed by a host", opts.ProxyURL)
+	}
+	return http.ProxyURL(u), nil
+}
+
+// WithUserAgent sets the User-Agent of the requests made by the providers, for example
+// so that a proxy can identify kubectl-ai traffic. It defaults to kubectl-ai/<version>.
+// AWS providers append it to the User-Agent of the AWS SDK instead of replacing it.
+func WithUserAgent(userAgent string) Option {
+	return func(o *ClientOptions) {
+		o.UserAgent = userAgent
+	}
+}
+
+// defaultUserAgent returns kubectl-ai/<version>, where the version is that of
+// the main module of the running binary.
+func defaultUserAgent() string {
+	version := "dev"
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		version = info.Main.Version
+	}
+	return "kubectl-ai/" + version
+}
+
+// WithCandidateCount asks for n alternative candidates in every chat response, for
+// providers that can generate several, such as OpenAI. The first candidate is the one
+// kept in the chat history. Bedrock generates a single candidate, and rejects n > 1.
+func WithCandidateCount(n int) Option {
+	return func(o *ClientOptions) {
+		o.CandidateCount = n
+	}
+}
+
+// WithLogger routes the diagnostic events of the client to logger, instead of klog.
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *ClientOptions) {
+		o.Logger = logger
+	}
+}
+
+// WithRegion sets the cloud region used by regional providers such as Bedrock.
+func WithRegion(region string) Option {
+	return func(o *ClientOptions) {
+		o.Region = region
+	}
+}
+
+// WithBedrockRegionFromInstanceMetadata makes the Bedrock client get its region from the EC2
+// instance metadata service when no region is configured, for example on EKS.
+// It is opt-in, so that clients outside AWS do not wait for the service.
+func WithBedrockRegionFromInstanceMetadata() Option {
+	return func(o *ClientOptions) {
+		o.Bedrock.RegionFromInstanceMetadata = true
+	}
+}
+
+// WithBedrockAutoInferenceProfile prefixes bare Bedrock model IDs with the
+// cross-region inference profile prefix of the configured region.
+func WithBedrockAutoInferenceProfile() Option {
+	return func(o *ClientOptions) {
+		o.Bedrock.AutoInferenceProfile = true
+	}
+}
+
+// WithBedrockFailFastOnNoCredentials makes the Bedrock client fail at construction
+// if no AWS credentials can be resolved, instead of on the first request.
+func WithBedrockFailFastOnNoCredentials() Option {
+	return func(o *ClientOptions) {
+		o.Bedrock.FailFastOnNoCredentials = true
+	}
+}
+
+// WithBedrockModelsCacheTTL sets how long the Bedrock client caches ListModels results.
+func WithBedrockModelsCacheTTL(ttl time.Duration) Option {
+	return func(o *ClientOptions) {
+		o.Bedrock.ModelsCacheTTL = ttl
+	}
+}
+
+// WithBedrockSystemPromptEnhancer replaces the function the Bedrock client uses to
+// rewrite system prompts. Pass a function that returns its input to disable enhancement.
+func WithBedrockSystemPromptEnhancer(enhancer SystemPromptEnhancer) Option {
+	return func(o *ClientOptions) {
+		o.Bedrock.SystemPromptEnhancer = enhancer
+	}
+}
+
+// WithBedrockOutputNormalizer sets the function the Bedrock client uses to clean up the
+// text of responses to tool-use shim prompts, such as DefaultBedrockOutputNormalizer.
+func WithBedrockOutputNormalizer(normalizer OutputNormalizer) Option {
+	return func(o *ClientOptions) {
+		o.Bedrock.OutputNormalizer = normalizer
+	}
+}
+
+// WithBedrockModelFallback sets the models that the Bedrock client falls back to, in order,
+// when a request to the chat's model fails because the model is throttled or unavailable.
+func WithBedrockModelFallback(models []string) Option {
+	return func(o *ClientOptions) {
+		o.Bedrock.ModelFallback = models
+	}
+}
+
+// WithBedrockDryRun makes Bedrock chats return a description of each request, as a *DryRunRequest
+// in the response's UsageMetadata, instead of sending it.
+func WithBedrockDryRun() Option {
+	return func(o *ClientOptions) {
+		o.Bedrock.DryRun = true
+	}
+}
+
+// WithBedrockAdditionalModelRequestFields passes model-specific inference parameters,
+// such as {"top_k": 50} for Claude models, with every Bedrock request.
+func WithBedrockAdditionalModelRequestFields(fields map[string]any) Option {
+	return func(o *ClientOptions) {
+		o.Bedrock.AdditionalModelRequestFields = fields
+	}
+}
+
+// WithBedrockMaxTokens sets the maximum number of tokens generated by a Bedrock response,
+// in place of the default of the model.
+func WithBedrockMaxTokens(maxTokens int32) Option {
+	return func(o *ClientOptions) {
+		o.Bedrock.MaxTokens = maxTokens
+	}
+}
+
+// WithBedrockTemperature sets the temperature of Bedrock requests, between 0 and 1.
+func WithBedrockTemperature(temperature float32) Option {
+	return func(o *ClientOptions) {
+		o.Bedrock.Temperature = &temperature
+	}
+}
+
+// WithBedrockTopP sets the top P of Bedrock requests, between 0 and 1 (below 1 for Cohere models).
+func WithBedrockTopP(topP float32) Option {
+	return func(o *ClientOptions) {
+		o.Bedrock.TopP = &topP
+	}
+}
+
+// WithBedrockTopK limits sampling to the k most likely tokens, for the Bedrock models that support it.
+func WithBedrockTopK(k int32) Option {
+	return func(o *ClientOptions) {
+		o.Bedrock.TopK = k
+	}
+}
+
+// WithBedrockCredentialsRefresh makes Bedrock load its AWS configuration again when its credentials
+// have expired, and retry the request once, so that long-running agents keep working after
+// their SSO or assumed-role credentials are renewed.
+func WithBedrockCredentialsRefresh() Option {
+	return func(o *ClientOptions) {
+		o.Bedrock.CredentialsRefresh = true
+	}
+}
+
+// WithBedrockStreamRetry makes Bedrock resume a streamed response that fails midway with a
+// retryable error, by asking the model to continue from the text it has streamed so far.
+func WithBedrockStreamRetry(enabled bool) Option {
+	return func(o *ClientOptions) {
+		o.Bedrock.StreamRetry = enabled
+	}
+}
+
+// WithBedrockStrictModel makes Bedrock chats started with a model that is not known to work
+// fail on their first request, so that a typo in the model is not masked. By default, such
+// chats use the model anyway, with a warning.
+func WithBedrockStrictModel() Option {
+	return func(o *ClientOptions) {
+		o.Bedrock.StrictModel = true
+	}
+}
+
+// WithBedrockHistoryLimit makes Bedrock chats keep only about the most recent n messages of their
+// conversation, dropping the oldest before each request. The system prompt is always kept,
+// and tool calls are never separated from their results.
+func WithBedrockHistoryLimit(n int) Option {
+	return func(o *ClientOptions) {
+		o.Bedrock.MaxHistoryMessages = n
+	}
+}
+
+// WithBedrockAutoContinue makes Bedrock continue responses that are cut off at the token limit,
+// returning each as a single, complete response. See BedrockOptions.AutoContinue.
+func WithBedrockAutoContinue() Option {
+	return func(o *ClientOptions) {
+		o.Bedrock.AutoContinue = true
+	}
+}
+
+// WithBedrockRegions makes Bedrock use the first of regions, and retry each turn that fails there with
+// a region-level error, such as Bedrock being unavailable, in each of the other regions in turn.
+func WithBedrockRegions(regions []string) Option {
+	return func(o *ClientOptions) {
+		if len(regions) == 0 {
+			return
+		}
+		o.Region = regions[0]
+		o.Bedrock.FailoverRegions = regions[1:]
+	}
+}
+
+// WithBedrockEndpointOverride makes Bedrock send its requests to endpointURL, such as a VPC
+// interface endpoint or a FIPS endpoint, instead of the default endpoint of its region.
+func WithBedrockEndpointOverride(endpointURL string) Option {
+	return func(o *ClientOptions) {
+		o.Bedrock.EndpointURL = endpointURL
+	}
+}
+
+// WithBedrockConfigLoadTimeout bounds the time Bedrock takes to load the AWS configuration when the
+// client is created, in place of the default 30 seconds. A negative timeout disables the bound.
+func WithBedrockConfigLoadTimeout(timeout time.Duration) Option {
+	return func(o *ClientOptions) {
+		o.Bedrock.ConfigLoadTimeout = timeout
+	}
+}
+
+// WithBedrockRequestMetadata tags every Bedrock request with the given key-value pairs, for example
+// to attribute requests to a tenant or a feature when analyzing model invocation logs.
+func WithBedrockRequestMetadata(metadata map[string]string) Option {
+	return func(o *ClientOptions) {
+		if o.Bedrock.RequestMetadata == nil {
+			o.Bedrock.RequestMetadata = make(map[string]string, len(metadata))
+		}
+		for k, v := range metadata {
+			o.Bedrock.RequestMetadata[k] = v
+		}
+	}
+}
+
+// WithBedrockToolResultFormat sets whether Bedrock sends function call results to the model as
+// JSON documents, the default, or as text, for models that only accept text tool results.
+func WithBedrockToolResultFormat(format ToolResultFormat) Option {
+	return func(o *ClientOptions) {
+		o.Bedrock.ToolResultFormat = format
+	}
+}
+
 type FactoryFunc func(ctx context.Context, opts ClientOptions) (Client, error)
 
 func RegisterProvider(id string, factoryFunc FactoryFunc) error {
@@ -108,7 +441,8 @@ func (r *registry) NewClient(ctx context.Context, providerID string, opts ...Opt
 
 	// Build ClientOptions
 	clientOpts := ClientOptions{
-		URL: u,
+		URL:       u,
+		UserAgent: defaultUserAgent(),
 	}
 	// Support environment variable override for SkipVerifySSL
 	if v := os.Getenv("LLM_SKIP_VERIFY_SSL"); v == "1" || strings.ToLower(v) == "true" {
@@ -117,8 +451,25 @@ func (r *registry) NewClient(ctx context.Context, providerID string, opts ...Opt
 	for _, opt := range opts {
 		opt(&clientOpts)
 	}
+	if _, err := proxyFunc(clientOpts); err != nil {
+		return nil, err
+	}
 
-	return factoryFunc(ctx, clientOpts)
+	client, err := factoryFunc(ctx, clientOpts)
+	if err != nil {
+		return nil, err
+	}
+	client = withRetryableStatusCodes(client, clientOpts)
+	client = withTranscript(client, clientOpts)
+	client = withDefaultSystemPrompt(client, clientOpts)
+	client = withStrictSchema(client, clientOpts)
+	client = withResponseValidator(client, clientOpts)
+	client = withConcurrencyLimit(client, clientOpts)
+	client = withAdaptiveLimiter(client, clientOpts)
+	client = withCircuitBreaker(client, clientOpts)
+	client = withBudget(client, clientOpts)
+	client = withTurnTimeout(client, clientOpts)
+	return observeClient(client, clientOpts), nil
 }
 
 /*
@@ -190,40 +541,123 @@ func DefaultIsRetryableError(err error) bool {
 	return false
 }
 
-// createCustomHTTPClient returns an *http.Client that optionally skips SSL certificate verification.
+// createCustomHTTPClient returns an *http.Client that optionally skips SSL certificate verification,
+// uses the proxy of opts, and adds the User-Agent and extra headers of opts to every request.
 // This is shared by all providers that need custom HTTP transport.
-func createCustomHTTPClient(skipVerify bool) *http.Client {
-	if !skipVerify {
+func createCustomHTTPClient(opts ClientOptions) *http.Client {
+	if !opts.SkipVerifySSL && opts.ProxyURL == "" && len(opts.ExtraHeaders) == 0 && opts.UserAgent == "" {
 		return http.DefaultClient
 	}
-	return &http.Client{
-		Transport: &http.Transport{
-			Proxy: http.ProxyFromEnvironment,
-			TLSClientConfig: &tls.Config{
+	var transport http.RoundTripper = http.DefaultTransport
+	if opts.SkipVerifySSL || opts.ProxyURL != "" {
+		custom := http.DefaultTransport.(*http.Transport).Clone()
+		if opts.SkipVerifySSL {
+			custom.TLSClientConfig = &tls.Config{
 				InsecureSkipVerify: true,
-			},
-		},
+			}
+		}
+		proxy, err := proxyFunc(opts)
+		if err != nil {
+			// NewClient rejects invalid proxy URLs; fail every request rather than bypass the proxy
+			proxy = func(*http.Request) (*url.URL, error) { return nil, err }
+		}
+		custom.Proxy = proxy
+		transport = custom
+	}
+	if len(opts.ExtraHeaders) != 0 || opts.UserAgent != "" {
+		transport = &headerTransport{base: transport, userAgent: opts.UserAgent, headers: opts.ExtraHeaders}
+	}
+	return &http.Client{Transport: transport}
+}
+
+// protectedHeaders are set by the providers themselves, and are never replaced by extra headers.
+var protectedHeaders = []string{"Authorization", "Content-Type"}
+
+// headerTransport is an http.RoundTripper that adds headers to every request,
+// and the request ID and idempotency key of its context, if any. A User-Agent in headers takes
+// precedence over userAgent.
+type headerTransport struct {
+	base      http.RoundTripper
+	userAgent string
+	headers   map[string]string
+}
+
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	if t.userAgent != "" {
+		req.Header.Set("User-Agent", t.userAgent)
+	}
+	for k, v := range t.headers {
+		if slices.Contains(protectedHeaders, http.CanonicalHeaderKey(k)) {
+			continue
+		}
+		req.Header.Set(k, v)
+	}
+	if id, ok := RequestIDFromContext(req.Context()); ok {
+		req.Header.Set(requestIDHeader, id)
+	}
+	if key, ok := IdempotencyKeyFromContext(req.Context()); ok {
+		req.Header.Set(idempotencyKeyHeader, key)
 	}
+	return t.base.RoundTrip(req)
 }
 
 // RetryConfig holds the configuration for the retry mechanism (same as before)
+//
+// A retry replays the model call of a chat turn, and nothing else. Tools are executed by
+// the caller, after the turn that asked for them has returned, and their results are sent
+// back as FunctionCallResult contents of the next turn. A retry of that turn sends the
+// same results again; it does not execute the tools again.
 type RetryConfig struct {
 	MaxAttempts    int
 	InitialBackoff time.Duration
 	MaxBackoff     time.Duration
 	BackoffFactor  float64
 	Jitter         bool
+
+	// RetryIdempotentOnly declares that the chat's turns only make the model call, so
+	// that replaying them is idempotent, as when tools are executed as described above.
+	// Without it, turns that carry a FunctionCallResult are not retried, in case the
+	// chat executes tools as part of the turn.
+	RetryIdempotentOnly bool
+
+	// RetryStreamOpen also retries SendStreaming, but only while opening the stream,
+	// when replaying the request is safe: once the stream has been handed to the
+	// caller, errors are returned as-is. Without it, SendStreaming is not retried.
+	RetryStreamOpen bool
 }
 
+// Default bounds of the backoff between retries. The providers that retry failed requests
+// themselves cap their backoff at defaultRetryMaxBackoff, rather than at the MaxBackoff of
+// DefaultRetryConfig, unless ClientOptions.RetryMaxBackoff is set.
+const (
+	defaultRetryInitialBackoff = 200 * time.Millisecond
+	defaultRetryMaxBackoff     = 30 * time.Second
+)
+
 // DefaultRetryConfig provides sensible defaults (same as before)
 var DefaultRetryConfig = RetryConfig{
 	MaxAttempts:    5,
-	InitialBackoff: 200 * time.Millisecond, // Slightly increased default
+	InitialBackoff: defaultRetryInitialBackoff,
 	MaxBackoff:     10 * time.Second,
 	BackoffFactor:  2.0,
 	Jitter:         true,
 }
 
+// backoff returns the time to wait after the given failed attempt, counting from 1:
+// InitialBackoff, multiplied by BackoffFactor after every attempt, plus up to half
+// as much jitter if enabled. It never exceeds MaxBackoff, if set.
+func (c RetryConfig) backoff(attempt int) time.Duration {
+	backoff := float64(c.InitialBackoff) * math.Pow(max(c.BackoffFactor, 1), float64(attempt-1))
+	if c.Jitter {
+		backoff += rand.Float64() * backoff / 2
+	}
+	if c.MaxBackoff > 0 && backoff > float64(c.MaxBackoff) {
+		return c.MaxBackoff
+	}
+	return time.Duration(backoff)
+}
+
 // Retry executes the provided operation with retries, returning the result and error.
 // It's now generic to handle any return type T.
 func Retry[T any](
@@ -237,10 +671,8 @@ func Retry[T any](
 
 	log := klog.FromContext(ctx)
 
-	backoff := config.InitialBackoff
-
 	for attempt := 1; attempt <= config.MaxAttempts; attempt++ {
-		log.V(2).Info("Retry attempt started", "attempt", attempt, "maxAttempts", config.MaxAttempts, "backoff", backoff)
+		log.V(2).Info("Retry attempt started", "attempt", attempt, "maxAttempts", config.MaxAttempts)
 		result, err := operation(ctx)
 
 		if err == nil {
@@ -271,9 +703,12 @@ func Retry[T any](
 		}
 
 		// Calculate wait time
-		waitTime := backoff
-		if config.Jitter {
-			waitTime += time.Duration(rand.Float64() * float64(backoff) / 2)
+		waitTime := config.backoff(attempt)
+
+		// Don't wait for a retry that the deadline would cut short
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) <= waitTime {
+			log.Info("Not enough time left before the deadline to retry", "attempt", attempt, "waitTime", waitTime)
+			return zero, fmt.Errorf("operation failed after %d attempts, with no time left to retry before the deadline: %w", attempt, lastErr)
 		}
 
 		log.V(2).Info("Waiting before next retry attempt", "waitTime", waitTime, "nextAttempt", attempt+1, "maxAttempts", config.MaxAttempts)
@@ -286,12 +721,6 @@ func Retry[T any](
 			log.Info("Context cancelled while waiting for retry after attempt %d.", "attempt", attempt)
 			return zero, ctx.Err()
 		}
-
-		// Increase backoff
-		backoff = time.Duration(float64(backoff) * config.BackoffFactor)
-		if backoff > config.MaxBackoff {
-			backoff = config.MaxBackoff
-		}
 	}
 
 	// If the loop finished, it means all attempts failed
@@ -300,6 +729,7 @@ func Retry[T any](
 }
 
 // retryChat is a generic decorator that adds retry logic to any Chat implementation.
+// See RetryConfig for what a retry replays.
 type retryChat[C Chat] struct {
 	underlying  Chat // The actual client implementation being wrapped
 	config      RetryConfig
@@ -321,6 +751,13 @@ func NewRetryChat[C Chat](
 
 // Embed implements the Client interface for the retryClient decorator.
 func (rc *retryChat[C]) Send(ctx context.Context, contents ...any) (ChatResponse, error) {
+	if !rc.retries(contents) {
+		return rc.underlying.Send(ctx, contents...)
+	}
+
+	// Every attempt carries the same idempotency key
+	ctx = ensureIdempotencyKey(ctx)
+
 	// Define the operation
 	operation := func(ctx context.Context) (ChatResponse, error) {
 		return rc.underlying.Send(ctx, contents...)
@@ -332,7 +769,32 @@ func (rc *retryChat[C]) Send(ctx context.Context, contents ...any) (ChatResponse
 
 // Embed implements the Client interface for the retryClient decorator.
 func (rc *retryChat[C]) SendStreaming(ctx context.Context, contents ...any) (ChatResponseIterator, error) {
-	return rc.underlying.SendStreaming(ctx, contents...)
+	if !rc.config.RetryStreamOpen || !rc.retries(contents) {
+		return rc.underlying.SendStreaming(ctx, contents...)
+	}
+
+	// Only opening the stream is retried; nothing has been yielded to the caller yet.
+	ctx = ensureIdempotencyKey(ctx)
+	operation := func(ctx context.Context) (ChatResponseIterator, error) {
+		return rc.underlying.SendStreaming(ctx, contents...)
+	}
+
+	return Retry[ChatResponseIterator](ctx, rc.config, rc.underlying.IsRetryableError, operation)
+}
+
+// retries reports whether a turn with the given contents may be retried: turns that carry
+// a FunctionCallResult only may if the config declares that retries are idempotent.
+func (rc *retryChat[C]) retries(contents []any) bool {
+	if rc.config.RetryIdempotentOnly {
+		return true
+	}
+	return !slices.ContainsFunc(contents, func(content any) bool {
+		switch content.(type) {
+		case FunctionCallResult, *FunctionCallResult:
+			return true
+		}
+		return false
+	})
 }
 
 func (rc *retryChat[C]) SetFunctionDefinitions(functionDefinitions []*FunctionDefinition) error {
@@ -346,3 +808,7 @@ func (rc *retryChat[C]) IsRetryableError(err error) bool {
 func (rc *retryChat[C]) Initialize(messages []*api.Message) error {
 	return rc.underlying.Initialize(messages)
 }
+
+func (rc *retryChat[C]) Validate() error {
+	return rc.underlying.Validate()
+}
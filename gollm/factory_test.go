@@ -0,0 +1,306 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gollm
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/api"
+)
+
+// fakeChat is a Chat whose Send and SendStreaming fail with the queued errors
+// before succeeding. It records the contents of every attempt.
+type fakeChat struct {
+	errs     []error
+	attempts [][]any
+}
+
+var _ Chat = &fakeChat{}
+
+func (f *fakeChat) nextErr(contents []any) error {
+	f.attempts = append(f.attempts, contents)
+	if len(f.errs) == 0 {
+		return nil
+	}
+	err := f.errs[0]
+	f.errs = f.errs[1:]
+	return err
+}
+
+func (f *fakeChat) Send(ctx context.Context, contents ...any) (ChatResponse, error) {
+	if err := f.nextErr(contents); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+func (f *fakeChat) SendStreaming(ctx context.Context, contents ...any) (ChatResponseIterator, error) {
+	if err := f.nextErr(contents); err != nil {
+		return nil, err
+	}
+	return singletonChatResponseIterator(nil), nil
+}
+
+func (f *fakeChat) SetFunctionDefinitions(functionDefinitions []*FunctionDefinition) error {
+	return nil
+}
+
+func (f *fakeChat) IsRetryableError(err error) bool {
+	return DefaultIsRetryableError(err)
+}
+
+func (f *fakeChat) Initialize(messages []*api.Message) error {
+	return nil
+}
+
+func (f *fakeChat) Validate() error {
+	return nil
+}
+
+func TestRetryChatFunctionCallResults(t *testing.T) {
+	retryable := &APIError{StatusCode: http.StatusServiceUnavailable, Message: "unavailable"}
+	result := FunctionCallResult{ID: "call-1", Name: "kubectl", Result: map[string]any{"stdout": "pod/nginx created"}}
+
+	sends := []struct {
+		name string
+		send func(ctx context.Context, chat Chat, contents ...any) error
+	}{
+		{
+			name: "Send",
+			send: func(ctx context.Context, chat Chat, contents ...any) error {
+				_, err := chat.Send(ctx, contents...)
+				return err
+			},
+		},
+		{
+			name: "SendStreaming",
+			send: func(ctx context.Context, chat Chat, contents ...any) error {
+				_, err := chat.SendStreaming(ctx, contents...)
+				return err
+			},
+		},
+	}
+	tests := []struct {
+		name         string
+		idempotent   bool
+		contents     []any
+		wantAttempts int
+	}{
+		{name: "text", contents: []any{"hello"}, wantAttempts: 2},
+		{name: "tool result", contents: []any{result}, wantAttempts: 1},
+		{name: "tool result pointer", contents: []any{&result}, wantAttempts: 1},
+		{name: "idempotent tool result", idempotent: true, contents: []any{result}, wantAttempts: 2},
+	}
+
+	for _, send := range sends {
+		for _, tt := range tests {
+			t.Run(send.name+"/"+tt.name, func(t *testing.T) {
+				underlying := &fakeChat{errs: []error{retryable}}
+				chat := NewRetryChat(underlying, RetryConfig{
+					MaxAttempts:         3,
+					BackoffFactor:       1,
+					RetryIdempotentOnly: tt.idempotent,
+					RetryStreamOpen:     true,
+				})
+
+				err := send.send(context.Background(), chat, tt.contents...)
+				if len(underlying.attempts) != tt.wantAttempts {
+					t.Fatalf("expected %d model requests, got %d", tt.wantAttempts, len(underlying.attempts))
+				}
+				if tt.wantAttempts == 1 && !errors.Is(err, retryable) {
+					t.Errorf("expected the error of the only attempt, got %v", err)
+				}
+				if tt.wantAttempts > 1 && err != nil {
+					t.Errorf("expected the retry to succeed, got %v", err)
+				}
+			})
+		}
+	}
+}
+
+func TestRetryChatStreamingWithoutStreamOpen(t *testing.T) {
+	retryable := &APIError{StatusCode: http.StatusServiceUnavailable, Message: "unavailable"}
+	underlying := &fakeChat{errs: []error{retryable}}
+	chat := NewRetryChat(underlying, RetryConfig{MaxAttempts: 3, BackoffFactor: 1})
+
+	if _, err := chat.SendStreaming(context.Background(), "hello"); err == nil {
+		t.Fatal("expected streaming error to be returned without retry")
+	}
+	if len(underlying.attempts) != 1 {
+		t.Errorf("expected 1 model request, got %d", len(underlying.attempts))
+	}
+}
+
+func TestCustomHTTPClientUserAgent(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+		want string
+	}{
+		{
+			name: "user agent",
+			opts: []Option{WithUserAgent("kubectl-ai/v1.2.3")},
+			want: "kubectl-ai/v1.2.3",
+		},
+		{
+			name: "extra header takes precedence",
+			opts: []Option{WithUserAgent("kubectl-ai/v1.2.3"), WithHTTPHeaders(map[string]string{"user-agent": "gateway-test"})},
+			want: "gateway-test",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = r.Header.Get("User-Agent")
+			}))
+			defer server.Close()
+
+			var opts ClientOptions
+			for _, opt := range tt.opts {
+				opt(&opts)
+			}
+			response, err := createCustomHTTPClient(opts).Get(server.URL)
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			response.Body.Close()
+			if got != tt.want {
+				t.Errorf("expected User-Agent %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestDefaultUserAgent(t *testing.T) {
+	if got := defaultUserAgent(); !strings.HasPrefix(got, "kubectl-ai/") {
+		t.Errorf("expected default User-Agent to start with kubectl-ai/, got %q", got)
+	}
+}
+
+func TestCustomHTTPClientRequestID(t *testing.T) {
+	var got []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, r.Header.Get("X-Request-ID"))
+	}))
+	defer server.Close()
+
+	client := createCustomHTTPClient(ClientOptions{UserAgent: "kubectl-ai/test"})
+	for _, ctx := range []context.Context{
+		WithRequestID(context.Background(), "trace-123"),
+		context.Background(),
+	} {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+		if err != nil {
+			t.Fatalf("creating request: %v", err)
+		}
+		response, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		response.Body.Close()
+	}
+
+	want := []string{"trace-123", ""}
+	if !slices.Equal(got, want) {
+		t.Errorf("expected X-Request-ID headers %q, got %q", want, got)
+	}
+}
+
+func TestCustomHTTPClientProxy(t *testing.T) {
+	var opts ClientOptions
+	WithProxy("http://proxy.example.com:3128")(&opts)
+	WithSkipVerifySSL()(&opts)
+
+	transport, ok := createCustomHTTPClient(opts).Transport.(*http.Transport)
+	if !ok {
+		t.Fatalf("expected an *http.Transport, got %T", createCustomHTTPClient(opts).Transport)
+	}
+	req, err := http.NewRequest(http.MethodGet, "https://api.openai.com/v1/chat/completions", nil)
+	if err != nil {
+		t.Fatalf("creating request: %v", err)
+	}
+	proxy, err := transport.Proxy(req)
+	if err != nil {
+		t.Fatalf("Proxy failed: %v", err)
+	}
+	if proxy == nil || proxy.String() != "http://proxy.example.com:3128" {
+		t.Errorf("expected the configured proxy, got %v", proxy)
+	}
+	if !transport.TLSClientConfig.InsecureSkipVerify {
+		t.Error("expected SSL verification to still be skipped")
+	}
+}
+
+func TestNewClientInvalidProxy(t *testing.T) {
+	for _, proxyURL := range []string{"proxy.example.com:3128", "ftp://proxy.example.com", "http://"} {
+		_, err := NewClient(context.Background(), "openai", WithProxy(proxyURL))
+		if err == nil || !strings.Contains(err.Error(), "proxy URL") {
+			t.Errorf("%q: expected an invalid proxy URL error, got %v", proxyURL, err)
+		}
+	}
+}
+
+// keyRecordingChat is a fakeChat that records the idempotency key of every attempt.
+type keyRecordingChat struct {
+	*fakeChat
+
+	keys []string
+}
+
+func (c *keyRecordingChat) Send(ctx context.Context, contents ...any) (ChatResponse, error) {
+	key, _ := IdempotencyKeyFromContext(ctx)
+	c.keys = append(c.keys, key)
+	return c.fakeChat.Send(ctx, contents...)
+}
+
+func TestRetryChatIdempotencyKey(t *testing.T) {
+	ctx := context.Background()
+	retryable := &APIError{StatusCode: http.StatusServiceUnavailable, Message: "unavailable"}
+	underlying := &keyRecordingChat{fakeChat: &fakeChat{errs: []error{retryable}}}
+	chat := NewRetryChat(underlying, RetryConfig{MaxAttempts: 3, BackoffFactor: 1})
+
+	if _, err := chat.Send(ctx, "hello"); err != nil {
+		t.Fatalf("first Send failed: %v", err)
+	}
+	if _, err := chat.Send(ctx, "hello again"); err != nil {
+		t.Fatalf("second Send failed: %v", err)
+	}
+
+	if len(underlying.keys) != 3 {
+		t.Fatalf("expected 3 attempts, got %d", len(underlying.keys))
+	}
+	if underlying.keys[0] == "" || underlying.keys[0] != underlying.keys[1] {
+		t.Errorf("expected the retry to reuse the key of the first attempt, got %q", underlying.keys[:2])
+	}
+	if underlying.keys[2] == underlying.keys[0] {
+		t.Errorf("expected a distinct Send to get a new key, got %q twice", underlying.keys[0])
+	}
+
+	// A key set by the caller is kept
+	if _, err := chat.Send(WithIdempotencyKey(ctx, "caller-key"), "hello"); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	if underlying.keys[3] != "caller-key" {
+		t.Errorf("expected the caller's key, got %q", underlying.keys[3])
+	}
+}
@@ -76,6 +76,7 @@ func NewGeminiAPIClient(ctx context.Context, opt GeminiAPIClientOptions) (*Googl
 	}
 
 	return &GoogleAIClient{
+		name:   "gemini",
 		client: client,
 	}, nil
 }
@@ -178,6 +179,7 @@ func NewVertexAIClient(ctx context.Context, opt VertexAIClientOptions) (*GoogleA
 	}
 
 	return &GoogleAIClient{
+		name:   "vertexai",
 		client: client,
 	}, nil
 }
@@ -185,14 +187,28 @@ func NewVertexAIClient(ctx context.Context, opt VertexAIClientOptions) (*GoogleA
 // GoogleAIClient is a client for the google AI APIs.
 // It implements the Client interface.
 type GoogleAIClient struct {
+	// name is the provider the client was created for, "gemini" or "vertexai".
+	name   string
 	client *genai.Client
 
 	// responseSchema will constrain the output to match the given schema
 	responseSchema *genai.Schema
+	// schema is the schema responseSchema was converted from.
+	schema *Schema
 }
 
 var _ Client = &GoogleAIClient{}
 
+// Name returns the name of the provider the client was created for, "gemini" or "vertexai".
+func (c *GoogleAIClient) Name() string {
+	return c.name
+}
+
+// Capabilities returns the features supported by the Gemini provider.
+func (c *GoogleAIClient) Capabilities() ProviderCapabilities {
+	return ProviderCapabilities{Tools: true, Streaming: true, ResponseSchema: true}
+}
+
 // ListModels lists the models available in the Gemini API.
 func (c *GoogleAIClient) ListModels(ctx context.Context) (modelNames []string, err error) {
 	for model, err := range c.client.Models.All(ctx) {
@@ -214,6 +230,7 @@ func (c *GoogleAIClient) Close() error {
 func (c *GoogleAIClient) SetResponseSchema(responseSchema *Schema) error {
 	if responseSchema == nil {
 		c.responseSchema = nil
+		c.schema = nil
 		return nil
 	}
 
@@ -223,9 +240,15 @@ func (c *GoogleAIClient) SetResponseSchema(responseSchema *Schema) error {
 	}
 
 	c.responseSchema = geminiSchema
+	c.schema = responseSchema
 	return nil
 }
 
+// CurrentSchema returns the schema set with SetResponseSchema, or nil if there is none.
+func (c *GoogleAIClient) CurrentSchema() *Schema {
+	return c.schema
+}
+
 func (c *GoogleAIClient) GenerateCompletion(ctx context.Context, request *CompletionRequest) (CompletionResponse, error) {
 	log := klog.FromContext(ctx)
 
@@ -251,6 +274,36 @@ func (c *GoogleAIClient) GenerateCompletion(ctx context.Context, request *Comple
 	return &GeminiCompletionResponse{geminiResponse: result, text: result.Text()}, nil
 }
 
+// GenerateCompletionStream streams a completion for the given prompt.
+func (c *GoogleAIClient) GenerateCompletionStream(ctx context.Context, request *CompletionRequest) (CompletionResponseIterator, error) {
+	var config *genai.GenerateContentConfig
+
+	if c.responseSchema != nil {
+		config = &genai.GenerateContentConfig{
+			ResponseSchema:   c.responseSchema,
+			ResponseMIMEType: "application/json",
+		}
+	}
+
+	content := []*genai.Content{
+		{Role: "user", Parts: []*genai.Part{{Text: request.Prompt}}},
+	}
+
+	stream := c.client.Models.GenerateContentStream(ctx, request.Model, content, config)
+
+	return func(yield func(CompletionResponse, error) bool) {
+		for result, err := range stream {
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			if !yield(&GeminiCompletionResponse{geminiResponse: result, text: result.Text()}, nil) {
+				return
+			}
+		}
+	}, nil
+}
+
 // StartChat starts a new chat with the model.
 func (c *GoogleAIClient) StartChat(systemPrompt string, model string) Chat {
 	// Some values that are recommended by aistudio
@@ -297,15 +350,17 @@ func (c *GoogleAIClient) StartChat(systemPrompt string, model string) Chat {
 // GeminiChat is a chat with the model.
 // It implements the Chat interface.
 type GeminiChat struct {
-	model     string
-	client    *genai.Client
-	history   []*genai.Content
-	genConfig *genai.GenerateContentConfig
+	model               string
+	client              *genai.Client
+	history             []*genai.Content
+	genConfig           *genai.GenerateContentConfig
+	functionDefinitions []*FunctionDefinition
 }
 
 // SetFunctionDefinitions sets the function definitions for the chat.
 // This allows the LLM to call user-defined functions.
 func (c *GeminiChat) SetFunctionDefinitions(functionDefinitions []*FunctionDefinition) error {
+	c.functionDefinitions = functionDefinitions
 	var genaiFunctionDeclarations []*genai.FunctionDeclaration
 	for _, functionDefinition := range functionDefinitions {
 		if functionDefinition.Parameters == nil {
@@ -334,6 +389,10 @@ func toGeminiSchema(schema *Schema) (*genai.Schema, error) {
 	ret := &genai.Schema{
 		Description: schema.Description,
 		Required:    schema.Required,
+		Enum:        schema.Enum,
+		Format:      schema.Format,
+		MinItems:    schema.MinItems,
+		MaxItems:    schema.MaxItems,
 	}
 
 	switch schema.Type {
@@ -397,6 +456,9 @@ func (c *GeminiChat) partsToGemini(contents ...any) ([]*genai.Part, error) {
 // Send sends a message to the model.
 // It returns a ChatResponse object containing the response from the model.
 func (c *GeminiChat) Send(ctx context.Context, contents ...any) (ChatResponse, error) {
+	if override, rest := takeModelOverride(contents); override != "" {
+		c.model, contents = string(override), rest
+	}
 	log := klog.FromContext(ctx)
 	log.V(1).Info("sending LLM request", "user", contents)
 
@@ -425,6 +487,9 @@ func (c *GeminiChat) Send(ctx context.Context, contents ...any) (ChatResponse, e
 }
 
 func (c *GeminiChat) SendStreaming(ctx context.Context, contents ...any) (ChatResponseIterator, error) {
+	if override, rest := takeModelOverride(contents); override != "" {
+		c.model, contents = string(override), rest
+	}
 	log := klog.FromContext(ctx)
 	log.V(1).Info("sending LLM streaming request", "user", contents)
 
@@ -544,12 +609,46 @@ func (r *GeminiChatResponse) Candidates() []Candidate {
 	return candidates
 }
 
+func (r *GeminiChatResponse) RequiresToolCall() bool {
+	return requiresToolCall(r.Candidates())
+}
+
 // GeminiCandidate is a candidate for the response.
 // It implements the Candidate interface.
 type GeminiCandidate struct {
 	candidate *genai.Candidate
 }
 
+// geminiFinishReason normalizes the finish reason of a Gemini candidate.
+func geminiFinishReason(reason genai.FinishReason) FinishReason {
+	switch reason {
+	case "", genai.FinishReasonUnspecified:
+		return FinishReasonUnspecified
+	case genai.FinishReasonStop:
+		return FinishReasonStop
+	case genai.FinishReasonMaxTokens:
+		return FinishReasonMaxTokens
+	case genai.FinishReasonSafety, genai.FinishReasonRecitation, genai.FinishReasonBlocklist,
+		genai.FinishReasonProhibitedContent, genai.FinishReasonSPII, genai.FinishReasonImageSafety:
+		return FinishReasonContentFiltered
+	default:
+		return FinishReasonOther
+	}
+}
+
+// FinishReason returns why the model stopped generating the candidate.
+func (r *GeminiCandidate) FinishReason() FinishReason {
+	if r.candidate == nil {
+		return FinishReasonUnspecified
+	}
+	return geminiFinishReason(r.candidate.FinishReason)
+}
+
+// IsRefusal reports whether the model refused to respond or its response was filtered.
+func (r *GeminiCandidate) IsRefusal() bool {
+	return r.FinishReason() == FinishReasonContentFiltered
+}
+
 // String returns a string representation of the response.
 func (r *GeminiCandidate) String() string {
 	var response strings.Builder
@@ -586,6 +685,10 @@ func (r *GeminiCandidate) Parts() []Part {
 	return parts
 }
 
+func (r *GeminiCandidate) HasToolCalls() bool {
+	return hasToolCalls(r.Parts())
+}
+
 // GeminiPart is a part of a candidate.
 // It implements the Part interface.
 type GeminiPart struct {
@@ -668,3 +771,8 @@ func (c *GeminiChat) IsRetryableError(err error) bool {
 
 	return false
 }
+
+// Validate checks the chat's function definitions without issuing a request.
+func (c *GeminiChat) Validate() error {
+	return validationResult(validateFunctionDefinitions(c.functionDefinitions))
+}
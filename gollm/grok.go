@@ -68,7 +68,7 @@ func NewGrokClient(ctx context.Context, opts ClientOptions) (*GrokClient, error)
 	}
 
 	// Use the OpenAI client with custom base URL and custom HTTP client
-	httpClient := createCustomHTTPClient(opts.SkipVerifySSL)
+	httpClient := createCustomHTTPClient(opts)
 	return &GrokClient{
 		client: openai.NewClient(
 			option.WithAPIKey(apiKey),
@@ -159,6 +159,21 @@ func (c *GrokClient) SetResponseSchema(schema *Schema) error {
 	return nil
 }
 
+// GenerateCompletionStream streams a completion for the given prompt.
+func (c *GrokClient) GenerateCompletionStream(ctx context.Context, req *CompletionRequest) (CompletionResponseIterator, error) {
+	return streamCompletionViaChat(ctx, c, req)
+}
+
+// Name returns "grok", the name the Grok provider is registered under.
+func (c *GrokClient) Name() string {
+	return "grok"
+}
+
+// Capabilities returns the features supported by the Grok provider.
+func (c *GrokClient) Capabilities() ProviderCapabilities {
+	return ProviderCapabilities{Tools: true, Streaming: true}
+}
+
 // ListModels returns a list of available Grok models.
 func (c *GrokClient) ListModels(ctx context.Context) ([]string, error) {
 	// Currently, Grok only has a fixed set of models
@@ -214,6 +229,9 @@ func (cs *grokChatSession) SetFunctionDefinitions(defs []*FunctionDefinition) er
 
 // Send sends the user message(s), appends to history, and gets the LLM response.
 func (cs *grokChatSession) Send(ctx context.Context, contents ...any) (ChatResponse, error) {
+	if override, rest := takeModelOverride(contents); override != "" {
+		cs.model, contents = string(override), rest
+	}
 	klog.V(1).InfoS("grokChatSession.Send called", "model", cs.model, "history_len", len(cs.history))
 
 	// Append user message(s) to history
@@ -279,6 +297,9 @@ func (cs *grokChatSession) Send(ctx context.Context, contents ...any) (ChatRespo
 
 // SendStreaming sends the user message(s) and returns an iterator for the LLM response stream.
 func (cs *grokChatSession) SendStreaming(ctx context.Context, contents ...any) (ChatResponseIterator, error) {
+	if override, rest := takeModelOverride(contents); override != "" {
+		cs.model, contents = string(override), rest
+	}
 	klog.V(1).InfoS("Starting Grok streaming request", "model", cs.model, "streamingEnabled", true)
 
 	// Append user message(s) to history
@@ -380,6 +401,11 @@ func (cs *grokChatSession) IsRetryableError(err error) bool {
 	return DefaultIsRetryableError(err)
 }
 
+// Validate checks the chat's function definitions without issuing a request.
+func (cs *grokChatSession) Validate() error {
+	return validationResult(validateFunctionDefinitions(cs.functionDefinitions))
+}
+
 func (cs *grokChatSession) Initialize(messages []*api.Message) error {
 	klog.Warning("chat history persistence is not supported for provider 'grok', using in-memory chat history")
 	return nil
@@ -412,6 +438,10 @@ func (r *grokChatResponse) Candidates() []Candidate {
 	return candidates
 }
 
+func (r *grokChatResponse) RequiresToolCall() bool {
+	return requiresToolCall(r.Candidates())
+}
+
 type grokCandidate struct {
 	grokChoice *openai.ChatCompletionChoice
 }
@@ -435,6 +465,20 @@ func (c *grokCandidate) Parts() []Part {
 	return parts
 }
 
+func (c *grokCandidate) HasToolCalls() bool {
+	return hasToolCalls(c.Parts())
+}
+
+// FinishReason returns why the model stopped generating the candidate.
+func (c *grokCandidate) FinishReason() FinishReason {
+	return openAIFinishReason(c.grokChoice.FinishReason)
+}
+
+// IsRefusal reports whether the model refused to respond or its response was filtered.
+func (c *grokCandidate) IsRefusal() bool {
+	return c.FinishReason() == FinishReasonContentFiltered
+}
+
 // String provides a simple string representation for logging/debugging.
 func (c *grokCandidate) String() string {
 	if c.grokChoice == nil {
@@ -516,6 +560,10 @@ func (r *grokChatStreamResponse) Candidates() []Candidate {
 	return candidates
 }
 
+func (r *grokChatStreamResponse) RequiresToolCall() bool {
+	return requiresToolCall(r.Candidates())
+}
+
 // grokStreamCandidate adapts a streaming chunk choice to the Candidate interface.
 type grokStreamCandidate struct {
 	streamChoice openai.ChatCompletionChunkChoice
@@ -524,6 +572,16 @@ type grokStreamCandidate struct {
 // Ensure the streaming candidate implements Candidate interface.
 var _ Candidate = (*grokStreamCandidate)(nil)
 
+// FinishReason returns why the model stopped generating the candidate.
+func (c *grokStreamCandidate) FinishReason() FinishReason {
+	return openAIFinishReason(c.streamChoice.FinishReason)
+}
+
+// IsRefusal reports whether the model refused to respond or its response was filtered.
+func (c *grokStreamCandidate) IsRefusal() bool {
+	return c.FinishReason() == FinishReasonContentFiltered
+}
+
 // String provides a string representation of the candidate.
 func (c *grokStreamCandidate) String() string {
 	return fmt.Sprintf("StreamingCandidate(Index: %d, FinishReason: %s)",
@@ -567,6 +625,10 @@ func (c *grokStreamCandidate) Parts() []Part {
 	return parts
 }
 
+func (c *grokStreamCandidate) HasToolCalls() bool {
+	return hasToolCalls(c.Parts())
+}
+
 // grokStreamPart adapts streaming parts to the Part interface.
 type grokStreamPart struct {
 	content   string
@@ -0,0 +1,74 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gollm
+
+// SerializableChat is a Chat whose conversation can be snapshotted and later
+// restored, for example to persist an agent session across restarts.
+type SerializableChat interface {
+	Chat
+
+	// MarshalHistory returns the conversation so far as provider-neutral JSON.
+	MarshalHistory() ([]byte, error)
+
+	// RestoreHistory replaces the conversation with one returned by MarshalHistory.
+	RestoreHistory(data []byte) error
+}
+
+// HistoryRole is the author of a HistoryMessage.
+type HistoryRole string
+
+const (
+	HistoryRoleUser      HistoryRole = "user"
+	HistoryRoleAssistant HistoryRole = "assistant"
+)
+
+// HistoryMessage is a provider-neutral chat message, as serialized by MarshalHistory.
+type HistoryMessage struct {
+	Role  HistoryRole   `json:"role"`
+	Parts []HistoryPart `json:"parts"`
+}
+
+// HistoryPart is one part of a HistoryMessage. Exactly one of its fields is set.
+type HistoryPart struct {
+	Text               string              `json:"text,omitempty"`
+	FunctionCall       *FunctionCall       `json:"functionCall,omitempty"`
+	FunctionCallResult *FunctionCallResult `json:"functionCallResult,omitempty"`
+	Document           *DocumentPart       `json:"document,omitempty"`
+}
+
+// preserveSerializable returns wrapper, extended with the history serialization of
+// underlying if it is a SerializableChat, so that wrapping a chat does not hide it.
+func preserveSerializable(wrapper, underlying Chat) Chat {
+	serializable, ok := underlying.(SerializableChat)
+	if !ok {
+		return wrapper
+	}
+	return &serializableWrapper{Chat: wrapper, serializable: serializable}
+}
+
+// serializableWrapper is a wrapping Chat that keeps the history serialization of the chat it wraps.
+type serializableWrapper struct {
+	Chat
+
+	serializable SerializableChat
+}
+
+func (c *serializableWrapper) MarshalHistory() ([]byte, error) {
+	return c.serializable.MarshalHistory()
+}
+
+func (c *serializableWrapper) RestoreHistory(data []byte) error {
+	return c.serializable.RestoreHistory(data)
+}
@@ -0,0 +1,48 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gollm
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// idempotencyKeyHeader carries the idempotency key of a context to HTTP-based providers.
+const idempotencyKeyHeader = "Idempotency-Key"
+
+type idempotencyKeyKey struct{}
+
+// WithIdempotencyKey returns a context carrying key, which identifies a logical request
+// across its retries. The retrying chat of NewRetryChat sets a new key for each Send
+// that does not have one. HTTP-based providers send it as the Idempotency-Key header,
+// and Bedrock records it in the request metadata of its invocation logs.
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKeyKey{}, key)
+}
+
+// IdempotencyKeyFromContext returns the idempotency key set on ctx by WithIdempotencyKey, if any.
+func IdempotencyKeyFromContext(ctx context.Context) (string, bool) {
+	key, ok := ctx.Value(idempotencyKeyKey{}).(string)
+	return key, ok && key != ""
+}
+
+// ensureIdempotencyKey returns ctx with a new idempotency key, unless it already has one.
+func ensureIdempotencyKey(ctx context.Context) context.Context {
+	if _, ok := IdempotencyKeyFromContext(ctx); ok {
+		return ctx
+	}
+	return WithIdempotencyKey(ctx, uuid.NewString())
+}
@@ -0,0 +1,213 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gollm
+
+import "context"
+
+// RequestInfo describes a model request, as seen by an Interceptor or UsageCallback.
+type RequestInfo struct {
+	Provider string
+	Model    string
+	// Stream is true for streaming requests.
+	Stream bool
+}
+
+// Interceptor observes model requests. It is called when a request is issued,
+// and the function it returns is called once the request has completed; for
+// streaming requests that is when the stream is exhausted.
+type Interceptor func(ctx context.Context, info RequestInfo) func(err error)
+
+// UsageCallback is called with the token usage of every model response that reports it.
+type UsageCallback func(info RequestInfo, usage *Usage)
+
+// WithInterceptor installs an Interceptor on the client.
+func WithInterceptor(interceptor Interceptor) Option {
+	return func(o *ClientOptions) {
+		o.Interceptors = append(o.Interceptors, interceptor)
+	}
+}
+
+// WithUsageCallback installs a UsageCallback on the client.
+func WithUsageCallback(callback UsageCallback) Option {
+	return func(o *ClientOptions) {
+		o.UsageCallbacks = append(o.UsageCallbacks, callback)
+	}
+}
+
+// observedClient is a Client that reports requests to interceptors and usage callbacks.
+type observedClient struct {
+	Client
+
+	interceptors   []Interceptor
+	usageCallbacks []UsageCallback
+	costCalculator CostCalculator
+}
+
+// observeClient wraps client so that its requests are reported to the
+// interceptors and usage callbacks in opts. It returns client unchanged if there are none.
+func observeClient(client Client, opts ClientOptions) Client {
+	if len(opts.Interceptors) == 0 && len(opts.UsageCallbacks) == 0 {
+		return client
+	}
+	return &observedClient{
+		Client:         client,
+		interceptors:   opts.Interceptors,
+		usageCallbacks: opts.UsageCallbacks,
+		costCalculator: opts.CostCalculator,
+	}
+}
+
+// begin calls the interceptors for a new request, and returns a function that
+// reports the request's completion to them.
+func (c *observedClient) begin(ctx context.Context, info RequestInfo) func(err error) {
+	var dones []func(err error)
+	for _, interceptor := range c.interceptors {
+		if done := interceptor(ctx, info); done != nil {
+			dones = append(dones, done)
+		}
+	}
+	return func(err error) {
+		for _, done := range dones {
+			done(err)
+		}
+	}
+}
+
+// reportUsage passes the usage of a response, if it reports any, to the usage callbacks.
+// Costs the provider left zero are filled in by the cost calculator, if there is one.
+func (c *observedClient) reportUsage(info RequestInfo, metadata any) {
+	usage, ok := metadata.(*Usage)
+	if !ok || usage == nil {
+		return
+	}
+	if c.costCalculator != nil && usage.TotalCost == 0 {
+		model := usage.Model
+		if model == "" {
+			model = info.Model
+		}
+		c.costCalculator.Calculate(info.Provider, model, usage)
+	}
+	for _, callback := range c.usageCallbacks {
+		callback(info, usage)
+	}
+}
+
+func (c *observedClient) GenerateCompletion(ctx context.Context, req *CompletionRequest) (CompletionResponse, error) {
+	info := RequestInfo{Provider: c.Name(), Model: req.Model}
+	done := c.begin(ctx, info)
+	response, err := c.Client.GenerateCompletion(ctx, req)
+	done(err)
+	if err == nil && response != nil {
+		c.reportUsage(info, response.UsageMetadata())
+	}
+	return response, err
+}
+
+func (c *observedClient) GenerateCompletionStream(ctx context.Context, req *CompletionRequest) (CompletionResponseIterator, error) {
+	info := RequestInfo{Provider: c.Name(), Model: req.Model, Stream: true}
+	done := c.begin(ctx, info)
+	stream, err := c.Client.GenerateCompletionStream(ctx, req)
+	if err != nil {
+		done(err)
+		return nil, err
+	}
+	return func(yield func(CompletionResponse, error) bool) {
+		var streamErr error
+		defer func() { done(streamErr) }()
+		for response, err := range stream {
+			if err != nil {
+				streamErr = err
+			} else if response != nil {
+				c.reportUsage(info, response.UsageMetadata())
+			}
+			if !yield(response, err) {
+				return
+			}
+		}
+	}, nil
+}
+
+func (c *observedClient) HealthCheck(ctx context.Context) error {
+	return HealthCheck(ctx, c.Client)
+}
+
+func (c *observedClient) StartChat(systemPrompt, model string) Chat {
+	return c.wrapChat(c.Client.StartChat(systemPrompt, model), model)
+}
+
+func (c *observedClient) StartChatWithOptions(systemPrompt, model string, opts ...ChatOption) (Chat, error) {
+	underlying, err := StartChatWithOptions(c.Client, systemPrompt, model, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return c.wrapChat(underlying, model), nil
+}
+
+func (c *observedClient) wrapChat(underlying Chat, model string) Chat {
+	return preserveSerializable(&observedChat{
+		Chat:   underlying,
+		client: c,
+		model:  model,
+	}, underlying)
+}
+
+// observedChat is a Chat that reports its requests to the interceptors and usage callbacks of its client.
+type observedChat struct {
+	Chat
+
+	client *observedClient
+	model  string
+}
+
+func (c *observedChat) Send(ctx context.Context, contents ...any) (ChatResponse, error) {
+	if override, _ := takeModelOverride(contents); override != "" {
+		c.model = string(override)
+	}
+	info := RequestInfo{Provider: c.client.Name(), Model: c.model}
+	done := c.client.begin(ctx, info)
+	response, err := c.Chat.Send(ctx, contents...)
+	done(err)
+	if err == nil && response != nil {
+		c.client.reportUsage(info, response.UsageMetadata())
+	}
+	return response, err
+}
+
+func (c *observedChat) SendStreaming(ctx context.Context, contents ...any) (ChatResponseIterator, error) {
+	if override, _ := takeModelOverride(contents); override != "" {
+		c.model = string(override)
+	}
+	info := RequestInfo{Provider: c.client.Name(), Model: c.model, Stream: true}
+	done := c.client.begin(ctx, info)
+	stream, err := c.Chat.SendStreaming(ctx, contents...)
+	if err != nil {
+		done(err)
+		return nil, err
+	}
+	return func(yield func(ChatResponse, error) bool) {
+		var streamErr error
+		defer func() { done(streamErr) }()
+		for response, err := range stream {
+			if err != nil {
+				streamErr = err
+			} else if response != nil {
+				c.client.reportUsage(info, response.UsageMetadata())
+			}
+			if !yield(response, err) {
+				return
+			}
+		}
+	}, nil
+}
@@ -17,9 +17,13 @@ package gollm
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"iter"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/api"
 )
@@ -28,18 +32,59 @@ import (
 type Client interface {
 	io.Closer
 
+	// Name returns the name the provider of the client is registered under, such as "bedrock".
+	Name() string
+
 	// StartChat starts a new multi-turn chat with a language model.
 	StartChat(systemPrompt, model string) Chat
 
 	// GenerateCompletion generates a single completion for a given prompt.
 	GenerateCompletion(ctx context.Context, req *CompletionRequest) (CompletionResponse, error)
 
+	// GenerateCompletionStream is the streaming version of GenerateCompletion.
+	// Concatenating the Response of every streamed chunk gives the full completion.
+	GenerateCompletionStream(ctx context.Context, req *CompletionRequest) (CompletionResponseIterator, error)
+
 	// SetResponseSchema constrains LLM responses to match the provided schema.
 	// Calling with nil will clear the current schema.
 	SetResponseSchema(schema *Schema) error
 
 	// ListModels lists the models available in the LLM.
 	ListModels(ctx context.Context) ([]string, error)
+
+	// Capabilities reports the features supported by the provider.
+	Capabilities() ProviderCapabilities
+}
+
+// HealthChecker is implemented by clients that can check that their provider is
+// reachable and accepts their credentials, without starting a chat.
+type HealthChecker interface {
+	HealthCheck(ctx context.Context) error
+}
+
+// HealthCheck checks that the provider of client is reachable and accepts its
+// credentials. It returns nil if the client does not support health checks.
+func HealthCheck(ctx context.Context, client Client) error {
+	checker, ok := client.(HealthChecker)
+	if !ok {
+		return nil
+	}
+	return checker.HealthCheck(ctx)
+}
+
+// ProviderCapabilities describes the features a provider supports, so that
+// callers can check for them rather than discover them by trial and error.
+type ProviderCapabilities struct {
+	// Tools is true if chats support function calling.
+	Tools bool
+	// Streaming is true if responses are streamed incrementally, rather than returned at once by SendStreaming.
+	Streaming bool
+	// ResponseSchema is true if SetResponseSchema constrains responses.
+	ResponseSchema bool
+	// Images is true if chats accept image input.
+	Images bool
+	// PromptCaching is true if the provider can cache prompt prefixes.
+	PromptCaching bool
 }
 
 // Chat is an active conversation with a language model.
@@ -62,6 +107,10 @@ type Chat interface {
 
 	// Initialize initializes the chat with a previous conversation history.
 	Initialize(messages []*api.Message) error
+
+	// Validate checks the chat's conversation, tools and model without issuing a request.
+	// It returns a *ValidationError listing every problem found.
+	Validate() error
 }
 
 // CompletionRequest is a request to generate a completion for a given prompt.
@@ -76,6 +125,9 @@ type CompletionResponse interface {
 	UsageMetadata() any
 }
 
+// CompletionResponseIterator is a streaming completion response from the LLM.
+type CompletionResponseIterator iter.Seq2[CompletionResponse, error]
+
 // FunctionCall is a function call to a language model.
 // The LLM will reply with a FunctionCall to a user-defined function, and we will send the results back.
 type FunctionCall struct {
@@ -100,6 +152,17 @@ type Schema struct {
 	Items       *Schema            `json:"items,omitempty"`
 	Description string             `json:"description,omitempty"`
 	Required    []string           `json:"required,omitempty"`
+
+	// Enum restricts the value to a fixed set of strings.
+	Enum []string `json:"enum,omitempty"`
+	// Format is a hint about the format of the value, for example "date-time".
+	Format string `json:"format,omitempty"`
+
+	// MinItems and MaxItems bound the number of items in an array.
+	MinItems *int64 `json:"minItems,omitempty"`
+	MaxItems *int64 `json:"maxItems,omitempty"`
+	// AdditionalProperties controls whether an object may have properties not listed in Properties.
+	AdditionalProperties *bool `json:"additionalProperties,omitempty"`
 }
 
 // ToRawSchema converts a Schema to a json.RawMessage.
@@ -134,6 +197,38 @@ type FunctionCallResult struct {
 	ID     string         `json:"id,omitempty"`
 	Name   string         `json:"name,omitempty"`
 	Result map[string]any `json:"result,omitempty"`
+	// IsError is set if the function failed, and Result describes the failure,
+	// so that models that distinguish failed calls can react to it.
+	IsError bool `json:"isError,omitempty"`
+}
+
+// DocumentPart is a file attached to a chat message for the model to read,
+// such as a manifest or a log. Format is the file type, for example "pdf", "csv", "txt" or "md".
+type DocumentPart struct {
+	Name   string `json:"name"`
+	Format string `json:"format"`
+	Bytes  []byte `json:"bytes"`
+}
+
+// ModelOverride switches a chat to another model when it is passed to Send or
+// SendStreaming along with the contents of a message, for example to escalate from
+// a cheap model to a more capable one. The model answers that message and the ones
+// after it, and the conversation so far is kept. The system prompt is unchanged.
+type ModelOverride string
+
+// takeModelOverride removes the model overrides from contents, and returns the last of them,
+// or "" if there are none.
+func takeModelOverride(contents []any) (ModelOverride, []any) {
+	var override ModelOverride
+	rest := make([]any, 0, len(contents))
+	for _, content := range contents {
+		if model, ok := content.(ModelOverride); ok {
+			override = model
+			continue
+		}
+		rest = append(rest, content)
+	}
+	return override, rest
 }
 
 // ChatResponse is a generic chat response from the LLM.
@@ -143,6 +238,85 @@ type ChatResponse interface {
 	// Candidates are a set of candidate responses from the LLM.
 	// The LLM may return multiple candidates, and we can choose the best one.
 	Candidates() []Candidate
+
+	// RequiresToolCall reports whether any candidate of the response asks for a function
+	// call, as opposed to being a final answer.
+	RequiresToolCall() bool
+}
+
+// StreamStats describes the timing of a streamed response.
+type StreamStats struct {
+	// TimeToFirstToken is the time from sending the request to receiving the first content.
+	TimeToFirstToken time.Duration
+	// Duration is the time from sending the request to the end of the stream.
+	Duration time.Duration
+	// Chunks is the number of content chunks received.
+	Chunks int
+}
+
+// StreamStatsReporter is implemented by the responses of providers that time their streams.
+// StreamStats returns nil except on the final response of a stream.
+type StreamStatsReporter interface {
+	StreamStats() *StreamStats
+}
+
+// ResponseMetrics describes where the time of a response was spent.
+type ResponseMetrics struct {
+	// ServerLatency is the time the provider reports it spent handling the request.
+	ServerLatency time.Duration
+	// ClientLatency is the time from sending the request to receiving the response, as seen
+	// by the client. Its difference with ServerLatency is mostly spent on the network.
+	ClientLatency time.Duration
+}
+
+// ResponseMetricsReporter is implemented by the responses of providers that report their latency.
+// ResponseMetrics returns nil if the response has no metrics, such as the partial responses of a stream.
+type ResponseMetricsReporter interface {
+	ResponseMetrics() *ResponseMetrics
+}
+
+// requiresToolCall reports whether any of candidates asks for a function call. It
+// implements ChatResponse.RequiresToolCall for responses that have no better way to tell.
+func requiresToolCall(candidates []Candidate) bool {
+	return slices.ContainsFunc(candidates, Candidate.HasToolCalls)
+}
+
+// hasToolCalls reports whether any of parts is a function call. It implements
+// Candidate.HasToolCalls for candidates that have no better way to tell.
+func hasToolCalls(parts []Part) bool {
+	for _, part := range parts {
+		if calls, ok := part.AsFunctionCalls(); ok && len(calls) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// ErrNoTextContent is returned by GenerateCompletion when the model answers the prompt
+// with function calls only, rather than with an empty completion.
+var ErrNoTextContent = errors.New("response has no text content, only function calls")
+
+// checkTextContent returns an error matching ErrNoTextContent if the first candidate of
+// response, as used for completions, has function calls but no text.
+func checkTextContent(response ChatResponse) error {
+	if response == nil {
+		return nil
+	}
+	candidates := response.Candidates()
+	if len(candidates) == 0 || !candidates[0].HasToolCalls() {
+		return nil
+	}
+	var names []string
+	for _, part := range candidates[0].Parts() {
+		if text, ok := part.AsText(); ok && text != "" {
+			return nil
+		}
+		calls, _ := part.AsFunctionCalls()
+		for _, call := range calls {
+			names = append(names, call.Name)
+		}
+	}
+	return fmt.Errorf("%w: the model called %s", ErrNoTextContent, strings.Join(names, ", "))
 }
 
 // ChatResponseIterator is a streaming chat response from the LLM.
@@ -155,8 +329,37 @@ type Candidate interface {
 
 	// Parts returns the parts of the candidate.
 	Parts() []Part
+
+	// HasToolCalls reports whether the candidate asks for a function call.
+	HasToolCalls() bool
+
+	// FinishReason returns why the model stopped generating the candidate, if it is known.
+	FinishReason() FinishReason
+
+	// IsRefusal reports whether the model refused to respond, or its response was
+	// filtered. Sending the same request again is unlikely to help.
+	IsRefusal() bool
 }
 
+// FinishReason is why a model stopped generating a candidate, normalized across providers.
+type FinishReason string
+
+const (
+	// FinishReasonUnspecified means that the provider did not report a reason,
+	// or that the candidate is a streamed chunk before the end of the response.
+	FinishReasonUnspecified FinishReason = ""
+	// FinishReasonStop means that the model finished its response.
+	FinishReasonStop FinishReason = "stop"
+	// FinishReasonMaxTokens means that the response was cut off at the token limit.
+	FinishReasonMaxTokens FinishReason = "max_tokens"
+	// FinishReasonToolUse means that the model is waiting for the results of function calls.
+	FinishReasonToolUse FinishReason = "tool_use"
+	// FinishReasonContentFiltered means that the model refused to respond, or its response was filtered.
+	FinishReasonContentFiltered FinishReason = "content_filtered"
+	// FinishReasonOther is any other reason reported by the provider.
+	FinishReasonOther FinishReason = "other"
+)
+
 // Part is a part of a candidate response from the LLM.
 // It can be a text response, or a function call.
 // A response may comprise multiple parts,
@@ -0,0 +1,189 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gollm
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+type fakeResponse struct {
+	candidates []Candidate
+}
+
+func (r *fakeResponse) UsageMetadata() any {
+	return nil
+}
+
+func (r *fakeResponse) Candidates() []Candidate {
+	return r.candidates
+}
+
+func (r *fakeResponse) RequiresToolCall() bool {
+	return requiresToolCall(r.Candidates())
+}
+
+type fakeCandidate struct {
+	parts []Part
+}
+
+func (c *fakeCandidate) String() string {
+	return ""
+}
+
+func (c *fakeCandidate) FinishReason() FinishReason {
+	return FinishReasonUnspecified
+}
+
+func (c *fakeCandidate) IsRefusal() bool {
+	return false
+}
+
+func (c *fakeCandidate) Parts() []Part {
+	return c.parts
+}
+
+func (c *fakeCandidate) HasToolCalls() bool {
+	return hasToolCalls(c.Parts())
+}
+
+type fakePart struct {
+	text  string
+	calls []FunctionCall
+}
+
+func (p *fakePart) AsText() (string, bool) {
+	return p.text, p.calls == nil
+}
+
+func (p *fakePart) AsFunctionCalls() ([]FunctionCall, bool) {
+	return p.calls, p.calls != nil
+}
+
+func TestRequiresToolCall(t *testing.T) {
+	tests := []struct {
+		name     string
+		response ChatResponse
+		want     bool
+	}{
+		{
+			name: "tool call",
+			response: &fakeResponse{candidates: []Candidate{&fakeCandidate{parts: []Part{
+				&fakePart{text: "Let me check the pods."},
+				&fakePart{calls: []FunctionCall{{ID: "call-1", Name: "kubectl"}}},
+			}}}},
+			want: true,
+		},
+		{
+			name: "text only",
+			response: &fakeResponse{candidates: []Candidate{&fakeCandidate{parts: []Part{
+				&fakePart{text: "All pods are running."},
+			}}}},
+			want: false,
+		},
+		{
+			name:     "no candidates",
+			response: &fakeResponse{},
+			want:     false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.response.RequiresToolCall(); got != tt.want {
+				t.Errorf("RequiresToolCall() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProviderCapabilities(t *testing.T) {
+	tests := []struct {
+		name   string
+		client Client
+		want   ProviderCapabilities
+	}{
+		{
+			name:   "bedrock",
+			client: &BedrockClient{},
+			want:   ProviderCapabilities{Tools: true, Streaming: true, ResponseSchema: true},
+		},
+		{
+			name:   "gemini",
+			client: &GoogleAIClient{},
+			want:   ProviderCapabilities{Tools: true, Streaming: true, ResponseSchema: true},
+		},
+		{
+			name:   "ollama",
+			client: &OllamaClient{},
+			want:   ProviderCapabilities{Tools: true},
+		},
+		{
+			// Wrappers installed by NewClient report the capabilities of the provider.
+			name: "observed bedrock",
+			client: observeClient(&BedrockClient{}, ClientOptions{
+				Interceptors: []Interceptor{func(ctx context.Context, info RequestInfo) func(error) { return nil }},
+			}),
+			want: ProviderCapabilities{Tools: true, Streaming: true, ResponseSchema: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.client.Capabilities(); got != tt.want {
+				t.Errorf("expected %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestClientName(t *testing.T) {
+	tests := []struct {
+		client Client
+		want   string
+	}{
+		{client: &AnthropicClient{}, want: "anthropic"},
+		{client: &AzureOpenAIClient{}, want: "azopenai"},
+		{client: &BedrockClient{}, want: "bedrock"},
+		{client: &GoogleAIClient{name: "gemini"}, want: "gemini"},
+		{client: &GoogleAIClient{name: "vertexai"}, want: "vertexai"},
+		{client: &GrokClient{}, want: "grok"},
+		{client: &LlamaCppClient{}, want: "llamacpp"},
+		{client: &OllamaClient{}, want: "ollama"},
+		{client: &OpenAIClient{}, want: "openai"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			if got := tt.client.Name(); got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+			if _, ok := globalRegistry.providers[tt.want]; !ok {
+				t.Errorf("%q is not a registered provider", tt.want)
+			}
+		})
+	}
+
+	// Wrappers installed by NewClient report the name of the provider.
+	client, err := NewClient(context.Background(), "llamacpp", WithMaxConcurrentRequests(1), WithTurnTimeout(time.Minute))
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer client.Close()
+	if got := client.Name(); got != "llamacpp" {
+		t.Errorf("expected the wrapped client to be named %q, got %q", "llamacpp", got)
+	}
+}
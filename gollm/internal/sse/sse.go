@@ -0,0 +1,100 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package sse reads server-sent events, the format in which OpenAI-compatible
+// APIs stream their responses.
+package sse
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"iter"
+	"strings"
+)
+
+// Done is the data of the event that OpenAI-compatible APIs send to end a stream.
+const Done = "[DONE]"
+
+// Event is a server-sent event.
+type Event struct {
+	// Type is the event type, or empty for the default "message" type.
+	Type string
+	// Data is the data of the event. Multiple data lines are joined by newlines.
+	Data string
+	// ID is the last event ID set in the stream.
+	ID string
+}
+
+// IsDone returns true if the event is the Done sentinel.
+func (e Event) IsDone() bool {
+	return e.Data == Done
+}
+
+// Read returns an iterator over the events read from r. Comments are skipped, and
+// an event that is not terminated by a blank line before the end of r is discarded.
+// Lines may end with "\n" or "\r\n". The iterator stops after the first error.
+func Read(r io.Reader) iter.Seq2[Event, error] {
+	return func(yield func(Event, error) bool) {
+		reader := bufio.NewReader(r)
+		var (
+			event Event
+			data  strings.Builder
+			// id persists across events, as the spec requires
+			id string
+		)
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				// At the end of r, a pending event or unterminated line is incomplete
+				if !errors.Is(err, io.EOF) {
+					yield(Event{}, err)
+				}
+				return
+			}
+			line = strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r")
+
+			if line == "" {
+				// A blank line dispatches the event, if it has data
+				if data.Len() > 0 {
+					event.Data = strings.TrimSuffix(data.String(), "\n")
+					event.ID = id
+					if !yield(event, nil) {
+						return
+					}
+				}
+				event = Event{}
+				data.Reset()
+				continue
+			}
+			if strings.HasPrefix(line, ":") {
+				continue
+			}
+
+			field, value, _ := strings.Cut(line, ":")
+			value = strings.TrimPrefix(value, " ")
+			switch field {
+			case "event":
+				event.Type = value
+			case "data":
+				data.WriteString(value)
+				data.WriteByte('\n')
+			case "id":
+				if !strings.ContainsRune(value, 0) {
+					id = value
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,138 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sse
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"unicode/utf8"
+)
+
+func TestRead(t *testing.T) {
+	tests := []struct {
+		name   string
+		stream string
+		want   []Event
+	}{
+		{
+			name:   "OpenAI chat completion chunks",
+			stream: "data: {\"id\":\"1\"}\n\ndata: {\"id\":\"2\"}\n\ndata: [DONE]\n\n",
+			want:   []Event{{Data: `{"id":"1"}`}, {Data: `{"id":"2"}`}, {Data: Done}},
+		},
+		{
+			name:   "multi-line data",
+			stream: "data: first\ndata: second\ndata:\ndata:third\n\n",
+			want:   []Event{{Data: "first\nsecond\n\nthird"}},
+		},
+		{
+			name:   "event type, id and comments",
+			stream: ": keep-alive\nevent: delta\nid: 7\ndata: hi\n\n: ping\n\ndata: there\n\n",
+			want:   []Event{{Type: "delta", ID: "7", Data: "hi"}, {ID: "7", Data: "there"}},
+		},
+		{
+			name:   "CRLF line endings",
+			stream: "event: delta\r\ndata: hi\r\n\r\n",
+			want:   []Event{{Type: "delta", Data: "hi"}},
+		},
+		{
+			name:   "events without data are not dispatched",
+			stream: "event: ping\n\nretry: 1000\n\ndata: hi\n\n",
+			want:   []Event{{Data: "hi"}},
+		},
+		{
+			name:   "unterminated event is discarded",
+			stream: "data: hi\n\ndata: partial\n",
+			want:   []Event{{Data: "hi"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Reading one byte at a time splits lines and events across reads
+			var got []Event
+			for event, err := range Read(iotest.OneByteReader(strings.NewReader(tt.stream))) {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				got = append(got, event)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected events %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestReadDone(t *testing.T) {
+	var events []Event
+	for event, err := range Read(strings.NewReader("data: {}\n\ndata: [DONE]\n\n")) {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		events = append(events, event)
+		if event.IsDone() {
+			break
+		}
+	}
+	if len(events) != 2 || events[0].IsDone() || !events[1].IsDone() {
+		t.Errorf("expected the second event to be the Done sentinel, got %+v", events)
+	}
+}
+
+func TestReadError(t *testing.T) {
+	broken := errors.New("connection reset")
+	r := io.MultiReader(strings.NewReader("data: hi\n\n"), iotest.ErrReader(broken))
+
+	var events []Event
+	var err error
+	for event, e := range Read(r) {
+		if e != nil {
+			err = e
+			continue
+		}
+		events = append(events, event)
+	}
+	if len(events) != 1 || !errors.Is(err, broken) {
+		t.Errorf("expected one event then the read error, got %+v and %v", events, err)
+	}
+}
+
+func TestReadLongMultibyteLine(t *testing.T) {
+	// Longer than the 64KiB lines a bufio.Scanner accepts by default
+	data := strings.Repeat("pod é 日本語 🚀 ", 10_000)
+	stream := "data: " + data + "\n\ndata: [DONE]\n\n"
+
+	// Reading one byte at a time splits every multibyte rune across reads
+	var got []Event
+	for event, err := range Read(iotest.OneByteReader(strings.NewReader(stream))) {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		got = append(got, event)
+	}
+	if len(got) != 2 || !got[1].IsDone() {
+		t.Fatalf("expected a data event and the Done sentinel, got %d events", len(got))
+	}
+	if got[0].Data != data {
+		t.Errorf("expected the %d byte line to be reassembled, got %d bytes", len(data), len(got[0].Data))
+	}
+	if !utf8.ValidString(got[0].Data) {
+		t.Error("expected the reassembled data to be valid UTF-8")
+	}
+}
@@ -45,13 +45,15 @@ type LlamaCppClient struct {
 	baseURL        *url.URL
 	httpClient     *http.Client
 	responseSchema *llamacppSchema
+	schema         *Schema
 }
 
 type LlamaCppChat struct {
-	client  *LlamaCppClient
-	model   string
-	history []llamacppChatMessage
-	tools   []llamacppTool
+	client              *LlamaCppClient
+	model               string
+	history             []llamacppChatMessage
+	tools               []llamacppTool
+	functionDefinitions []*FunctionDefinition
 }
 
 var _ Client = &LlamaCppClient{}
@@ -70,7 +72,7 @@ func NewLlamaCppClient(ctx context.Context, opts ClientOptions) (*LlamaCppClient
 	}
 	klog.Infof("using llama.cpp with base url %v", baseURL.String())
 
-	httpClient := createCustomHTTPClient(opts.SkipVerifySSL)
+	httpClient := createCustomHTTPClient(opts)
 
 	return &LlamaCppClient{
 		baseURL:    baseURL,
@@ -152,16 +154,39 @@ func (c *LlamaCppClient) doChat(ctx context.Context, req *llamacppChatRequest) (
 	return chatResponse, nil
 }
 
+// GenerateCompletionStream streams a completion for the given prompt.
+func (c *LlamaCppClient) GenerateCompletionStream(ctx context.Context, req *CompletionRequest) (CompletionResponseIterator, error) {
+	return streamCompletionViaChat(ctx, c, req)
+}
+
+// Name returns "llamacpp", the name the llama.cpp provider is registered under.
+func (c *LlamaCppClient) Name() string {
+	return "llamacpp"
+}
+
+// Capabilities returns the features supported by the llama.cpp provider.
+func (c *LlamaCppClient) Capabilities() ProviderCapabilities {
+	return ProviderCapabilities{Tools: true, ResponseSchema: true}
+}
+
 func (c *LlamaCppClient) ListModels(ctx context.Context) ([]string, error) {
 	return nil, fmt.Errorf("model switching not supported by llama.cpp")
 }
 
+// SetResponseSchema constrains LLM responses to match the provided schema.
+// Calling with nil will clear the current schema.
 func (c *LlamaCppClient) SetResponseSchema(responseSchema *Schema) error {
 	llamaSchema := toLlamacppSchema(responseSchema)
 	c.responseSchema = llamaSchema
+	c.schema = responseSchema
 	return nil
 }
 
+// CurrentSchema returns the schema set with SetResponseSchema, or nil if there is none.
+func (c *LlamaCppClient) CurrentSchema() *Schema {
+	return c.schema
+}
+
 func (c *LlamaCppClient) StartChat(systemPrompt, model string) Chat {
 	return &LlamaCppChat{
 		client: c,
@@ -188,6 +213,9 @@ func (r *LlamaCppCompletionResponse) UsageMetadata() any {
 }
 
 func (c *LlamaCppChat) Send(ctx context.Context, contents ...any) (ChatResponse, error) {
+	if override, rest := takeModelOverride(contents); override != "" {
+		c.model, contents = string(override), rest
+	}
 	log := klog.FromContext(ctx)
 	for _, content := range contents {
 		switch v := content.(type) {
@@ -282,6 +310,9 @@ func (c *LlamaCppChat) Send(ctx context.Context, contents ...any) (ChatResponse,
 }
 
 func (c *LlamaCppChat) SendStreaming(ctx context.Context, contents ...any) (ChatResponseIterator, error) {
+	if override, rest := takeModelOverride(contents); override != "" {
+		c.model, contents = string(override), rest
+	}
 	// TODO: Implement streaming
 	response, err := c.Send(ctx, contents...)
 	if err != nil {
@@ -295,6 +326,11 @@ func (c *LlamaCppChat) IsRetryableError(err error) bool {
 	return false
 }
 
+// Validate checks the chat's function definitions without issuing a request.
+func (c *LlamaCppChat) Validate() error {
+	return validationResult(validateFunctionDefinitions(c.functionDefinitions))
+}
+
 func (c *LlamaCppChat) Initialize(messages []*api.Message) error {
 	klog.Warning("chat history persistence is not supported for provider 'llamacpp', using in-memory chat history")
 	return nil
@@ -345,10 +381,25 @@ func (r *LlamaCppChatResponse) Candidates() []Candidate {
 	return cads
 }
 
+func (r *LlamaCppChatResponse) RequiresToolCall() bool {
+	return requiresToolCall(r.Candidates())
+}
+
 type LlamaCppCandidate struct {
 	parts []*LlamaCppPart
 }
 
+// FinishReason returns why the model stopped generating the candidate.
+func (r *LlamaCppCandidate) FinishReason() FinishReason {
+	// The llama.cpp responses used here do not report why generation stopped
+	return FinishReasonUnspecified
+}
+
+// IsRefusal reports whether the model refused to respond or its response was filtered.
+func (r *LlamaCppCandidate) IsRefusal() bool {
+	return r.FinishReason() == FinishReasonContentFiltered
+}
+
 func (r *LlamaCppCandidate) String() string {
 	return r.parts[0].text
 }
@@ -361,6 +412,10 @@ func (r *LlamaCppCandidate) Parts() []Part {
 	return out
 }
 
+func (r *LlamaCppCandidate) HasToolCalls() bool {
+	return hasToolCalls(r.Parts())
+}
+
 type LlamaCppPart struct {
 	text          string
 	functionCalls []FunctionCall
@@ -381,6 +436,7 @@ func (p *LlamaCppPart) AsFunctionCalls() ([]FunctionCall, bool) {
 }
 
 func (c *LlamaCppChat) SetFunctionDefinitions(functionDefinitions []*FunctionDefinition) error {
+	c.functionDefinitions = functionDefinitions
 	var tools []llamacppTool
 	for _, functionDefinition := range functionDefinitions {
 		tools = append(tools, toLlamacppTool(functionDefinition))
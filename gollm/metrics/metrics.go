@@ -0,0 +1,122 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package metrics exports Prometheus metrics for the token usage and latency of gollm clients.
+package metrics
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/gollm"
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/klog/v2"
+)
+
+// Metrics holds the Prometheus collectors for gollm requests.
+type Metrics struct {
+	inputTokens    *prometheus.CounterVec
+	outputTokens   *prometheus.CounterVec
+	requestLatency *prometheus.HistogramVec
+}
+
+var labels = []string{"provider", "model", "stream"}
+
+// New creates the collectors and registers them with reg. If the collectors
+// are already registered with reg, the registered ones are reused, so that
+// several clients can share a registry.
+func New(reg prometheus.Registerer) (*Metrics, error) {
+	m := &Metrics{
+		inputTokens: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "gollm_input_tokens_total",
+			Help: "Number of input tokens sent to the model.",
+		}, labels),
+		outputTokens: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "gollm_output_tokens_total",
+			Help: "Number of output tokens generated by the model.",
+		}, labels),
+		requestLatency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "gollm_request_duration_seconds",
+			Help:    "Latency of model requests, until the full response has been received.",
+			Buckets: prometheus.ExponentialBuckets(0.1, 2, 10),
+		}, append(labels, "error")),
+	}
+
+	var err error
+	if m.inputTokens, err = register(reg, m.inputTokens); err != nil {
+		return nil, err
+	}
+	if m.outputTokens, err = register(reg, m.outputTokens); err != nil {
+		return nil, err
+	}
+	if m.requestLatency, err = register(reg, m.requestLatency); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// register registers c with reg, returning the already registered collector if there is one.
+func register[T prometheus.Collector](reg prometheus.Registerer, c T) (T, error) {
+	if err := reg.Register(c); err != nil {
+		var alreadyRegistered prometheus.AlreadyRegisteredError
+		if errors.As(err, &alreadyRegistered) {
+			if existing, ok := alreadyRegistered.ExistingCollector.(T); ok {
+				return existing, nil
+			}
+		}
+		return c, err
+	}
+	return c, nil
+}
+
+// UsageCallback returns a gollm.UsageCallback that counts input and output tokens.
+func (m *Metrics) UsageCallback() gollm.UsageCallback {
+	return func(info gollm.RequestInfo, usage *gollm.Usage) {
+		values := labelValues(info)
+		m.inputTokens.WithLabelValues(values...).Add(float64(usage.InputTokens))
+		m.outputTokens.WithLabelValues(values...).Add(float64(usage.OutputTokens))
+	}
+}
+
+// Interceptor returns a gollm.Interceptor that records request latency.
+func (m *Metrics) Interceptor() gollm.Interceptor {
+	return func(ctx context.Context, info gollm.RequestInfo) func(err error) {
+		start := time.Now()
+		return func(err error) {
+			values := append(labelValues(info), strconv.FormatBool(err != nil))
+			m.requestLatency.WithLabelValues(values...).Observe(time.Since(start).Seconds())
+		}
+	}
+}
+
+func labelValues(info gollm.RequestInfo) []string {
+	return []string{info.Provider, info.Model, strconv.FormatBool(info.Stream)}
+}
+
+// WithMetrics returns a gollm.Option that records the client's token usage and
+// request latency in collectors registered with reg.
+// Registration errors are logged, and the client is created without metrics.
+func WithMetrics(reg prometheus.Registerer) gollm.Option {
+	return func(o *gollm.ClientOptions) {
+		m, err := New(reg)
+		if err != nil {
+			klog.Warningf("not recording gollm metrics: %v", err)
+			return
+		}
+		o.Interceptors = append(o.Interceptors, m.Interceptor())
+		o.UsageCallbacks = append(o.UsageCallbacks, m.UsageCallback())
+	}
+}
@@ -0,0 +1,133 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"context"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/gollm"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// fakeClient is a gollm.Client whose chats return a fixed usage.
+type fakeClient struct {
+	gollm.Client
+}
+
+func (c *fakeClient) Name() string {
+	return "metricstest"
+}
+
+func (c *fakeClient) StartChat(systemPrompt, model string) gollm.Chat {
+	return &fakeChat{}
+}
+
+type fakeChat struct {
+	gollm.Chat
+}
+
+func (c *fakeChat) Send(ctx context.Context, contents ...any) (gollm.ChatResponse, error) {
+	return &fakeResponse{usage: &gollm.Usage{InputTokens: 10, OutputTokens: 4, TotalTokens: 14}}, nil
+}
+
+func (c *fakeChat) SendStreaming(ctx context.Context, contents ...any) (gollm.ChatResponseIterator, error) {
+	return func(yield func(gollm.ChatResponse, error) bool) {
+		// Only the final chunk of a stream reports usage.
+		if !yield(&fakeResponse{}, nil) {
+			return
+		}
+		yield(&fakeResponse{usage: &gollm.Usage{InputTokens: 7, OutputTokens: 3, TotalTokens: 10}}, nil)
+	}, nil
+}
+
+type fakeResponse struct {
+	usage *gollm.Usage
+}
+
+func (r *fakeResponse) UsageMetadata() any {
+	return r.usage
+}
+
+func (r *fakeResponse) Candidates() []gollm.Candidate {
+	return nil
+}
+
+func (r *fakeResponse) RequiresToolCall() bool {
+	return false
+}
+
+func init() {
+	if err := gollm.RegisterProvider("metricstest", func(ctx context.Context, opts gollm.ClientOptions) (gollm.Client, error) {
+		return &fakeClient{}, nil
+	}); err != nil {
+		panic(err)
+	}
+}
+
+func TestWithMetrics(t *testing.T) {
+	ctx := context.Background()
+	reg := prometheus.NewRegistry()
+
+	client, err := gollm.NewClient(ctx, "metricstest", WithMetrics(reg))
+	if err != nil {
+		t.Fatalf("creating client: %v", err)
+	}
+	chat := client.StartChat("", "test-model")
+
+	if _, err := chat.Send(ctx, "hello"); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	stream, err := chat.SendStreaming(ctx, "hello")
+	if err != nil {
+		t.Fatalf("SendStreaming failed: %v", err)
+	}
+	for _, err := range stream {
+		if err != nil {
+			t.Fatalf("reading stream: %v", err)
+		}
+	}
+
+	// A second client sharing the registry reuses the same collectors.
+	if _, err := gollm.NewClient(ctx, "metricstest", WithMetrics(reg)); err != nil {
+		t.Fatalf("creating second client: %v", err)
+	}
+
+	m, err := New(reg)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	tests := []struct {
+		name      string
+		collector prometheus.Collector
+		want      float64
+	}{
+		{"input tokens", m.inputTokens.WithLabelValues("metricstest", "test-model", "false"), 10},
+		{"output tokens", m.outputTokens.WithLabelValues("metricstest", "test-model", "false"), 4},
+		{"streamed input tokens", m.inputTokens.WithLabelValues("metricstest", "test-model", "true"), 7},
+		{"streamed output tokens", m.outputTokens.WithLabelValues("metricstest", "test-model", "true"), 3},
+	}
+	for _, tt := range tests {
+		if got := testutil.ToFloat64(tt.collector); got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	// One latency observation per request, labelled by stream.
+	if got := testutil.CollectAndCount(m.requestLatency, "gollm_request_duration_seconds"); got != 2 {
+		t.Errorf("expected 2 latency series, got %d", got)
+	}
+}
@@ -47,10 +47,11 @@ type OllamaClient struct {
 }
 
 type OllamaChat struct {
-	client  *api.Client
-	model   string
-	history []api.Message
-	tools   []api.Tool
+	client              *api.Client
+	model               string
+	history             []api.Message
+	tools               []api.Tool
+	functionDefinitions []*FunctionDefinition
 }
 
 var _ Client = &OllamaClient{}
@@ -59,7 +60,7 @@ var _ Client = &OllamaClient{}
 // Supports custom HTTP client and skipVerifySSL via ClientOptions if the SDK supports it.
 func NewOllamaClient(ctx context.Context, opts ClientOptions) (*OllamaClient, error) {
 	// Create custom HTTP client with SSL verification option from client options
-	httpClient := createCustomHTTPClient(opts.SkipVerifySSL)
+	httpClient := createCustomHTTPClient(opts)
 	client := api.NewClient(envconfig.Host(), httpClient)
 
 	return &OllamaClient{
@@ -93,6 +94,21 @@ func (c *OllamaClient) GenerateCompletion(ctx context.Context, request *Completi
 	return ollamaResponse, nil
 }
 
+// GenerateCompletionStream streams a completion for the given prompt.
+func (c *OllamaClient) GenerateCompletionStream(ctx context.Context, req *CompletionRequest) (CompletionResponseIterator, error) {
+	return streamCompletionViaChat(ctx, c, req)
+}
+
+// Name returns "ollama", the name the Ollama provider is registered under.
+func (c *OllamaClient) Name() string {
+	return "ollama"
+}
+
+// Capabilities returns the features supported by the Ollama provider.
+func (c *OllamaClient) Capabilities() ProviderCapabilities {
+	return ProviderCapabilities{Tools: true}
+}
+
 func (c *OllamaClient) ListModels(ctx context.Context) ([]string, error) {
 	modelResponse, err := c.client.List(ctx)
 	if err != nil {
@@ -137,6 +153,9 @@ func (r *OllamaCompletionResponse) UsageMetadata() any {
 }
 
 func (c *OllamaChat) Send(ctx context.Context, contents ...any) (ChatResponse, error) {
+	if override, rest := takeModelOverride(contents); override != "" {
+		c.model, contents = string(override), rest
+	}
 	log := klog.FromContext(ctx)
 	for _, content := range contents {
 		switch v := content.(type) {
@@ -200,7 +219,15 @@ func (c *OllamaChat) IsRetryableError(err error) bool {
 	return false
 }
 
+// Validate checks the chat's function definitions without issuing a request.
+func (c *OllamaChat) Validate() error {
+	return validationResult(validateFunctionDefinitions(c.functionDefinitions))
+}
+
 func (c *OllamaChat) SendStreaming(ctx context.Context, contents ...any) (ChatResponseIterator, error) {
+	if override, rest := takeModelOverride(contents); override != "" {
+		c.model, contents = string(override), rest
+	}
 	// TODO: Implement streaming
 	response, err := c.Send(ctx, contents...)
 	if err != nil {
@@ -244,10 +271,25 @@ func (r *OllamaChatResponse) Candidates() []Candidate {
 	return cads
 }
 
+func (r *OllamaChatResponse) RequiresToolCall() bool {
+	return requiresToolCall(r.Candidates())
+}
+
 type OllamaCandidate struct {
 	parts []OllamaPart
 }
 
+// FinishReason returns why the model stopped generating the candidate.
+func (r *OllamaCandidate) FinishReason() FinishReason {
+	// The Ollama responses used here do not report why generation stopped
+	return FinishReasonUnspecified
+}
+
+// IsRefusal reports whether the model refused to respond or its response was filtered.
+func (r *OllamaCandidate) IsRefusal() bool {
+	return r.FinishReason() == FinishReasonContentFiltered
+}
+
 func (r *OllamaCandidate) String() string {
 	return r.parts[0].text
 }
@@ -263,6 +305,10 @@ func (r *OllamaCandidate) Parts() []Part {
 	return parts
 }
 
+func (r *OllamaCandidate) HasToolCalls() bool {
+	return hasToolCalls(r.Parts())
+}
+
 type OllamaPart struct {
 	text      string
 	toolCalls []api.ToolCall
@@ -290,6 +336,7 @@ func (p *OllamaPart) AsFunctionCalls() ([]FunctionCall, bool) {
 }
 
 func (c *OllamaChat) SetFunctionDefinitions(functionDefinitions []*FunctionDefinition) error {
+	c.functionDefinitions = functionDefinitions
 	var tools []api.Tool
 	for _, functionDefinition := range functionDefinitions {
 		tools = append(tools, fnDefToOllamaTool(functionDefinition))
@@ -66,6 +66,10 @@ func init() {
 // OpenAIClient implements the gollm.Client interface for OpenAI models.
 type OpenAIClient struct {
 	client openai.Client
+	// candidateCount is the number of candidates requested per chat response, if greater than one.
+	candidateCount int
+	// maxToolResultBytes, if positive, truncates the function call results sent to the model.
+	maxToolResultBytes int
 }
 
 // Ensure OpenAIClient implements the Client interface.
@@ -95,11 +99,13 @@ func NewOpenAIClient(ctx context.Context, opts ClientOptions) (*OpenAIClient, er
 	}
 
 	// Support custom HTTP client (e.g., skip SSL verification)
-	httpClient := createCustomHTTPClient(opts.SkipVerifySSL)
+	httpClient := createCustomHTTPClient(opts)
 	options = append(options, option.WithHTTPClient(httpClient))
 
 	return &OpenAIClient{
-		client: openai.NewClient(options...),
+		client:             openai.NewClient(options...),
+		candidateCount:     opts.CandidateCount,
+		maxToolResultBytes: opts.MaxToolResultBytes,
 	}, nil
 }
 
@@ -123,9 +129,11 @@ func (c *OpenAIClient) StartChat(systemPrompt, model string) Chat {
 	}
 
 	return &openAIChatSession{
-		client:  c.client,
-		history: history,
-		model:   selectedModel,
+		client:             c.client,
+		history:            history,
+		model:              selectedModel,
+		candidateCount:     c.candidateCount,
+		maxToolResultBytes: c.maxToolResultBytes,
 		// functionDefinitions and tools will be set later via SetFunctionDefinitions
 	}
 }
@@ -181,6 +189,21 @@ func (c *OpenAIClient) SetResponseSchema(schema *Schema) error {
 	return nil
 }
 
+// GenerateCompletionStream streams a completion for the given prompt.
+func (c *OpenAIClient) GenerateCompletionStream(ctx context.Context, req *CompletionRequest) (CompletionResponseIterator, error) {
+	return streamCompletionViaChat(ctx, c, req)
+}
+
+// Name returns "openai", the name the OpenAI provider is registered under.
+func (c *OpenAIClient) Name() string {
+	return "openai"
+}
+
+// Capabilities returns the features supported by the OpenAI provider.
+func (c *OpenAIClient) Capabilities() ProviderCapabilities {
+	return ProviderCapabilities{Tools: true, Streaming: true}
+}
+
 // ListModels returns a slice of strings with model IDs.
 // Note: This may not work with all OpenAI-compatible providers if they don't fully implement
 // the Models.List endpoint or return data in a different format.
@@ -206,6 +229,8 @@ type openAIChatSession struct {
 	model               string
 	functionDefinitions []*FunctionDefinition            // Stored in gollm format
 	tools               []openai.ChatCompletionToolParam // Stored in OpenAI format
+	candidateCount      int
+	maxToolResultBytes  int
 }
 
 // Ensure openAIChatSession implements the Chat interface.
@@ -241,6 +266,9 @@ func (cs *openAIChatSession) SetFunctionDefinitions(defs []*FunctionDefinition)
 
 // Send sends the user message(s), appends to history, and gets the LLM response.
 func (cs *openAIChatSession) Send(ctx context.Context, contents ...any) (ChatResponse, error) {
+	if override, rest := takeModelOverride(contents); override != "" {
+		cs.model, contents = string(override), rest
+	}
 	klog.V(1).InfoS("openAIChatSession.Send called", "model", cs.model, "history_len", len(cs.history))
 
 	// Process and append messages to history
@@ -256,6 +284,9 @@ func (cs *openAIChatSession) Send(ctx context.Context, contents ...any) (ChatRes
 	if len(cs.tools) > 0 {
 		chatReq.Tools = cs.tools
 	}
+	if cs.candidateCount > 1 {
+		chatReq.N = openai.Int(int64(cs.candidateCount))
+	}
 
 	// Call the OpenAI API
 	klog.V(1).InfoS("Sending request to OpenAI Chat API", "model", cs.model, "messages", len(chatReq.Messages), "tools", len(chatReq.Tools))
@@ -289,8 +320,16 @@ func (cs *openAIChatSession) Send(ctx context.Context, contents ...any) (ChatRes
 
 // SendStreaming sends the user message(s) and returns an iterator for the LLM response stream.
 func (cs *openAIChatSession) SendStreaming(ctx context.Context, contents ...any) (ChatResponseIterator, error) {
+	if override, rest := takeModelOverride(contents); override != "" {
+		cs.model, contents = string(override), rest
+	}
 	klog.V(1).InfoS("Starting OpenAI streaming request", "model", cs.model)
 
+	// The stream is assembled into a single message, so there is no way to return several candidates.
+	if cs.candidateCount > 1 {
+		return nil, fmt.Errorf("streaming returns a single candidate per response, %d candidates are not supported", cs.candidateCount)
+	}
+
 	// Process and append messages to history
 	if err := cs.addContentsToHistory(contents); err != nil {
 		return nil, err
@@ -423,6 +462,11 @@ func (cs *openAIChatSession) IsRetryableError(err error) bool {
 	return DefaultIsRetryableError(err)
 }
 
+// Validate checks the chat's function definitions without issuing a request.
+func (cs *openAIChatSession) Validate() error {
+	return validationResult(validateFunctionDefinitions(cs.functionDefinitions))
+}
+
 func (cs *openAIChatSession) Initialize(messages []*api.Message) error {
 	klog.Warning("chat history persistence is not supported for provider 'openai', using in-memory chat history")
 	return nil
@@ -455,6 +499,10 @@ func (r *openAIChatResponse) Candidates() []Candidate {
 	return candidates
 }
 
+func (r *openAIChatResponse) RequiresToolCall() bool {
+	return requiresToolCall(r.Candidates())
+}
+
 type openAICandidate struct {
 	openaiChoice *openai.ChatCompletionChoice
 }
@@ -478,6 +526,38 @@ func (c *openAICandidate) Parts() []Part {
 	return parts
 }
 
+func (c *openAICandidate) HasToolCalls() bool {
+	return hasToolCalls(c.Parts())
+}
+
+// openAIFinishReason normalizes the finish reason of an OpenAI-compatible chat completion choice.
+func openAIFinishReason(reason string) FinishReason {
+	switch reason {
+	case "":
+		return FinishReasonUnspecified
+	case "stop":
+		return FinishReasonStop
+	case "length":
+		return FinishReasonMaxTokens
+	case "tool_calls", "function_call":
+		return FinishReasonToolUse
+	case "content_filter":
+		return FinishReasonContentFiltered
+	default:
+		return FinishReasonOther
+	}
+}
+
+// FinishReason returns why the model stopped generating the candidate.
+func (c *openAICandidate) FinishReason() FinishReason {
+	return openAIFinishReason(c.openaiChoice.FinishReason)
+}
+
+// IsRefusal reports whether the model refused to respond or its response was filtered.
+func (c *openAICandidate) IsRefusal() bool {
+	return c.FinishReason() == FinishReasonContentFiltered
+}
+
 // String provides a simple string representation for logging/debugging.
 func (c *openAICandidate) String() string {
 	if c.openaiChoice == nil {
@@ -532,6 +612,10 @@ func (r *openAIChatStreamResponse) Candidates() []Candidate {
 	return candidates
 }
 
+func (r *openAIChatStreamResponse) RequiresToolCall() bool {
+	return requiresToolCall(r.Candidates())
+}
+
 // Update openAIStreamCandidate to handle delta content
 type openAIStreamCandidate struct {
 	streamChoice openai.ChatCompletionChunkChoice
@@ -560,6 +644,10 @@ func (c *openAIStreamCandidate) Parts() []Part {
 	return parts
 }
 
+func (c *openAIStreamCandidate) HasToolCalls() bool {
+	return hasToolCalls(c.Parts())
+}
+
 // Add UsageMetadata implementation
 func (r *openAIChatStreamResponse) UsageMetadata() any {
 	if r.accumulator.Usage.TotalTokens > 0 {
@@ -568,6 +656,16 @@ func (r *openAIChatStreamResponse) UsageMetadata() any {
 	return nil
 }
 
+// FinishReason returns why the model stopped generating the candidate.
+func (c *openAIStreamCandidate) FinishReason() FinishReason {
+	return openAIFinishReason(c.streamChoice.FinishReason)
+}
+
+// IsRefusal reports whether the model refused to respond or its response was filtered.
+func (c *openAIStreamCandidate) IsRefusal() bool {
+	return c.FinishReason() == FinishReasonContentFiltered
+}
+
 // Add String implementation
 func (c *openAIStreamCandidate) String() string {
 	return fmt.Sprintf("StreamingCandidate(Content: %q, ToolCalls: %d)",
@@ -641,6 +739,8 @@ func convertSchemaForOpenAI(schema *Schema) (*Schema, error) {
 
 	case TypeString:
 		validated.Type = TypeString
+		validated.Enum = schema.Enum
+		validated.Format = schema.Format
 
 	case TypeNumber:
 		validated.Type = TypeNumber
@@ -781,7 +881,11 @@ func (cs *openAIChatSession) addContentsToHistory(contents []any) error {
 				klog.Errorf("Failed to marshal function call result: %v", err)
 				return fmt.Errorf("failed to marshal function call result %q: %w", c.Name, err)
 			}
-			cs.history = append(cs.history, openai.ToolMessage(string(resultJSON), c.ID))
+			content, truncated := truncateToolResult(resultJSON, cs.maxToolResultBytes)
+			if truncated {
+				klog.V(2).Infof("Truncated tool call result %s from %d bytes", c.ID, len(resultJSON))
+			}
+			cs.history = append(cs.history, openai.ToolMessage(content, c.ID))
 		default:
 			klog.Warningf("Unhandled content type: %T", content)
 			return fmt.Errorf("unhandled content type: %T", content)
@@ -15,7 +15,13 @@
 package gollm
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
 	"testing"
 
 	"github.com/openai/openai-go"
@@ -604,3 +610,118 @@ func TestConvertToolCallsToFunctionCalls(t *testing.T) {
 		})
 	}
 }
+
+func TestOpenAIExtraHeaders(t *testing.T) {
+	var requests []http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Header.Clone())
+		var body struct {
+			Stream bool `json:"stream"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		if body.Stream {
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprint(w, `data: {"id":"1","object":"chat.completion.chunk","model":"gpt-4o","choices":[{"index":0,"delta":{"role":"assistant","content":"hi"}}]}`+"\n\n")
+			fmt.Fprint(w, "data: [DONE]\n\n")
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"id":"1","object":"chat.completion","model":"gpt-4o","choices":[{"index":0,"finish_reason":"stop","message":{"role":"assistant","content":"hi"}}]}`)
+	}))
+	defer server.Close()
+
+	oldKey, oldEndpoint := openAIAPIKey, openAIEndpoint
+	openAIAPIKey, openAIEndpoint = "test-key", server.URL
+	defer func() { openAIAPIKey, openAIEndpoint = oldKey, oldEndpoint }()
+
+	opts := ClientOptions{}
+	WithHTTPHeaders(map[string]string{
+		"X-Tenant-ID":   "tenant-1",
+		"authorization": "Bearer stolen",
+		"Content-Type":  "text/plain",
+	})(&opts)
+	client, err := NewOpenAIClient(context.Background(), opts)
+	if err != nil {
+		t.Fatalf("creating client: %v", err)
+	}
+
+	chat := client.StartChat("", "gpt-4o")
+	if _, err := chat.Send(context.Background(), "hello"); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	stream, err := chat.SendStreaming(context.Background(), "hello")
+	if err != nil {
+		t.Fatalf("SendStreaming failed: %v", err)
+	}
+	for _, err := range stream {
+		if err != nil {
+			t.Fatalf("stream error: %v", err)
+		}
+	}
+
+	if len(requests) != 2 {
+		t.Fatalf("expected 2 requests, got %d", len(requests))
+	}
+	for i, header := range requests {
+		if got := header.Get("X-Tenant-ID"); got != "tenant-1" {
+			t.Errorf("request %d: expected X-Tenant-ID %q, got %q", i, "tenant-1", got)
+		}
+		if got := header.Get("Authorization"); got != "Bearer test-key" {
+			t.Errorf("request %d: expected Authorization to be kept, got %q", i, got)
+		}
+		if got := header.Get("Content-Type"); !strings.HasPrefix(got, "application/json") {
+			t.Errorf("request %d: expected Content-Type to be kept, got %q", i, got)
+		}
+	}
+}
+
+func TestOpenAICandidateCount(t *testing.T) {
+	var requested int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			N int `json:"n"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		requested = body.N
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"id":"1","object":"chat.completion","model":"gpt-4o","choices":[`+
+			`{"index":0,"finish_reason":"stop","message":{"role":"assistant","content":"first"}},`+
+			`{"index":1,"finish_reason":"stop","message":{"role":"assistant","content":"second"}}]}`)
+	}))
+	defer server.Close()
+
+	oldKey, oldEndpoint := openAIAPIKey, openAIEndpoint
+	openAIAPIKey, openAIEndpoint = "test-key", server.URL
+	defer func() { openAIAPIKey, openAIEndpoint = oldKey, oldEndpoint }()
+
+	opts := ClientOptions{}
+	WithCandidateCount(2)(&opts)
+	client, err := NewOpenAIClient(context.Background(), opts)
+	if err != nil {
+		t.Fatalf("creating client: %v", err)
+	}
+
+	chat := client.StartChat("", "gpt-4o")
+	response, err := chat.Send(context.Background(), "hello")
+	if err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	if requested != 2 {
+		t.Errorf("expected n=2 in the request, got %d", requested)
+	}
+	var texts []string
+	for _, candidate := range response.Candidates() {
+		for _, part := range candidate.Parts() {
+			if text, ok := part.AsText(); ok {
+				texts = append(texts, text)
+			}
+		}
+	}
+	if want := []string{"first", "second"}; !slices.Equal(texts, want) {
+		t.Errorf("expected candidates %q, got %q", want, texts)
+	}
+
+	if _, err := chat.SendStreaming(context.Background(), "hello"); err == nil {
+		t.Error("expected streaming with several candidates to fail")
+	}
+}
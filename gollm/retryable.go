@@ -0,0 +1,133 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gollm
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+)
+
+// WithRetryableStatusCodes adds HTTP status codes to those that chats consider retryable,
+// for gateways that use non-standard codes, such as 430 for throttling.
+// The provider's own retryable errors are still retried.
+func WithRetryableStatusCodes(codes ...int) Option {
+	return func(o *ClientOptions) {
+		o.RetryableStatusCodes = append(o.RetryableStatusCodes, codes...)
+	}
+}
+
+// WithInitialBackoff sets the backoff before the first retry of providers that retry failed
+// requests themselves, such as Bedrock. It doubles after every retry, up to the maximum set
+// with WithMaxBackoff.
+func WithInitialBackoff(backoff time.Duration) Option {
+	return func(o *ClientOptions) {
+		o.RetryInitialBackoff = backoff
+	}
+}
+
+// WithMaxBackoff caps the backoff between the retries of providers that retry failed
+// requests themselves, such as Bedrock. The default cap is 30s.
+func WithMaxBackoff(backoff time.Duration) Option {
+	return func(o *ClientOptions) {
+		o.RetryMaxBackoff = backoff
+	}
+}
+
+// retryBackoff returns the RetryConfig whose backoff the providers that retry failed
+// requests themselves use, with the bounds set in opts or the default ones.
+func retryBackoff(opts ClientOptions) RetryConfig {
+	config := DefaultRetryConfig
+	config.MaxBackoff = defaultRetryMaxBackoff
+	if opts.RetryInitialBackoff > 0 {
+		config.InitialBackoff = opts.RetryInitialBackoff
+	}
+	if opts.RetryMaxBackoff > 0 {
+		config.MaxBackoff = opts.RetryMaxBackoff
+	}
+	config.MaxBackoff = max(config.MaxBackoff, config.InitialBackoff)
+	return config
+}
+
+// httpStatusCode returns the HTTP status code of the response that caused err, if known.
+// This covers the APIError of the HTTP providers and the response errors of the AWS SDK.
+func httpStatusCode(err error) (int, bool) {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode, true
+	}
+	var responseErr interface{ HTTPStatusCode() int }
+	if errors.As(err, &responseErr) {
+		return responseErr.HTTPStatusCode(), true
+	}
+	return 0, false
+}
+
+// retryableStatusClient is a Client whose chats also retry errors with the given status codes.
+type retryableStatusClient struct {
+	Client
+
+	codes []int
+}
+
+// withRetryableStatusCodes wraps client so that its chats retry opts.RetryableStatusCodes,
+// or returns it unchanged if there are none.
+func withRetryableStatusCodes(client Client, opts ClientOptions) Client {
+	if len(opts.RetryableStatusCodes) == 0 {
+		return client
+	}
+	return &retryableStatusClient{
+		Client: client,
+		codes:  slices.Clone(opts.RetryableStatusCodes),
+	}
+}
+
+func (c *retryableStatusClient) HealthCheck(ctx context.Context) error {
+	return HealthCheck(ctx, c.Client)
+}
+
+func (c *retryableStatusClient) StartChat(systemPrompt, model string) Chat {
+	return c.wrapChat(c.Client.StartChat(systemPrompt, model))
+}
+
+func (c *retryableStatusClient) StartChatWithOptions(systemPrompt, model string, opts ...ChatOption) (Chat, error) {
+	underlying, err := StartChatWithOptions(c.Client, systemPrompt, model, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return c.wrapChat(underlying), nil
+}
+
+func (c *retryableStatusClient) wrapChat(underlying Chat) Chat {
+	return preserveSerializable(&retryableStatusChat{
+		Chat:  underlying,
+		codes: c.codes,
+	}, underlying)
+}
+
+// retryableStatusChat is a Chat that also retries errors with the status codes of its client.
+type retryableStatusChat struct {
+	Chat
+
+	codes []int
+}
+
+func (c *retryableStatusChat) IsRetryableError(err error) bool {
+	if code, ok := httpStatusCode(err); ok && slices.Contains(c.codes, code) {
+		return true
+	}
+	return c.Chat.IsRetryableError(err)
+}
@@ -0,0 +1,139 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gollm
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestRetryableStatusCodes(t *testing.T) {
+	tests := []struct {
+		name         string
+		status       int
+		wantAttempts int
+	}{
+		{name: "custom code", status: 430, wantAttempts: 2},
+		{name: "default code", status: http.StatusServiceUnavailable, wantAttempts: 2},
+		{name: "not listed", status: 431, wantAttempts: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			underlying := &fakeChat{errs: []error{&APIError{StatusCode: tt.status, Message: "throttled"}}}
+			client := withRetryableStatusCodes(&fakeChatClient{chat: underlying}, ClientOptions{RetryableStatusCodes: []int{430}})
+			chat := NewRetryChat(client.StartChat("", "model"), RetryConfig{MaxAttempts: 3, BackoffFactor: 1})
+
+			_, err := chat.Send(context.Background(), "hello")
+			if len(underlying.attempts) != tt.wantAttempts {
+				t.Errorf("expected %d attempts, got %d", tt.wantAttempts, len(underlying.attempts))
+			}
+			if wantErr := tt.wantAttempts == 1; (err != nil) != wantErr {
+				t.Errorf("expected error %v, got %v", wantErr, err)
+			}
+		})
+	}
+}
+
+func TestRetryConfigBackoff(t *testing.T) {
+	config := RetryConfig{InitialBackoff: 200 * time.Millisecond, MaxBackoff: time.Second, BackoffFactor: 2}
+	var got []time.Duration
+	for attempt := 1; attempt <= 5; attempt++ {
+		got = append(got, config.backoff(attempt))
+	}
+	want := []time.Duration{200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond, time.Second, time.Second}
+	if !slices.Equal(got, want) {
+		t.Errorf("expected backoff %v, got %v", want, got)
+	}
+
+	// Jitter stays within the bounds.
+	config.Jitter = true
+	for attempt := 1; attempt <= 5; attempt++ {
+		for range 100 {
+			if backoff := config.backoff(attempt); backoff < want[attempt-1] || backoff > config.MaxBackoff {
+				t.Fatalf("attempt %d: expected a backoff between %v and %v, got %v", attempt, want[attempt-1], config.MaxBackoff, backoff)
+			}
+		}
+	}
+}
+
+func TestRetryBackoffOptions(t *testing.T) {
+	tests := []struct {
+		name        string
+		opts        []Option
+		wantInitial time.Duration
+		wantMax     time.Duration
+	}{
+		{name: "defaults", wantInitial: 200 * time.Millisecond, wantMax: 30 * time.Second},
+		{
+			name:        "tuned",
+			opts:        []Option{WithInitialBackoff(time.Second), WithMaxBackoff(5 * time.Second)},
+			wantInitial: time.Second,
+			wantMax:     5 * time.Second,
+		},
+		{
+			name:        "shorter cap",
+			opts:        []Option{WithMaxBackoff(2 * time.Second)},
+			wantInitial: 200 * time.Millisecond,
+			wantMax:     2 * time.Second,
+		},
+		{
+			name:        "max below initial",
+			opts:        []Option{WithInitialBackoff(time.Minute)},
+			wantInitial: time.Minute,
+			wantMax:     time.Minute,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var opts ClientOptions
+			for _, opt := range tt.opts {
+				opt(&opts)
+			}
+			config := retryBackoff(opts)
+			if config.InitialBackoff != tt.wantInitial || config.MaxBackoff != tt.wantMax {
+				t.Errorf("expected backoff between %v and %v, got %v and %v", tt.wantInitial, tt.wantMax, config.InitialBackoff, config.MaxBackoff)
+			}
+		})
+	}
+}
+
+func TestRetryStopsBeforeDeadline(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	unavailable := &APIError{StatusCode: http.StatusServiceUnavailable, Message: "unavailable"}
+
+	attempts := 0
+	start := time.Now()
+	_, err := Retry(ctx, RetryConfig{MaxAttempts: 3, InitialBackoff: time.Minute, BackoffFactor: 2}, DefaultIsRetryableError,
+		func(ctx context.Context) (any, error) {
+			attempts++
+			return nil, unavailable
+		})
+	if !errors.Is(err, unavailable) {
+		t.Errorf("expected the error of the attempt, got %v", err)
+	}
+	if attempts != 1 {
+		t.Errorf("expected 1 attempt, got %d", attempts)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("expected Retry to return without waiting for the deadline, took %v", elapsed)
+	}
+}
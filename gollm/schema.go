@@ -15,7 +15,11 @@
 package gollm
 
 import (
+	"fmt"
+	"math"
 	"reflect"
+	"slices"
+	"sort"
 	"strings"
 
 	"k8s.io/klog/v2"
@@ -68,3 +72,175 @@ func BuildSchemaFor(t reflect.Type) *Schema {
 
 	return out
 }
+
+// ArgumentValidationError is returned by ValidateArguments when function-call
+// arguments don't conform to the function's declared parameters.
+type ArgumentValidationError struct {
+	// Function is the name of the function whose arguments were validated.
+	Function string
+	// Problems describes each mismatch, prefixed with the path of the offending field.
+	Problems []string
+}
+
+func (e *ArgumentValidationError) Error() string {
+	return fmt.Sprintf("invalid arguments for function %q: %s", e.Function, strings.Join(e.Problems, "; "))
+}
+
+// ValidateArguments checks the arguments of a function call against the function's
+// declared parameter Schema: required fields, value types and array item types.
+// It returns an *ArgumentValidationError describing every mismatch found.
+func ValidateArguments(def *FunctionDefinition, args map[string]any) error {
+	if def == nil || def.Parameters == nil {
+		return nil
+	}
+
+	var problems []string
+	var obj any = args
+	if args == nil {
+		obj = map[string]any{}
+	}
+	validateValue(def.Parameters, obj, "", &problems)
+
+	if len(problems) != 0 {
+		return &ArgumentValidationError{Function: def.Name, Problems: problems}
+	}
+	return nil
+}
+
+// validateValue appends a problem for each way value does not conform to schema.
+func validateValue(schema *Schema, value any, path string, problems *[]string) {
+	if schema == nil {
+		return
+	}
+
+	describe := func(format string, args ...any) string {
+		msg := fmt.Sprintf(format, args...)
+		if path == "" {
+			return msg
+		}
+		return fmt.Sprintf("%s: %s", path, msg)
+	}
+
+	if schema.Type != "" && !valueHasType(value, schema.Type) {
+		*problems = append(*problems, describe("expected %s, got %s", schema.Type, describeValueType(value)))
+		return
+	}
+
+	if len(schema.Enum) != 0 {
+		if str, ok := value.(string); ok && !slices.Contains(schema.Enum, str) {
+			*problems = append(*problems, describe("value %q is not one of %q", str, schema.Enum))
+		}
+	}
+
+	switch schema.Type {
+	case TypeObject:
+		obj, _ := value.(map[string]any)
+		for _, name := range schema.Required {
+			if _, ok := obj[name]; !ok {
+				*problems = append(*problems, describe("missing required field %q", name))
+			}
+		}
+		names := make([]string, 0, len(obj))
+		for name := range obj {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			propSchema, ok := schema.Properties[name]
+			if !ok {
+				if schema.AdditionalProperties != nil && !*schema.AdditionalProperties {
+					*problems = append(*problems, describe("unexpected field %q", name))
+				}
+				continue
+			}
+			validateValue(propSchema, obj[name], joinFieldPath(path, name), problems)
+		}
+	case TypeArray:
+		items := reflect.ValueOf(value)
+		if schema.MinItems != nil && int64(items.Len()) < *schema.MinItems {
+			*problems = append(*problems, describe("expected at least %d items, got %d", *schema.MinItems, items.Len()))
+		}
+		if schema.MaxItems != nil && int64(items.Len()) > *schema.MaxItems {
+			*problems = append(*problems, describe("expected at most %d items, got %d", *schema.MaxItems, items.Len()))
+		}
+		for i := 0; i < items.Len(); i++ {
+			validateValue(schema.Items, items.Index(i).Interface(), fmt.Sprintf("%s[%d]", path, i), problems)
+		}
+	}
+}
+
+// valueHasType returns true if the (JSON-decoded) value is of the given schema type.
+func valueHasType(value any, schemaType SchemaType) bool {
+	switch schemaType {
+	case TypeObject:
+		_, ok := value.(map[string]any)
+		return ok
+	case TypeArray:
+		if value == nil {
+			return false
+		}
+		kind := reflect.TypeOf(value).Kind()
+		return kind == reflect.Slice || kind == reflect.Array
+	case TypeString:
+		_, ok := value.(string)
+		return ok
+	case TypeBoolean:
+		_, ok := value.(bool)
+		return ok
+	case TypeNumber:
+		_, ok := toFloat(value)
+		return ok
+	case TypeInteger:
+		f, ok := toFloat(value)
+		return ok && f == math.Trunc(f)
+	default:
+		// Unknown types are not validated
+		return true
+	}
+}
+
+// toFloat converts any Go numeric value to a float64.
+func toFloat(value any) (float64, bool) {
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	default:
+		return 0, false
+	}
+}
+
+// describeValueType names the JSON type of a value, for error messages.
+func describeValueType(value any) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case map[string]any:
+		return "object"
+	}
+	if f, ok := toFloat(value); ok {
+		if f == math.Trunc(f) {
+			return "integer"
+		}
+		return "number"
+	}
+	if kind := reflect.TypeOf(value).Kind(); kind == reflect.Slice || kind == reflect.Array {
+		return "array"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+func joinFieldPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
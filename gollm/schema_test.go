@@ -0,0 +1,230 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gollm
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestValidateArguments(t *testing.T) {
+	def := &FunctionDefinition{
+		Name: "scale",
+		Parameters: &Schema{
+			Type: TypeObject,
+			Properties: map[string]*Schema{
+				"name":      {Type: TypeString},
+				"strategy":  {Type: TypeString, Enum: []string{"Recreate", "RollingUpdate"}},
+				"replicas":  {Type: TypeInteger},
+				"dryRun":    {Type: TypeBoolean},
+				"ratio":     {Type: TypeNumber},
+				"selectors": {Type: TypeArray, Items: &Schema{Type: TypeString}},
+				"commands":  {Type: TypeArray, Items: &Schema{Type: TypeString}, MinItems: ptrTo[int64](1), MaxItems: ptrTo[int64](2)},
+				"target": {
+					Type: TypeObject,
+					Properties: map[string]*Schema{
+						"namespace": {Type: TypeString},
+					},
+					Required:             []string{"namespace"},
+					AdditionalProperties: ptrTo(false),
+				},
+			},
+			Required: []string{"name", "replicas"},
+		},
+	}
+
+	tests := []struct {
+		name         string
+		args         string
+		wantProblems []string
+	}{
+		{
+			name: "valid",
+			args: `{"name": "web", "replicas": 3, "strategy": "Recreate", "dryRun": true, "ratio": 0.5, "selectors": ["app=web"], "target": {"namespace": "default"}}`,
+		},
+		{
+			name: "valid with only required fields",
+			args: `{"name": "web", "replicas": 0}`,
+		},
+		{
+			name:         "missing required field",
+			args:         `{"name": "web"}`,
+			wantProblems: []string{`missing required field "replicas"`},
+		},
+		{
+			name:         "wrong type",
+			args:         `{"name": "web", "replicas": "three"}`,
+			wantProblems: []string{"replicas: expected integer, got string"},
+		},
+		{
+			name:         "fractional integer",
+			args:         `{"name": "web", "replicas": 1.5}`,
+			wantProblems: []string{"replicas: expected integer, got number"},
+		},
+		{
+			name:         "wrong array item type",
+			args:         `{"name": "web", "replicas": 1, "selectors": ["app=web", 7]}`,
+			wantProblems: []string{"selectors[1]: expected string, got integer"},
+		},
+		{
+			name:         "value not in enum",
+			args:         `{"name": "web", "replicas": 1, "strategy": "BlueGreen"}`,
+			wantProblems: []string{`strategy: value "BlueGreen" is not one of ["Recreate" "RollingUpdate"]`},
+		},
+		{
+			name:         "too few items",
+			args:         `{"name": "web", "replicas": 1, "commands": []}`,
+			wantProblems: []string{"commands: expected at least 1 items, got 0"},
+		},
+		{
+			name:         "too many items",
+			args:         `{"name": "web", "replicas": 1, "commands": ["a", "b", "c"]}`,
+			wantProblems: []string{"commands: expected at most 2 items, got 3"},
+		},
+		{
+			name:         "additional property not allowed",
+			args:         `{"name": "web", "replicas": 1, "target": {"namespace": "default", "cluster": "prod"}}`,
+			wantProblems: []string{`target: unexpected field "cluster"`},
+		},
+		{
+			name:         "nested object",
+			args:         `{"name": "web", "replicas": 1, "target": {"namespace": false}}`,
+			wantProblems: []string{"target.namespace: expected string, got boolean"},
+		},
+		{
+			name: "multiple problems",
+			args: `{"replicas": 1, "dryRun": "yes", "target": {}}`,
+			wantProblems: []string{
+				`missing required field "name"`,
+				"dryRun: expected boolean, got string",
+				`target: missing required field "namespace"`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var args map[string]any
+			if err := json.Unmarshal([]byte(tt.args), &args); err != nil {
+				t.Fatalf("parsing test arguments: %v", err)
+			}
+
+			err := ValidateArguments(def, args)
+			if len(tt.wantProblems) == 0 {
+				if err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				return
+			}
+
+			var validationErr *ArgumentValidationError
+			if !errors.As(err, &validationErr) {
+				t.Fatalf("expected *ArgumentValidationError, got %v", err)
+			}
+			if validationErr.Function != "scale" {
+				t.Errorf("expected function %q, got %q", "scale", validationErr.Function)
+			}
+			if !reflect.DeepEqual(validationErr.Problems, tt.wantProblems) {
+				t.Errorf("expected problems %q, got %q", tt.wantProblems, validationErr.Problems)
+			}
+		})
+	}
+}
+
+func TestToRawSchemaEnumAndFormat(t *testing.T) {
+	schema := &Schema{
+		Type: TypeObject,
+		Properties: map[string]*Schema{
+			"level": {Type: TypeString, Enum: []string{"info", "warning"}},
+			"since": {Type: TypeString, Format: "date-time"},
+		},
+	}
+
+	raw, err := schema.ToRawSchema()
+	if err != nil {
+		t.Fatalf("ToRawSchema failed: %v", err)
+	}
+
+	var roundTripped Schema
+	if err := json.Unmarshal(raw, &roundTripped); err != nil {
+		t.Fatalf("unmarshaling raw schema: %v", err)
+	}
+	if !reflect.DeepEqual(&roundTripped, schema) {
+		t.Errorf("expected schema to survive the round trip, got %+v", roundTripped)
+	}
+}
+
+func TestSetResponseSchemaNilClears(t *testing.T) {
+	schema := &Schema{
+		Type:       TypeObject,
+		Properties: map[string]*Schema{"name": {Type: TypeString}},
+	}
+
+	gemini := &GoogleAIClient{}
+	if err := gemini.SetResponseSchema(schema); err != nil {
+		t.Fatalf("SetResponseSchema failed: %v", err)
+	}
+	if gemini.CurrentSchema() != schema {
+		t.Errorf("expected the schema to be tracked, got %v", gemini.CurrentSchema())
+	}
+	if config := gemini.StartChat("", "gemini-2.5-pro").(*GeminiChat).genConfig; config.ResponseSchema == nil {
+		t.Error("expected the chat to be constrained by the schema")
+	}
+	if err := gemini.SetResponseSchema(nil); err != nil {
+		t.Fatalf("clearing the schema failed: %v", err)
+	}
+	if gemini.CurrentSchema() != nil {
+		t.Errorf("expected no schema after clearing, got %v", gemini.CurrentSchema())
+	}
+	if config := gemini.StartChat("", "gemini-2.5-pro").(*GeminiChat).genConfig; config.ResponseSchema != nil || config.ResponseMIMEType != "text/plain" {
+		t.Errorf("expected an unconstrained chat after clearing, got schema %v and MIME type %q", config.ResponseSchema, config.ResponseMIMEType)
+	}
+
+	llamacpp := &LlamaCppClient{}
+	llamacpp.SetResponseSchema(schema)
+	if llamacpp.CurrentSchema() != schema || llamacpp.responseSchema == nil {
+		t.Errorf("expected the schema to be tracked, got %v", llamacpp.CurrentSchema())
+	}
+	llamacpp.SetResponseSchema(nil)
+	if llamacpp.CurrentSchema() != nil || llamacpp.responseSchema != nil {
+		t.Errorf("expected no schema after clearing, got %v", llamacpp.CurrentSchema())
+	}
+
+	bedrock := &BedrockClient{}
+	if err := bedrock.SetResponseSchema(schema); err != nil {
+		t.Fatalf("SetResponseSchema failed: %v", err)
+	}
+	if bedrock.CurrentSchema() != schema {
+		t.Errorf("expected the schema to be tracked, got %v", bedrock.CurrentSchema())
+	}
+	if chat := bedrock.StartChat("", "").(*bedrockChat); chat.responseTool == nil {
+		t.Error("expected the chat to respond with the response tool")
+	}
+	if err := bedrock.SetResponseSchema(nil); err != nil {
+		t.Fatalf("clearing the schema failed: %v", err)
+	}
+	if bedrock.CurrentSchema() != nil {
+		t.Errorf("expected no schema after clearing, got %v", bedrock.CurrentSchema())
+	}
+	if chat := bedrock.StartChat("", "").(*bedrockChat); chat.responseTool != nil {
+		t.Error("expected an unconstrained chat after clearing")
+	}
+	// Bedrock tool inputs, and so its response schemas, must be objects
+	if err := bedrock.SetResponseSchema(&Schema{Type: TypeString}); err == nil {
+		t.Error("expected Bedrock to reject a schema that is not an object")
+	}
+}
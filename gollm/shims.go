@@ -14,6 +14,11 @@
 
 package gollm
 
+import (
+	"context"
+	"strings"
+)
+
 func singletonChatResponseIterator(response ChatResponse) ChatResponseIterator {
 	return func(yield func(ChatResponse, error) bool) {
 		if !yield(response, nil) {
@@ -21,3 +26,52 @@ func singletonChatResponseIterator(response ChatResponse) ChatResponseIterator {
 		}
 	}
 }
+
+// streamCompletionViaChat implements GenerateCompletionStream for providers
+// without a native streaming completion API, by streaming a single-turn chat.
+func streamCompletionViaChat(ctx context.Context, client Client, req *CompletionRequest) (CompletionResponseIterator, error) {
+	stream, err := client.StartChat("", req.Model).SendStreaming(ctx, req.Prompt)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(yield func(CompletionResponse, error) bool) {
+		for response, err := range stream {
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			if response == nil {
+				return
+			}
+			if !yield(&chatCompletionChunk{chatResponse: response}, nil) {
+				return
+			}
+		}
+	}, nil
+}
+
+// chatCompletionChunk adapts a streamed ChatResponse to a CompletionResponse.
+type chatCompletionChunk struct {
+	chatResponse ChatResponse
+}
+
+var _ CompletionResponse = &chatCompletionChunk{}
+
+func (r *chatCompletionChunk) Response() string {
+	candidates := r.chatResponse.Candidates()
+	if len(candidates) == 0 {
+		return ""
+	}
+	var text strings.Builder
+	for _, part := range candidates[0].Parts() {
+		if s, ok := part.AsText(); ok {
+			text.WriteString(s)
+		}
+	}
+	return text.String()
+}
+
+func (r *chatCompletionChunk) UsageMetadata() any {
+	return r.chatResponse.UsageMetadata()
+}
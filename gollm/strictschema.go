@@ -0,0 +1,236 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gollm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// ErrSchemaViolation is matched, with errors.Is, by the *SchemaViolationError returned
+// in strict schema mode for a response that does not conform to the response schema.
+var ErrSchemaViolation = errors.New("response does not conform to the response schema")
+
+// SchemaViolationError is returned in strict schema mode for a response that is not
+// JSON, or that does not conform to the schema set with SetResponseSchema.
+type SchemaViolationError struct {
+	// Response is the text of the response.
+	Response string
+	// Problems describes each mismatch, prefixed with the path of the offending field.
+	Problems []string
+}
+
+func (e *SchemaViolationError) Error() string {
+	return fmt.Sprintf("%v: %s", ErrSchemaViolation, strings.Join(e.Problems, "; "))
+}
+
+func (e *SchemaViolationError) Unwrap() error {
+	return ErrSchemaViolation
+}
+
+// WithResponseSchemaStrict validates the responses of a client with a response schema
+// against it, and fails those that do not conform with a *SchemaViolationError, rather
+// than return malformed output to the caller. Responses that call functions are not
+// validated, and streamed responses are validated once the stream has ended.
+func WithResponseSchemaStrict() Option {
+	return func(o *ClientOptions) {
+		o.StrictSchema = true
+	}
+}
+
+// validateResponse returns a *SchemaViolationError if text is not JSON conforming to schema.
+func validateResponse(schema *Schema, text string) error {
+	var value any
+	if err := json.Unmarshal([]byte(text), &value); err != nil {
+		return &SchemaViolationError{Response: text, Problems: []string{fmt.Sprintf("invalid JSON: %v", err)}}
+	}
+	var problems []string
+	validateValue(schema, value, "", &problems)
+	if len(problems) != 0 {
+		return &SchemaViolationError{Response: text, Problems: problems}
+	}
+	return nil
+}
+
+// strictSchemaClient is a Client that validates its responses against its response schema.
+type strictSchemaClient struct {
+	Client
+
+	mu     sync.Mutex
+	schema *Schema
+}
+
+// withStrictSchema wraps client so that its responses are validated against its
+// response schema if opts.StrictSchema is set, or returns it unchanged otherwise.
+func withStrictSchema(client Client, opts ClientOptions) Client {
+	if !opts.StrictSchema {
+		return client
+	}
+	return &strictSchemaClient{Client: client}
+}
+
+func (c *strictSchemaClient) SetResponseSchema(schema *Schema) error {
+	if err := c.Client.SetResponseSchema(schema); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.schema = schema
+	return nil
+}
+
+// CurrentSchema returns the schema responses are validated against, or nil if there is none.
+func (c *strictSchemaClient) CurrentSchema() *Schema {
+	return c.responseSchema()
+}
+
+func (c *strictSchemaClient) responseSchema() *Schema {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.schema
+}
+
+func (c *strictSchemaClient) GenerateCompletion(ctx context.Context, req *CompletionRequest) (CompletionResponse, error) {
+	response, err := c.Client.GenerateCompletion(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if schema := c.responseSchema(); schema != nil {
+		if err := validateResponse(schema, response.Response()); err != nil {
+			return nil, err
+		}
+	}
+	return response, nil
+}
+
+func (c *strictSchemaClient) GenerateCompletionStream(ctx context.Context, req *CompletionRequest) (CompletionResponseIterator, error) {
+	stream, err := c.Client.GenerateCompletionStream(ctx, req)
+	schema := c.responseSchema()
+	if err != nil || schema == nil {
+		return stream, err
+	}
+	return func(yield func(CompletionResponse, error) bool) {
+		var text strings.Builder
+		for response, err := range stream {
+			if err == nil && response != nil {
+				text.WriteString(response.Response())
+			}
+			if !yield(response, err) || err != nil {
+				return
+			}
+		}
+		if err := validateResponse(schema, text.String()); err != nil {
+			yield(nil, err)
+		}
+	}, nil
+}
+
+func (c *strictSchemaClient) StartChat(systemPrompt, model string) Chat {
+	// Like the providers, a chat keeps the response schema it was started with
+	return c.wrapChat(c.Client.StartChat(systemPrompt, model), c.responseSchema())
+}
+
+// StartChatWithOptions starts a chat whose responses are validated against the schema
+// of its options, if it has one, and otherwise against the response schema of the client.
+func (c *strictSchemaClient) StartChatWithOptions(systemPrompt, model string, opts ...ChatOption) (Chat, error) {
+	underlying, err := StartChatWithOptions(c.Client, systemPrompt, model, opts...)
+	if err != nil {
+		return nil, err
+	}
+	schema := newChatOptions(opts).Schema
+	if schema == nil {
+		schema = c.responseSchema()
+	}
+	return c.wrapChat(underlying, schema), nil
+}
+
+func (c *strictSchemaClient) wrapChat(underlying Chat, schema *Schema) Chat {
+	if schema == nil {
+		return underlying
+	}
+	return preserveSerializable(&strictSchemaChat{Chat: underlying, schema: schema}, underlying)
+}
+
+func (c *strictSchemaClient) HealthCheck(ctx context.Context) error {
+	return HealthCheck(ctx, c.Client)
+}
+
+// strictSchemaChat is a Chat that validates its responses against a response schema.
+type strictSchemaChat struct {
+	Chat
+
+	schema *Schema
+}
+
+// responseText returns the text of the first candidate of response, and whether it calls functions.
+func responseText(response ChatResponse) (string, bool) {
+	if response == nil || len(response.Candidates()) == 0 {
+		return "", false
+	}
+	var text strings.Builder
+	for _, part := range response.Candidates()[0].Parts() {
+		if s, ok := part.AsText(); ok {
+			text.WriteString(s)
+		}
+		if calls, ok := part.AsFunctionCalls(); ok && len(calls) != 0 {
+			return "", true
+		}
+	}
+	return text.String(), false
+}
+
+func (c *strictSchemaChat) Send(ctx context.Context, contents ...any) (ChatResponse, error) {
+	response, err := c.Chat.Send(ctx, contents...)
+	if err != nil {
+		return nil, err
+	}
+	if text, calls := responseText(response); !calls {
+		if err := validateResponse(c.schema, text); err != nil {
+			return nil, err
+		}
+	}
+	return response, nil
+}
+
+func (c *strictSchemaChat) SendStreaming(ctx context.Context, contents ...any) (ChatResponseIterator, error) {
+	stream, err := c.Chat.SendStreaming(ctx, contents...)
+	if err != nil {
+		return nil, err
+	}
+	return func(yield func(ChatResponse, error) bool) {
+		var text strings.Builder
+		callsFunctions := false
+		for response, err := range stream {
+			if err == nil {
+				chunk, calls := responseText(response)
+				text.WriteString(chunk)
+				callsFunctions = callsFunctions || calls
+			}
+			if !yield(response, err) || err != nil {
+				return
+			}
+		}
+		if callsFunctions {
+			return
+		}
+		if err := validateResponse(c.schema, text.String()); err != nil {
+			yield(nil, err)
+		}
+	}, nil
+}
@@ -0,0 +1,155 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gollm
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// cannedChatClient is a Client whose chats answer every message with text.
+type cannedChatClient struct {
+	Client
+
+	text string
+}
+
+func (c *cannedChatClient) SetResponseSchema(schema *Schema) error {
+	return nil
+}
+
+func (c *cannedChatClient) StartChat(systemPrompt, model string) Chat {
+	return &cannedChat{fakeChat: &fakeChat{}, text: c.text}
+}
+
+// cannedChat is a Chat that answers every message with text, streamed in two chunks.
+type cannedChat struct {
+	*fakeChat
+
+	text string
+}
+
+func textResponse(text string) ChatResponse {
+	return &fakeResponse{candidates: []Candidate{&fakeCandidate{parts: []Part{&fakePart{text: text}}}}}
+}
+
+func (c *cannedChat) Send(ctx context.Context, contents ...any) (ChatResponse, error) {
+	return textResponse(c.text), nil
+}
+
+func (c *cannedChat) SendStreaming(ctx context.Context, contents ...any) (ChatResponseIterator, error) {
+	half := len(c.text) / 2
+	return func(yield func(ChatResponse, error) bool) {
+		if yield(textResponse(c.text[:half]), nil) {
+			yield(textResponse(c.text[half:]), nil)
+		}
+	}, nil
+}
+
+func TestStrictSchema(t *testing.T) {
+	schema := &Schema{
+		Type:     TypeObject,
+		Required: []string{"answer"},
+		Properties: map[string]*Schema{
+			"answer":     {Type: TypeString},
+			"confidence": {Type: TypeNumber},
+		},
+	}
+	tests := []struct {
+		name    string
+		text    string
+		wantErr bool
+	}{
+		{name: "conforming", text: `{"answer": "3 pods", "confidence": 0.9}`},
+		{name: "missing required field", text: `{"confidence": 0.9}`, wantErr: true},
+		{name: "wrong type", text: `{"answer": 3}`, wantErr: true},
+		{name: "not JSON", text: `There are 3 pods.`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := withStrictSchema(&cannedChatClient{text: tt.text}, ClientOptions{StrictSchema: true})
+			if err := client.SetResponseSchema(schema); err != nil {
+				t.Fatalf("SetResponseSchema failed: %v", err)
+			}
+			chat := client.StartChat("", "model")
+
+			_, err := chat.Send(context.Background(), "how many pods?")
+			if tt.wantErr != errors.Is(err, ErrSchemaViolation) {
+				t.Errorf("Send: expected schema violation %v, got %v", tt.wantErr, err)
+			}
+
+			stream, err := chat.SendStreaming(context.Background(), "how many pods?")
+			if err != nil {
+				t.Fatalf("SendStreaming failed: %v", err)
+			}
+			var streamErr error
+			for _, err := range stream {
+				if err != nil {
+					streamErr = err
+				}
+			}
+			if tt.wantErr != errors.Is(streamErr, ErrSchemaViolation) {
+				t.Errorf("SendStreaming: expected schema violation %v, got %v", tt.wantErr, streamErr)
+			}
+			var violation *SchemaViolationError
+			if tt.wantErr && (!errors.As(streamErr, &violation) || violation.Response != tt.text) {
+				t.Errorf("expected a *SchemaViolationError for the whole response, got %v", streamErr)
+			}
+		})
+	}
+}
+
+func TestStrictSchemaWithoutSchema(t *testing.T) {
+	client := withStrictSchema(&cannedChatClient{text: "There are 3 pods."}, ClientOptions{StrictSchema: true})
+	if _, err := client.StartChat("", "model").Send(context.Background(), "how many pods?"); err != nil {
+		t.Errorf("expected responses to pass without a response schema, got %v", err)
+	}
+}
+
+// optionsChatClient is a cannedChatClient that accepts chat options.
+type optionsChatClient struct {
+	cannedChatClient
+}
+
+func (c *optionsChatClient) StartChatWithOptions(systemPrompt, model string, opts ...ChatOption) (Chat, error) {
+	return c.StartChat(systemPrompt, model), nil
+}
+
+func TestStrictSchemaPerChat(t *testing.T) {
+	podSchema := &Schema{Type: TypeObject, Required: []string{"pods"}, Properties: map[string]*Schema{"pods": {Type: TypeInteger}}}
+	nodeSchema := &Schema{Type: TypeObject, Required: []string{"nodes"}, Properties: map[string]*Schema{"nodes": {Type: TypeInteger}}}
+	client := withStrictSchema(&optionsChatClient{cannedChatClient{text: `{"pods": 3}`}}, ClientOptions{StrictSchema: true})
+
+	podChat, err := StartChatWithOptions(client, "", "model", WithChatSchema(podSchema))
+	if err != nil {
+		t.Fatalf("starting pod chat: %v", err)
+	}
+	nodeChat, err := StartChatWithOptions(client, "", "model", WithChatSchema(nodeSchema))
+	if err != nil {
+		t.Fatalf("starting node chat: %v", err)
+	}
+
+	if _, err := podChat.Send(context.Background(), "how many pods?"); err != nil {
+		t.Errorf("expected the response to conform to the schema of its chat, got %v", err)
+	}
+	if _, err := nodeChat.Send(context.Background(), "how many nodes?"); !errors.Is(err, ErrSchemaViolation) {
+		t.Errorf("expected the response to be validated against the schema of its chat, got %v", err)
+	}
+	if client.(*strictSchemaClient).CurrentSchema() != nil {
+		t.Error("expected the schemas of chats to leave the client's schema unset")
+	}
+}
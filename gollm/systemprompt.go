@@ -0,0 +1,71 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gollm
+
+import "context"
+
+// systemPromptSeparator separates the default system prompt from the system prompt of a chat.
+const systemPromptSeparator = "\n\n"
+
+// WithDefaultSystemPrompt prepends prompt, for example a safety preamble, to the system
+// prompt of every chat the client starts, including chats started without one.
+func WithDefaultSystemPrompt(prompt string) Option {
+	return func(o *ClientOptions) {
+		o.DefaultSystemPrompt = prompt
+	}
+}
+
+// defaultPromptClient is a Client that prepends a default system prompt to the system prompt of its chats.
+type defaultPromptClient struct {
+	Client
+
+	prompt string
+}
+
+// withDefaultSystemPrompt wraps client so that its chats start with opts.DefaultSystemPrompt,
+// or returns it unchanged if there is none.
+func withDefaultSystemPrompt(client Client, opts ClientOptions) Client {
+	if opts.DefaultSystemPrompt == "" {
+		return client
+	}
+	return &defaultPromptClient{
+		Client: client,
+		prompt: opts.DefaultSystemPrompt,
+	}
+}
+
+func (c *defaultPromptClient) StartChat(systemPrompt, model string) Chat {
+	return c.Client.StartChat(composeSystemPrompt(c.prompt, systemPrompt), model)
+}
+
+func (c *defaultPromptClient) StartChatWithOptions(systemPrompt, model string, opts ...ChatOption) (Chat, error) {
+	return StartChatWithOptions(c.Client, composeSystemPrompt(c.prompt, systemPrompt), model, opts...)
+}
+
+func (c *defaultPromptClient) HealthCheck(ctx context.Context) error {
+	return HealthCheck(ctx, c.Client)
+}
+
+// composeSystemPrompt returns the system prompt of a chat, preceded by the default system prompt.
+func composeSystemPrompt(defaultPrompt, systemPrompt string) string {
+	switch {
+	case defaultPrompt == "":
+		return systemPrompt
+	case systemPrompt == "":
+		return defaultPrompt
+	default:
+		return defaultPrompt + systemPromptSeparator + systemPrompt
+	}
+}
@@ -0,0 +1,72 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gollm
+
+import "testing"
+
+// promptRecordingClient is a Client that records the system prompts of the chats it starts.
+type promptRecordingClient struct {
+	Client
+
+	prompts []string
+}
+
+func (c *promptRecordingClient) StartChat(systemPrompt, model string) Chat {
+	c.prompts = append(c.prompts, systemPrompt)
+	return &fakeChat{}
+}
+
+func TestDefaultSystemPrompt(t *testing.T) {
+	tests := []struct {
+		name          string
+		defaultPrompt string
+		systemPrompt  string
+		want          string
+	}{
+		{
+			name:          "prepended",
+			defaultPrompt: "Never delete namespaces.",
+			systemPrompt:  "You are a Kubernetes assistant.",
+			want:          "Never delete namespaces.\n\nYou are a Kubernetes assistant.",
+		},
+		{
+			name:          "empty chat prompt",
+			defaultPrompt: "Never delete namespaces.",
+			want:          "Never delete namespaces.",
+		},
+		{
+			name:         "empty default",
+			systemPrompt: "You are a Kubernetes assistant.",
+			want:         "You are a Kubernetes assistant.",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			underlying := &promptRecordingClient{}
+			var opts ClientOptions
+			WithDefaultSystemPrompt(tt.defaultPrompt)(&opts)
+			client := withDefaultSystemPrompt(underlying, opts)
+			if tt.defaultPrompt == "" && client != Client(underlying) {
+				t.Errorf("expected the client to be unchanged without a default prompt")
+			}
+
+			client.StartChat(tt.systemPrompt, "model")
+			if len(underlying.prompts) != 1 || underlying.prompts[0] != tt.want {
+				t.Errorf("expected system prompt %q, got %q", tt.want, underlying.prompts)
+			}
+		})
+	}
+}
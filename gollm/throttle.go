@@ -0,0 +1,174 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gollm
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+
+	"github.com/aws/smithy-go"
+)
+
+// WithAdaptiveLimiter limits the number of requests the client has in flight with limiter,
+// which lowers the limit while the provider throttles requests. Further requests wait,
+// until their context is done, for a slot to become free. A streaming request holds its
+// slot until the stream has been consumed or its context is done, so a caller that drops
+// a stream must cancel its context. A limiter may be shared by several clients.
+func WithAdaptiveLimiter(limiter *AdaptiveLimiter) Option {
+	return func(o *ClientOptions) {
+		o.AdaptiveLimiter = limiter
+	}
+}
+
+// AdaptiveLimiterState is a snapshot of the state of an AdaptiveLimiter.
+type AdaptiveLimiterState struct {
+	// Limit is the number of requests currently allowed in flight.
+	Limit int
+	// MaxConcurrent is the limit while the provider does not throttle requests.
+	MaxConcurrent int
+	// InFlight is the number of requests in flight.
+	InFlight int
+	// Throttled is the number of throttled requests observed.
+	Throttled int
+}
+
+// AdaptiveLimiter limits the number of requests in flight, adapting the limit to
+// throttling by the provider: a throttled request halves the limit, down to one, and
+// the limit then grows back by one for each limit's worth of requests that succeed,
+// up to its maximum. Requests that were already in flight when the limit was lowered
+// do not lower it again, so that a burst of throttling only halves it once.
+type AdaptiveLimiter struct {
+	maxConcurrent int
+
+	mu        sync.Mutex
+	limit     int
+	inFlight  int
+	throttled int
+	// successes counts the requests that succeeded since the limit last changed.
+	successes int
+	// generation is incremented every time the limit is lowered.
+	generation int
+	// changed is closed, and replaced, whenever a slot may have become free.
+	changed chan struct{}
+}
+
+// NewAdaptiveLimiter returns an AdaptiveLimiter that allows up to maxConcurrent requests in flight.
+func NewAdaptiveLimiter(maxConcurrent int) *AdaptiveLimiter {
+	maxConcurrent = max(maxConcurrent, 1)
+	return &AdaptiveLimiter{
+		maxConcurrent: maxConcurrent,
+		limit:         maxConcurrent,
+		changed:       make(chan struct{}),
+	}
+}
+
+// State returns the current state of the limiter.
+func (l *AdaptiveLimiter) State() AdaptiveLimiterState {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return AdaptiveLimiterState{
+		Limit:         l.limit,
+		MaxConcurrent: l.maxConcurrent,
+		InFlight:      l.inFlight,
+		Throttled:     l.throttled,
+	}
+}
+
+// acquire waits for a slot, and returns a function that releases it with the outcome of the request.
+func (l *AdaptiveLimiter) acquire(ctx context.Context) (release func(err error), err error) {
+	for {
+		l.mu.Lock()
+		if l.inFlight < l.limit {
+			l.inFlight++
+			generation := l.generation
+			l.mu.Unlock()
+			return func(err error) { l.release(generation, err) }, nil
+		}
+		changed := l.changed
+		l.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return nil, context.Cause(ctx)
+		case <-changed:
+		}
+	}
+}
+
+// release frees the slot of a request that was started in generation, and adapts the limit to its outcome.
+func (l *AdaptiveLimiter) release(generation int, err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.inFlight--
+	switch {
+	case isThrottlingError(err):
+		l.throttled++
+		if generation == l.generation {
+			l.generation++
+			l.limit = max(l.limit/2, 1)
+			l.successes = 0
+		}
+	case err == nil && l.limit < l.maxConcurrent:
+		l.successes++
+		if l.successes >= l.limit {
+			l.limit++
+			l.successes = 0
+		}
+	}
+	close(l.changed)
+	l.changed = make(chan struct{})
+}
+
+// isThrottlingError reports whether err means that the provider is rate limiting
+// requests: an HTTP 429, or an AWS throttling error.
+func isThrottlingError(err error) bool {
+	if err == nil {
+		return false
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusTooManyRequests {
+		return true
+	}
+	var awsErr smithy.APIError
+	if errors.As(err, &awsErr) {
+		switch awsErr.ErrorCode() {
+		case "ThrottlingException", "TooManyRequestsException":
+			return true
+		}
+	}
+	return false
+}
+
+// withAdaptiveLimiter wraps client so that its requests go through opts.AdaptiveLimiter,
+// or returns it unchanged if there is none.
+func withAdaptiveLimiter(client Client, opts ClientOptions) Client {
+	limiter := opts.AdaptiveLimiter
+	if limiter == nil {
+		return client
+	}
+	return decorateClient(client, limiter.startTurn, func() turnStarter { return limiter.startTurn })
+}
+
+// startTurn starts a request once the limiter has a slot free for it.
+func (l *AdaptiveLimiter) startTurn(ctx context.Context, _ func(error) bool) (*turn, error) {
+	release, err := l.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &turn{ctx: ctx, end: release}, nil
+}
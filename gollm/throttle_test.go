@@ -0,0 +1,135 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gollm
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime/types"
+)
+
+func TestAdaptiveLimiterTightensAndLoosens(t *testing.T) {
+	ctx := context.Background()
+	throttled := &APIError{StatusCode: http.StatusTooManyRequests, Message: "rate limited"}
+	awsThrottled := &types.ThrottlingException{Message: aws.String("Too many requests")}
+	underlying := &fakeChat{errs: []error{throttled, awsThrottled, throttled}}
+	limiter := NewAdaptiveLimiter(8)
+	chat := withAdaptiveLimiter(&fakeChatClient{chat: underlying}, ClientOptions{AdaptiveLimiter: limiter}).StartChat("", "model")
+
+	// Each burst of throttling halves the limit
+	for _, want := range []int{4, 2, 1} {
+		if _, err := chat.Send(ctx, "hello"); err == nil {
+			t.Fatal("expected the throttling error to be returned")
+		}
+		if got := limiter.State().Limit; got != want {
+			t.Errorf("expected the limit to tighten to %d, got %d", want, got)
+		}
+	}
+
+	// Once throttling subsides, the limit grows back by one per limit's worth of successes
+	for _, want := range []int{2, 3, 4, 5, 6, 7, 8, 8} {
+		for range limiter.State().Limit {
+			if _, err := chat.Send(ctx, "hello"); err != nil {
+				t.Fatalf("Send failed: %v", err)
+			}
+		}
+		if got := limiter.State().Limit; got != want {
+			t.Errorf("expected the limit to loosen to %d, got %d", want, got)
+		}
+	}
+
+	want := AdaptiveLimiterState{Limit: 8, MaxConcurrent: 8, Throttled: 3}
+	if got := limiter.State(); got != want {
+		t.Errorf("expected state %+v, got %+v", want, got)
+	}
+}
+
+func TestAdaptiveLimiterBurstHalvesOnce(t *testing.T) {
+	throttled := &APIError{StatusCode: http.StatusTooManyRequests, Message: "rate limited"}
+	limiter := NewAdaptiveLimiter(8)
+
+	// Requests in flight together are throttled together
+	var releases []func(error)
+	for range 4 {
+		release, err := limiter.acquire(context.Background())
+		if err != nil {
+			t.Fatalf("acquire failed: %v", err)
+		}
+		releases = append(releases, release)
+	}
+	for _, release := range releases {
+		release(throttled)
+	}
+
+	want := AdaptiveLimiterState{Limit: 4, MaxConcurrent: 8, Throttled: 4}
+	if got := limiter.State(); got != want {
+		t.Errorf("expected state %+v, got %+v", want, got)
+	}
+}
+
+func TestAdaptiveLimiterWaitsForSlot(t *testing.T) {
+	limiter := NewAdaptiveLimiter(4)
+	for range 2 {
+		release, err := limiter.acquire(context.Background())
+		if err != nil {
+			t.Fatalf("acquire failed: %v", err)
+		}
+		release(&APIError{StatusCode: http.StatusTooManyRequests, Message: "rate limited"})
+	}
+	if got := limiter.State().Limit; got != 1 {
+		t.Fatalf("expected the limit to tighten to 1, got %d", got)
+	}
+
+	release, err := limiter.acquire(context.Background())
+	if err != nil {
+		t.Fatalf("acquire failed: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := limiter.acquire(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected a second request to wait for the only slot, got %v", err)
+	}
+
+	acquired := make(chan error)
+	go func() {
+		_, err := limiter.acquire(context.Background())
+		acquired <- err
+	}()
+	release(nil)
+	if err := <-acquired; err != nil {
+		t.Fatalf("expected the waiting request to get the freed slot, got %v", err)
+	}
+}
+
+func TestAdaptiveLimiterReleasesCancelledStream(t *testing.T) {
+	limiter := NewAdaptiveLimiter(1)
+	chat := withAdaptiveLimiter(&fakeChatClient{chat: &fakeChat{}}, ClientOptions{AdaptiveLimiter: limiter}).StartChat("", "model")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	dropStream(t, ctx, chat)
+	if got := limiter.State().InFlight; got != 1 {
+		t.Fatalf("expected the stream to hold a slot, got %d in flight", got)
+	}
+	cancel()
+	waitFor(t, func() bool { return limiter.State().InFlight == 0 })
+	if state := limiter.State(); state.Limit != 1 || state.Throttled != 0 {
+		t.Errorf("expected the cancelled stream to leave the limit alone, got %+v", state)
+	}
+}
@@ -0,0 +1,38 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gollm
+
+// Tokenizer counts the tokens in text, for features that estimate the size of a request,
+// such as dry runs. A tokenizer matching the model, such as tiktoken for OpenAI models,
+// is more accurate than the default HeuristicTokenizer for code and non-Latin text.
+type Tokenizer interface {
+	Count(text string) int
+}
+
+// WithTokenizer sets the tokenizer used to estimate token counts. It defaults to HeuristicTokenizer.
+func WithTokenizer(tokenizer Tokenizer) Option {
+	return func(o *ClientOptions) {
+		o.Tokenizer = tokenizer
+	}
+}
+
+// HeuristicTokenizer estimates the number of tokens in text, at about four bytes per token.
+type HeuristicTokenizer struct{}
+
+var _ Tokenizer = HeuristicTokenizer{}
+
+func (HeuristicTokenizer) Count(text string) int {
+	return (len(text) + 3) / 4
+}
@@ -0,0 +1,44 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gollm
+
+import (
+	"fmt"
+	"unicode/utf8"
+)
+
+// WithMaxToolResultBytes truncates the results of function calls that are larger than n bytes
+// once encoded as JSON, before they are sent to the model. Providers limit the size of tool
+// results, and large outputs, such as the logs of a pod, are otherwise rejected.
+// A truncated result is sent as text, ending with a "...[truncated N bytes]" marker.
+func WithMaxToolResultBytes(n int) Option {
+	return func(o *ClientOptions) {
+		o.MaxToolResultBytes = n
+	}
+}
+
+// truncateToolResult cuts the JSON encoding of a function call result to maxBytes, at a
+// UTF-8 boundary, and appends a marker of how many bytes were cut. It returns false if
+// maxBytes is not positive or the encoding fits, in which case it can be sent unchanged.
+func truncateToolResult(encoded []byte, maxBytes int) (string, bool) {
+	if maxBytes <= 0 || len(encoded) <= maxBytes {
+		return string(encoded), false
+	}
+	cut := maxBytes
+	for cut > 0 && !utf8.RuneStart(encoded[cut]) {
+		cut--
+	}
+	return fmt.Sprintf("%s...[truncated %d bytes]", encoded[:cut], len(encoded)-cut), true
+}
@@ -0,0 +1,59 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gollm
+
+import (
+	"testing"
+	"unicode/utf8"
+)
+
+func TestTruncateToolResult(t *testing.T) {
+	tests := []struct {
+		name          string
+		encoded       string
+		maxBytes      int
+		want          string
+		wantTruncated bool
+	}{
+		{name: "no limit", encoded: `{"stdout":"ok"}`, want: `{"stdout":"ok"}`},
+		{name: "fits", encoded: `{"stdout":"ok"}`, maxBytes: 15, want: `{"stdout":"ok"}`},
+		{
+			name:          "too large",
+			encoded:       `{"stdout":"0123456789"}`,
+			maxBytes:      15,
+			want:          `{"stdout":"0123...[truncated 8 bytes]`,
+			wantTruncated: true,
+		},
+		{
+			name:          "cut inside a character",
+			encoded:       `{"stdout":"héllo"}`,
+			maxBytes:      13,
+			want:          `{"stdout":"h...[truncated 7 bytes]`,
+			wantTruncated: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, truncated := truncateToolResult([]byte(tt.encoded), tt.maxBytes)
+			if got != tt.want || truncated != tt.wantTruncated {
+				t.Errorf("expected %q (truncated %v), got %q (truncated %v)", tt.want, tt.wantTruncated, got, truncated)
+			}
+			if !utf8.ValidString(got) {
+				t.Errorf("expected valid UTF-8, got %q", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,195 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gollm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// TranscriptEntry is a message of a conversation, written as a line of JSON to the
+// transcript writer set with WithTranscriptWriter.
+type TranscriptEntry struct {
+	Time time.Time `json:"time"`
+	// Role is "system", "user", "assistant" or "tool".
+	Role    string `json:"role"`
+	Content string `json:"content,omitempty"`
+	// ToolCalls are the functions called by an assistant message.
+	ToolCalls []FunctionCall `json:"toolCalls,omitempty"`
+	// ToolResult is the result of a function, sent in a tool message.
+	ToolResult *FunctionCallResult `json:"toolResult,omitempty"`
+}
+
+// WithTranscriptWriter writes the messages of every chat the client starts to w, as JSON
+// lines of TranscriptEntry, for audit and debugging. The messages of a turn are written
+// once it has succeeded, so that retried requests are written once. Chats started by the
+// same client share w, and their lines may interleave.
+func WithTranscriptWriter(w io.Writer) Option {
+	return func(o *ClientOptions) {
+		o.TranscriptWriter = w
+	}
+}
+
+// transcript writes TranscriptEntry lines to a writer shared by the chats of a client.
+type transcript struct {
+	now func() time.Time
+
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (t *transcript) write(entries ...TranscriptEntry) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, entry := range entries {
+		line, err := json.Marshal(entry)
+		if err != nil {
+			line, _ = json.Marshal(TranscriptEntry{Time: entry.Time, Role: entry.Role, Content: fmt.Sprintf("(unserializable entry: %v)", err)})
+		}
+		t.w.Write(append(line, '\n'))
+	}
+}
+
+// transcriptClient is a Client that writes the messages of its chats to a transcript.
+type transcriptClient struct {
+	Client
+
+	transcript *transcript
+}
+
+// withTranscript wraps client so that its chats are written to opts.TranscriptWriter,
+// or returns it unchanged if there is none.
+func withTranscript(client Client, opts ClientOptions) Client {
+	if opts.TranscriptWriter == nil {
+		return client
+	}
+	return &transcriptClient{
+		Client:     client,
+		transcript: &transcript{now: time.Now, w: opts.TranscriptWriter},
+	}
+}
+
+func (c *transcriptClient) StartChat(systemPrompt, model string) Chat {
+	return c.wrapChat(c.Client.StartChat(systemPrompt, model), systemPrompt)
+}
+
+func (c *transcriptClient) StartChatWithOptions(systemPrompt, model string, opts ...ChatOption) (Chat, error) {
+	underlying, err := StartChatWithOptions(c.Client, systemPrompt, model, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return c.wrapChat(underlying, systemPrompt), nil
+}
+
+func (c *transcriptClient) wrapChat(underlying Chat, systemPrompt string) Chat {
+	if systemPrompt != "" {
+		c.transcript.write(TranscriptEntry{Time: c.transcript.now(), Role: "system", Content: systemPrompt})
+	}
+	return preserveSerializable(&transcriptChat{Chat: underlying, transcript: c.transcript}, underlying)
+}
+
+func (c *transcriptClient) HealthCheck(ctx context.Context) error {
+	return HealthCheck(ctx, c.Client)
+}
+
+// transcriptChat is a Chat that writes its messages to the transcript of its client.
+type transcriptChat struct {
+	Chat
+
+	transcript *transcript
+}
+
+// sentEntries returns the entries of the contents of a request sent at time sent.
+func sentEntries(sent time.Time, contents []any) []TranscriptEntry {
+	var entries []TranscriptEntry
+	for _, content := range contents {
+		switch v := content.(type) {
+		case string:
+			entries = append(entries, TranscriptEntry{Time: sent, Role: "user", Content: v})
+		case FunctionCallResult:
+			entries = append(entries, TranscriptEntry{Time: sent, Role: "tool", ToolResult: &v})
+		case []FunctionCallResult:
+			for _, result := range v {
+				entries = append(entries, TranscriptEntry{Time: sent, Role: "tool", ToolResult: &result})
+			}
+		case DocumentPart:
+			entries = append(entries, TranscriptEntry{Time: sent, Role: "user", Content: fmt.Sprintf("(%s document %q)", v.Format, v.Name)})
+		case ModelOverride:
+			entries = append(entries, TranscriptEntry{Time: sent, Role: "system", Content: fmt.Sprintf("(model changed to %s)", v)})
+		default:
+			entries = append(entries, TranscriptEntry{Time: sent, Role: "user", Content: fmt.Sprintf("(%T)", content)})
+		}
+	}
+	return entries
+}
+
+// addResponse adds the text and function calls of the first candidate of response to entry.
+func addResponse(entry *TranscriptEntry, response ChatResponse) {
+	if response == nil || len(response.Candidates()) == 0 {
+		return
+	}
+	for _, part := range response.Candidates()[0].Parts() {
+		if text, ok := part.AsText(); ok {
+			entry.Content += text
+		}
+		if calls, ok := part.AsFunctionCalls(); ok {
+			entry.ToolCalls = append(entry.ToolCalls, calls...)
+		}
+	}
+}
+
+func (c *transcriptChat) Send(ctx context.Context, contents ...any) (ChatResponse, error) {
+	sent := c.transcript.now()
+	response, err := c.Chat.Send(ctx, contents...)
+	if err != nil {
+		return nil, err
+	}
+	reply := TranscriptEntry{Time: c.transcript.now(), Role: "assistant"}
+	addResponse(&reply, response)
+	c.transcript.write(append(sentEntries(sent, contents), reply)...)
+	return response, nil
+}
+
+func (c *transcriptChat) SendStreaming(ctx context.Context, contents ...any) (ChatResponseIterator, error) {
+	sent := c.transcript.now()
+	stream, err := c.Chat.SendStreaming(ctx, contents...)
+	if err != nil {
+		return nil, err
+	}
+	return func(yield func(ChatResponse, error) bool) {
+		reply := TranscriptEntry{Role: "assistant"}
+		var streamErr error
+		defer func() {
+			if streamErr == nil {
+				reply.Time = c.transcript.now()
+				c.transcript.write(append(sentEntries(sent, contents), reply)...)
+			}
+		}()
+		for response, err := range stream {
+			if err != nil {
+				streamErr = err
+			} else {
+				addResponse(&reply, response)
+			}
+			if !yield(response, err) {
+				return
+			}
+		}
+	}, nil
+}
@@ -0,0 +1,95 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gollm
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime/document"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime/types"
+)
+
+func TestTranscriptWriter(t *testing.T) {
+	ctx := context.Background()
+	fake := &fakeBedrockAPI{
+		converseOutputs: []*bedrockruntime.ConverseOutput{
+			assistantOutput(
+				&types.ContentBlockMemberText{Value: "Let me list the pods."},
+				&types.ContentBlockMemberToolUse{Value: types.ToolUseBlock{
+					ToolUseId: aws.String("call-1"),
+					Name:      aws.String("kubectl"),
+					Input:     document.NewLazyDocument(map[string]any{"command": "kubectl get pods"}),
+				}},
+			),
+		},
+		streams: []*fakeEventStream{{events: []types.ConverseStreamOutput{
+			textDeltaEvent("There is one pod, "),
+			textDeltaEvent("nginx."),
+		}}},
+	}
+
+	var out bytes.Buffer
+	var opts ClientOptions
+	WithTranscriptWriter(&out)(&opts)
+	client := withTranscript(&BedrockClient{runtime: fake}, opts)
+	now := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	client.(*transcriptClient).transcript.now = func() time.Time { return now }
+
+	chat := client.StartChat("You are a Kubernetes assistant.", "us.anthropic.claude-sonnet-4-20250514-v1:0")
+	if _, err := chat.Send(ctx, "what pods are there?"); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	stream, err := chat.SendStreaming(ctx, FunctionCallResult{ID: "call-1", Name: "kubectl", Result: map[string]any{"stdout": "nginx"}})
+	if err != nil {
+		t.Fatalf("SendStreaming failed: %v", err)
+	}
+	for _, err := range stream {
+		if err != nil {
+			t.Fatalf("stream error: %v", err)
+		}
+	}
+
+	want := strings.Join([]string{
+		`{"time":"2025-01-02T03:04:05Z","role":"system","content":"You are a Kubernetes assistant."}`,
+		`{"time":"2025-01-02T03:04:05Z","role":"user","content":"what pods are there?"}`,
+		`{"time":"2025-01-02T03:04:05Z","role":"assistant","content":"Let me list the pods.","toolCalls":[{"id":"call-1","name":"kubectl","arguments":{"command":"kubectl get pods"}}]}`,
+		`{"time":"2025-01-02T03:04:05Z","role":"tool","toolResult":{"id":"call-1","name":"kubectl","result":{"stdout":"nginx"}}}`,
+		`{"time":"2025-01-02T03:04:05Z","role":"assistant","content":"There is one pod, nginx."}`,
+	}, "\n") + "\n"
+	if out.String() != want {
+		t.Errorf("expected transcript\n%s\ngot\n%s", want, out.String())
+	}
+}
+
+func TestTranscriptWriterSkipsFailedRequests(t *testing.T) {
+	var out bytes.Buffer
+	var opts ClientOptions
+	WithTranscriptWriter(&out)(&opts)
+	client := withTranscript(&fakeChatClient{chat: &fakeChat{errs: []error{context.DeadlineExceeded}}}, opts)
+
+	chat := client.StartChat("", "model")
+	if _, err := chat.Send(context.Background(), "hello"); err == nil {
+		t.Fatal("expected Send to fail")
+	}
+	if out.Len() != 0 {
+		t.Errorf("expected nothing to be written for a failed request, got %q", out.String())
+	}
+}
@@ -0,0 +1,62 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gollm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrTurnTimeout is returned by a chat turn that took longer than the turn timeout.
+var ErrTurnTimeout = errors.New("chat turn timed out")
+
+// WithTurnTimeout bounds the time each chat turn may take, in addition to the deadline
+// of the caller's context. A streaming turn lasts until its stream has been consumed.
+// Zero means no bound.
+func WithTurnTimeout(timeout time.Duration) Option {
+	return func(o *ClientOptions) {
+		o.TurnTimeout = timeout
+	}
+}
+
+// withTurnTimeout wraps client so that its chat turns take at most opts.TurnTimeout,
+// or returns it unchanged if there is no bound.
+func withTurnTimeout(client Client, opts ClientOptions) Client {
+	if opts.TurnTimeout <= 0 {
+		return client
+	}
+	start := turnTimeout(opts.TurnTimeout)
+	return decorateClient(client, nil, func() turnStarter { return start })
+}
+
+// turnTimeout returns a turnStarter whose turns are cancelled with ErrTurnTimeout after
+// timeout, and whose errors are then marked as ErrTurnTimeout.
+func turnTimeout(timeout time.Duration) turnStarter {
+	return func(ctx context.Context, _ func(error) bool) (*turn, error) {
+		ctx, cancel := context.WithTimeoutCause(ctx, timeout, ErrTurnTimeout)
+		return &turn{
+			ctx: ctx,
+			observe: func(_ any, err error) error {
+				if err != nil && errors.Is(context.Cause(ctx), ErrTurnTimeout) {
+					return fmt.Errorf("%w after %v: %w", ErrTurnTimeout, timeout, err)
+				}
+				return err
+			},
+			end: func(error) { cancel() },
+		}, nil
+	}
+}
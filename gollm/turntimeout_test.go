@@ -0,0 +1,111 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gollm
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// hangingChat is a Chat whose turns last until their context is done.
+type hangingChat struct {
+	fakeChat
+}
+
+func (c *hangingChat) Send(ctx context.Context, contents ...any) (ChatResponse, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (c *hangingChat) SendStreaming(ctx context.Context, contents ...any) (ChatResponseIterator, error) {
+	return func(yield func(ChatResponse, error) bool) {
+		if !yield(nil, nil) {
+			return
+		}
+		<-ctx.Done()
+		yield(nil, ctx.Err())
+	}, nil
+}
+
+// hangingChatClient is a Client whose chats are hangingChats.
+type hangingChatClient struct {
+	Client
+}
+
+func (c *hangingChatClient) StartChat(systemPrompt, model string) Chat {
+	return &hangingChat{}
+}
+
+func TestTurnTimeout(t *testing.T) {
+	client := withTurnTimeout(&hangingChatClient{}, ClientOptions{TurnTimeout: 20 * time.Millisecond})
+	chat := client.StartChat("", "model")
+
+	tests := []struct {
+		name string
+		turn func(ctx context.Context) error
+	}{
+		{
+			name: "Send",
+			turn: func(ctx context.Context) error {
+				_, err := chat.Send(ctx, "hello")
+				return err
+			},
+		},
+		{
+			name: "SendStreaming",
+			turn: func(ctx context.Context) error {
+				stream, err := chat.SendStreaming(ctx, "hello")
+				if err != nil {
+					return err
+				}
+				for _, err := range stream {
+					if err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+
+			start := time.Now()
+			err := tt.turn(ctx)
+			if !errors.Is(err, ErrTurnTimeout) || !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("expected a turn timeout, got %v", err)
+			}
+			if elapsed := time.Since(start); elapsed > 5*time.Second {
+				t.Errorf("expected the turn to be aborted at the turn timeout, took %v", elapsed)
+			}
+		})
+	}
+
+	// The caller's own deadline is not reported as a turn timeout
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	if _, err := chat.Send(ctx, "hello"); errors.Is(err, ErrTurnTimeout) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the caller's deadline to be exceeded, got %v", err)
+	}
+
+	if _, ok := withTurnTimeout(&hangingChatClient{}, ClientOptions{}).(*hangingChatClient); !ok {
+		t.Error("expected no wrapper without a turn timeout")
+	}
+}
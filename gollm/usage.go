@@ -0,0 +1,133 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gollm
+
+import (
+	"sync"
+	"time"
+)
+
+// Usage is the normalized token usage of a single LLM request.
+// Providers that report usage return a *Usage from UsageMetadata.
+type Usage struct {
+	InputTokens  int `json:"inputTokens"`
+	OutputTokens int `json:"outputTokens"`
+	TotalTokens  int `json:"totalTokens"`
+
+	// CacheReadTokens and CacheWriteTokens count input tokens served from or written to a prompt cache.
+	CacheReadTokens  int `json:"cacheReadTokens,omitempty"`
+	CacheWriteTokens int `json:"cacheWriteTokens,omitempty"`
+
+	// Costs are in US dollars, and are zero if the model's pricing is not known.
+	InputCost  float64 `json:"inputCost,omitempty"`
+	OutputCost float64 `json:"outputCost,omitempty"`
+	TotalCost  float64 `json:"totalCost,omitempty"`
+
+	// Estimated is true if the token counts were estimated rather than reported by the provider.
+	Estimated bool `json:"estimated,omitempty"`
+	// Source describes where the token counts come from, for example UsageSourceAPI.
+	Source string `json:"source,omitempty"`
+
+	Provider  string    `json:"provider,omitempty"`
+	Model     string    `json:"model,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+
+	// Region is the cloud region that served the request, for providers that are regional.
+	Region string `json:"region,omitempty"`
+}
+
+// UsageSourceAPI is the Source of usage reported by the provider's API.
+const UsageSourceAPI = "api"
+
+// ModelPricing is the on-demand price of a model, in US dollars per million tokens.
+type ModelPricing struct {
+	InputPerMillionTokens  float64 `json:"inputPerMillionTokens"`
+	OutputPerMillionTokens float64 `json:"outputPerMillionTokens"`
+}
+
+// applyPricing fills in the costs of the usage from the given pricing.
+func (u *Usage) applyPricing(pricing ModelPricing) {
+	u.InputCost = float64(u.InputTokens) * pricing.InputPerMillionTokens / 1_000_000
+	u.OutputCost = float64(u.OutputTokens) * pricing.OutputPerMillionTokens / 1_000_000
+	u.TotalCost = u.InputCost + u.OutputCost
+}
+
+// add accumulates the token counts and costs of other into u.
+// The result is estimated if either usage is.
+func (u *Usage) add(other Usage) {
+	u.InputTokens += other.InputTokens
+	u.OutputTokens += other.OutputTokens
+	u.TotalTokens += other.TotalTokens
+	u.CacheReadTokens += other.CacheReadTokens
+	u.CacheWriteTokens += other.CacheWriteTokens
+	u.InputCost += other.InputCost
+	u.OutputCost += other.OutputCost
+	u.TotalCost += other.TotalCost
+	u.Estimated = u.Estimated || other.Estimated
+	if other.Timestamp.After(u.Timestamp) {
+		u.Timestamp = other.Timestamp
+	}
+}
+
+// UsageAggregator totals the usage of many requests, overall and per model.
+// It is safe for concurrent use, and its UsageCallback can be installed on a client
+// with WithUsageCallback.
+type UsageAggregator struct {
+	mu       sync.Mutex
+	total    Usage
+	perModel map[string]Usage
+}
+
+// Record adds the usage of a request to the totals.
+func (a *UsageAggregator) Record(provider, model string, usage Usage) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.total.add(usage)
+
+	if a.perModel == nil {
+		a.perModel = make(map[string]Usage)
+	}
+	modelUsage := a.perModel[model]
+	modelUsage.Provider = provider
+	modelUsage.Model = model
+	modelUsage.add(usage)
+	a.perModel[model] = modelUsage
+}
+
+// Total returns the usage of all recorded requests.
+func (a *UsageAggregator) Total() Usage {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.total
+}
+
+// PerModel returns the usage of the recorded requests, by model.
+func (a *UsageAggregator) PerModel() map[string]Usage {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	perModel := make(map[string]Usage, len(a.perModel))
+	for model, usage := range a.perModel {
+		perModel[model] = usage
+	}
+	return perModel
+}
+
+// UsageCallback returns a UsageCallback that records every usage it is called with.
+func (a *UsageAggregator) UsageCallback() UsageCallback {
+	return func(info RequestInfo, usage *Usage) {
+		a.Record(info.Provider, info.Model, *usage)
+	}
+}
@@ -0,0 +1,139 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gollm
+
+import (
+	"encoding/json"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestUsageJSONRoundTrip(t *testing.T) {
+	tests := []struct {
+		name  string
+		usage Usage
+		want  string
+	}{
+		{
+			name: "reported",
+			usage: Usage{
+				InputTokens:  10,
+				OutputTokens: 5,
+				TotalTokens:  15,
+				Source:       UsageSourceAPI,
+				Provider:     "bedrock",
+				Timestamp:    time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC),
+			},
+			want: `{"inputTokens":10,"outputTokens":5,"totalTokens":15,"source":"api","provider":"bedrock","timestamp":"2025-06-01T00:00:00Z"}`,
+		},
+		{
+			name: "estimated",
+			usage: Usage{
+				InputTokens:  10,
+				OutputTokens: 5,
+				TotalTokens:  15,
+				Estimated:    true,
+				Source:       "heuristic",
+				Timestamp:    time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC),
+			},
+			want: `{"inputTokens":10,"outputTokens":5,"totalTokens":15,"estimated":true,"source":"heuristic","timestamp":"2025-06-01T00:00:00Z"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(tt.usage)
+			if err != nil {
+				t.Fatalf("marshaling usage: %v", err)
+			}
+			if string(data) != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, data)
+			}
+
+			var roundTripped Usage
+			if err := json.Unmarshal(data, &roundTripped); err != nil {
+				t.Fatalf("unmarshaling usage: %v", err)
+			}
+			if !reflect.DeepEqual(roundTripped, tt.usage) {
+				t.Errorf("expected %+v after the round trip, got %+v", tt.usage, roundTripped)
+			}
+		})
+	}
+}
+
+func TestUsageAggregator(t *testing.T) {
+	requests := []struct {
+		info  RequestInfo
+		usage Usage
+	}{
+		{
+			info:  RequestInfo{Provider: "bedrock", Model: "us.anthropic.claude-sonnet-4-20250514-v1:0"},
+			usage: Usage{InputTokens: 1000, OutputTokens: 200, TotalTokens: 1200, InputCost: 0.003, OutputCost: 0.003, TotalCost: 0.006},
+		},
+		{
+			info:  RequestInfo{Provider: "bedrock", Model: "us.anthropic.claude-sonnet-4-20250514-v1:0", Stream: true},
+			usage: Usage{InputTokens: 1500, OutputTokens: 100, TotalTokens: 1600, CacheReadTokens: 1000},
+		},
+		{
+			info:  RequestInfo{Provider: "bedrock", Model: "us.amazon.nova-pro-v1:0"},
+			usage: Usage{InputTokens: 400, OutputTokens: 50, TotalTokens: 450, Estimated: true},
+		},
+	}
+
+	// Requests are reported concurrently, as they would be by chats running in parallel.
+	aggregator := &UsageAggregator{}
+	callback := aggregator.UsageCallback()
+	const rounds = 10
+	var wg sync.WaitGroup
+	for range rounds {
+		for _, request := range requests {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				callback(request.info, &request.usage)
+			}()
+		}
+	}
+	wg.Wait()
+
+	total := aggregator.Total()
+	if total.InputTokens != rounds*2900 || total.OutputTokens != rounds*350 || total.TotalTokens != rounds*3250 {
+		t.Errorf("unexpected total token counts: %+v", total)
+	}
+	if total.CacheReadTokens != rounds*1000 {
+		t.Errorf("expected %d cache read tokens, got %d", rounds*1000, total.CacheReadTokens)
+	}
+	if !total.Estimated {
+		t.Error("expected the total to be estimated, as one of its parts is")
+	}
+
+	perModel := aggregator.PerModel()
+	if len(perModel) != 2 {
+		t.Fatalf("expected usage for 2 models, got %d", len(perModel))
+	}
+	sonnet := perModel["us.anthropic.claude-sonnet-4-20250514-v1:0"]
+	if sonnet.InputTokens != rounds*2500 || sonnet.OutputTokens != rounds*300 || sonnet.Provider != "bedrock" {
+		t.Errorf("unexpected usage for Claude Sonnet: %+v", sonnet)
+	}
+	if sonnet.Estimated {
+		t.Error("expected usage for Claude Sonnet not to be estimated")
+	}
+	nova := perModel["us.amazon.nova-pro-v1:0"]
+	if nova.InputTokens != rounds*400 || nova.OutputTokens != rounds*50 || !nova.Estimated {
+		t.Errorf("unexpected usage for Nova Pro: %+v", nova)
+	}
+}
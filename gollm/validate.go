@@ -0,0 +1,119 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gollm
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// ValidationError is returned by Chat.Validate when the chat is not in a state
+// that the provider would accept.
+type ValidationError struct {
+	// Problems describes each issue found.
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid chat request: " + strings.Join(e.Problems, "; ")
+}
+
+// validationResult returns a *ValidationError for problems, or nil if there are none.
+func validationResult(problems []string) error {
+	if len(problems) == 0 {
+		return nil
+	}
+	return &ValidationError{Problems: problems}
+}
+
+// functionNamePattern matches the function names accepted by all providers.
+var functionNamePattern = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
+
+// validateFunctionDefinitions returns the problems with a set of function definitions:
+// illegal or duplicate names, and parameter schemas that cannot be satisfied.
+func validateFunctionDefinitions(defs []*FunctionDefinition) []string {
+	var problems []string
+	seen := make(map[string]bool)
+	for _, def := range defs {
+		if def == nil {
+			problems = append(problems, "nil function definition")
+			continue
+		}
+		if !functionNamePattern.MatchString(def.Name) {
+			problems = append(problems, fmt.Sprintf("function name %q must be 1-64 letters, digits, underscores or hyphens", def.Name))
+		}
+		if seen[def.Name] {
+			problems = append(problems, fmt.Sprintf("function %q is defined more than once", def.Name))
+		}
+		seen[def.Name] = true
+
+		var schemaProblems []string
+		validateSchema(def.Parameters, "", &schemaProblems)
+		for _, problem := range schemaProblems {
+			problems = append(problems, fmt.Sprintf("function %q: %s", def.Name, problem))
+		}
+	}
+	return problems
+}
+
+// validateSchema appends a problem for each inconsistency in schema, such as
+// array bounds that cannot both hold or required fields that are not declared.
+func validateSchema(schema *Schema, path string, problems *[]string) {
+	if schema == nil {
+		return
+	}
+
+	describe := func(format string, args ...any) string {
+		msg := fmt.Sprintf(format, args...)
+		if path == "" {
+			return msg
+		}
+		return fmt.Sprintf("%s: %s", path, msg)
+	}
+
+	if len(schema.Enum) != 0 && schema.Type != TypeString {
+		*problems = append(*problems, describe("enum is only supported for strings, not %s", schema.Type))
+	}
+	if schema.MinItems != nil && *schema.MinItems < 0 {
+		*problems = append(*problems, describe("minItems %d is negative", *schema.MinItems))
+	}
+	if schema.MaxItems != nil && *schema.MaxItems < 0 {
+		*problems = append(*problems, describe("maxItems %d is negative", *schema.MaxItems))
+	}
+	if schema.MinItems != nil && schema.MaxItems != nil && *schema.MinItems > *schema.MaxItems {
+		*problems = append(*problems, describe("minItems %d is greater than maxItems %d", *schema.MinItems, *schema.MaxItems))
+	}
+
+	switch schema.Type {
+	case TypeObject:
+		for _, name := range schema.Required {
+			if _, ok := schema.Properties[name]; !ok {
+				*problems = append(*problems, describe("required field %q is not a declared property", name))
+			}
+		}
+		names := make([]string, 0, len(schema.Properties))
+		for name := range schema.Properties {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+		for _, name := range names {
+			validateSchema(schema.Properties[name], joinFieldPath(path, name), problems)
+		}
+	case TypeArray:
+		validateSchema(schema.Items, path+"[]", problems)
+	}
+}
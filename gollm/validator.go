@@ -0,0 +1,146 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gollm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrResponseRejected is matched, with errors.Is, by the error returned for a response
+// that the ResponseValidator rejected. The validator's error is wrapped as well.
+var ErrResponseRejected = errors.New("response rejected by validator")
+
+// ResponseValidator checks the text of a completed response, returning an error to reject it.
+type ResponseValidator func(text string) error
+
+// WithResponseValidator runs validator on the text of every completed, non-streaming
+// response of the client, and fails those it rejects, for example to keep responses
+// that contain secrets from reaching the caller. Responses that call functions are
+// not validated.
+func WithResponseValidator(validator ResponseValidator) Option {
+	return func(o *ClientOptions) {
+		o.ResponseValidator = validator
+	}
+}
+
+// WithRegenerateOnValidationError makes a response rejected by the ResponseValidator
+// be generated once more before failing. A chat asks for the new response with a
+// message telling the model why the previous one was rejected.
+func WithRegenerateOnValidationError() Option {
+	return func(o *ClientOptions) {
+		o.RegenerateOnValidationError = true
+	}
+}
+
+// validatedClient is a Client whose responses are checked by a ResponseValidator.
+type validatedClient struct {
+	Client
+
+	validator  ResponseValidator
+	regenerate bool
+}
+
+// withResponseValidator wraps client so that its responses are checked by
+// opts.ResponseValidator, or returns it unchanged if there is none.
+func withResponseValidator(client Client, opts ClientOptions) Client {
+	if opts.ResponseValidator == nil {
+		return client
+	}
+	return &validatedClient{
+		Client:     client,
+		validator:  opts.ResponseValidator,
+		regenerate: opts.RegenerateOnValidationError,
+	}
+}
+
+// rejected returns the error for a response rejected by the validator with rejection.
+func rejected(rejection error) error {
+	return fmt.Errorf("%w: %w", ErrResponseRejected, rejection)
+}
+
+func (c *validatedClient) GenerateCompletion(ctx context.Context, req *CompletionRequest) (CompletionResponse, error) {
+	response, err := c.Client.GenerateCompletion(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	rejection := c.validator(response.Response())
+	if rejection != nil && c.regenerate {
+		if response, err = c.Client.GenerateCompletion(ctx, req); err != nil {
+			return nil, err
+		}
+		rejection = c.validator(response.Response())
+	}
+	if rejection != nil {
+		return nil, rejected(rejection)
+	}
+	return response, nil
+}
+
+func (c *validatedClient) HealthCheck(ctx context.Context) error {
+	return HealthCheck(ctx, c.Client)
+}
+
+func (c *validatedClient) StartChat(systemPrompt, model string) Chat {
+	return c.wrapChat(c.Client.StartChat(systemPrompt, model))
+}
+
+func (c *validatedClient) StartChatWithOptions(systemPrompt, model string, opts ...ChatOption) (Chat, error) {
+	underlying, err := StartChatWithOptions(c.Client, systemPrompt, model, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return c.wrapChat(underlying), nil
+}
+
+func (c *validatedClient) wrapChat(underlying Chat) Chat {
+	return preserveSerializable(&validatedChat{Chat: underlying, client: c}, underlying)
+}
+
+// validatedChat is a Chat whose responses are checked by the validator of its client.
+type validatedChat struct {
+	Chat
+
+	client *validatedClient
+}
+
+func (c *validatedChat) Send(ctx context.Context, contents ...any) (ChatResponse, error) {
+	response, err := c.Chat.Send(ctx, contents...)
+	if err != nil {
+		return nil, err
+	}
+	text, calls := responseText(response)
+	if calls {
+		return response, nil
+	}
+	rejection := c.client.validator(text)
+	if rejection != nil && c.client.regenerate {
+		// The rejected response is in the history, so rather than send the contents
+		// again, the model is told why it was rejected and asked for another.
+		retry := fmt.Sprintf("Your previous response was rejected: %v. Respond again, without this problem.", rejection)
+		if response, err = c.Chat.Send(ctx, retry); err != nil {
+			return nil, err
+		}
+		if text, calls = responseText(response); calls {
+			return response, nil
+		}
+		rejection = c.client.validator(text)
+	}
+	if rejection != nil {
+		return nil, rejected(rejection)
+	}
+	return response, nil
+}
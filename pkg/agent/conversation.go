@@ -40,6 +40,19 @@ import (
 //go:embed systemprompt_template_default.txt
 var defaultSystemPromptTemplate string
 
+// chatRetryConfig is how the agent retries its chat turns. Retries only replay the model
+// request; tools are dispatched separately in DispatchToolCalls and are never re-executed
+// by a retry.
+var chatRetryConfig = gollm.RetryConfig{
+	MaxAttempts:         3,
+	InitialBackoff:      10 * time.Second,
+	MaxBackoff:          60 * time.Second,
+	BackoffFactor:       2,
+	Jitter:              true,
+	RetryIdempotentOnly: true,
+	RetryStreamOpen:     true,
+}
+
 type Agent struct {
 	// Input is the channel to receive user input.
 	Input chan any
@@ -230,17 +243,8 @@ func (s *Agent) Init(ctx context.Context) error {
 		return fmt.Errorf("generating system prompt: %w", err)
 	}
 
-	// Start a new chat session
-	s.llmChat = gollm.NewRetryChat(
-		s.LLM.StartChat(systemPrompt, s.Model),
-		gollm.RetryConfig{
-			MaxAttempts:    3,
-			InitialBackoff: 10 * time.Second,
-			MaxBackoff:     60 * time.Second,
-			BackoffFactor:  2,
-			Jitter:         true,
-		},
-	)
+	// Start a new chat session.
+	s.llmChat = gollm.NewRetryChat(s.LLM.StartChat(systemPrompt, s.Model), chatRetryConfig)
 	err = s.llmChat.Initialize(s.session.ChatMessageStore.ChatMessages())
 	if err != nil {
 		return fmt.Errorf("initializing chat session: %w", err)
@@ -499,6 +503,10 @@ func (c *Agent) Run(ctx context.Context, initialQuery string) error {
 				// accumulator for streamed text
 				var streamedText string
 				var llmError error
+				// refused is set if the model declined the request, which retrying will not change
+				var refused bool
+				// requiresToolCall is set if the model asked for function calls, rather than giving a final answer
+				var requiresToolCall bool
 
 				for response, err := range stream {
 					if err != nil {
@@ -523,6 +531,8 @@ func (c *Agent) Run(ctx context.Context, initialQuery string) error {
 					}
 
 					candidate := response.Candidates()[0]
+					refused = refused || candidate.IsRefusal()
+					requiresToolCall = requiresToolCall || response.RequiresToolCall()
 
 					for _, part := range candidate.Parts() {
 						// Check if it's a text response
@@ -550,8 +560,12 @@ func (c *Agent) Run(ctx context.Context, initialQuery string) error {
 				if streamedText != "" {
 					c.addMessage(api.MessageSourceModel, api.MessageTypeText, streamedText)
 				}
+				if refused {
+					log.Info("LLM refused the request or its response was filtered")
+					c.addMessage(api.MessageSourceAgent, api.MessageTypeError, "The model declined to respond to this request, or its response was filtered.")
+				}
 				// If no function calls to be made, we're done
-				if len(functionCalls) == 0 {
+				if !requiresToolCall {
 					log.Info("No function calls to be made, so most likely the task is completed, so we're done.")
 					c.setAgentState(api.AgentStateDone)
 					c.currChatContent = []any{}
@@ -600,9 +614,10 @@ func (c *Agent) Run(ctx context.Context, initialQuery string) error {
 						// For models with tool-use support (shim disabled), use proper FunctionCallResult
 						// Note: This assumes the model supports sending FunctionCallResult
 						c.currChatContent = append(c.currChatContent, gollm.FunctionCallResult{
-							ID:     toolCallAnalysisResults[interactiveToolCallIndex].FunctionCall.ID,
-							Name:   toolCallAnalysisResults[interactiveToolCallIndex].FunctionCall.Name,
-							Result: map[string]any{"error": toolCallAnalysisResults[interactiveToolCallIndex].IsInteractiveError.Error()},
+							ID:      toolCallAnalysisResults[interactiveToolCallIndex].FunctionCall.ID,
+							Name:    toolCallAnalysisResults[interactiveToolCallIndex].FunctionCall.Name,
+							Result:  map[string]any{"error": toolCallAnalysisResults[interactiveToolCallIndex].IsInteractiveError.Error()},
+							IsError: true,
 						})
 					}
 					c.pendingFunctionCalls = []ToolCallAnalysis{} // reset pending function calls
@@ -867,6 +882,7 @@ func (c *Agent) handleChoice(ctx context.Context, choice *api.UserChoiceResponse
 				"status":    "declined",
 				"retryable": false,
 			},
+			IsError: true,
 		})
 		c.pendingFunctionCalls = []ToolCallAnalysis{}
 		dispatchToolCalls = false
@@ -1002,6 +1018,7 @@ func toMap(v any) (map[string]any, error) {
 func candidateToShimCandidate(iterator gollm.ChatResponseIterator) (gollm.ChatResponseIterator, error) {
 	return func(yield func(gollm.ChatResponse, error) bool) {
 		buffer := ""
+		finishReason := gollm.FinishReasonUnspecified
 		for response, err := range iterator {
 			if err != nil {
 				yield(nil, err)
@@ -1014,6 +1031,9 @@ func candidateToShimCandidate(iterator gollm.ChatResponseIterator) (gollm.ChatRe
 			}
 
 			candidate := response.Candidates()[0]
+			if reason := candidate.FinishReason(); reason != gollm.FinishReasonUnspecified {
+				finishReason = reason
+			}
 
 			for _, part := range candidate.Parts() {
 				if text, ok := part.AsText(); ok {
@@ -1026,6 +1046,12 @@ func candidateToShimCandidate(iterator gollm.ChatResponseIterator) (gollm.ChatRe
 			}
 		}
 
+		// A refusal is not in the ReAct format; pass it on as the answer
+		if finishReason == gollm.FinishReasonContentFiltered {
+			yield(&ShimResponse{candidate: &ReActResponse{Answer: buffer}, finishReason: finishReason}, nil)
+			return
+		}
+
 		if buffer == "" {
 			yield(nil, nil)
 			return
@@ -1037,12 +1063,13 @@ func candidateToShimCandidate(iterator gollm.ChatResponseIterator) (gollm.ChatRe
 			return
 		}
 		buffer = "" // TODO: any trailing text?
-		yield(&ShimResponse{candidate: parsedReActResp}, nil)
+		yield(&ShimResponse{candidate: parsedReActResp, finishReason: finishReason}, nil)
 	}, nil
 }
 
 type ShimResponse struct {
-	candidate *ReActResponse
+	candidate    *ReActResponse
+	finishReason gollm.FinishReason
 }
 
 func (r *ShimResponse) UsageMetadata() any {
@@ -1050,17 +1077,30 @@ func (r *ShimResponse) UsageMetadata() any {
 }
 
 func (r *ShimResponse) Candidates() []gollm.Candidate {
-	return []gollm.Candidate{&ShimCandidate{candidate: r.candidate}}
+	return []gollm.Candidate{&ShimCandidate{candidate: r.candidate, finishReason: r.finishReason}}
+}
+
+func (r *ShimResponse) RequiresToolCall() bool {
+	return r.candidate.Action != nil
 }
 
 type ShimCandidate struct {
-	candidate *ReActResponse
+	candidate    *ReActResponse
+	finishReason gollm.FinishReason
 }
 
 func (c *ShimCandidate) String() string {
 	return fmt.Sprintf("Thought: %s\nAnswer: %s\nAction: %s", c.candidate.Thought, c.candidate.Answer, c.candidate.Action)
 }
 
+func (c *ShimCandidate) FinishReason() gollm.FinishReason {
+	return c.finishReason
+}
+
+func (c *ShimCandidate) IsRefusal() bool {
+	return c.finishReason == gollm.FinishReasonContentFiltered
+}
+
 func (c *ShimCandidate) Parts() []gollm.Part {
 	var parts []gollm.Part
 	if c.candidate.Thought != "" {
@@ -1075,6 +1115,10 @@ func (c *ShimCandidate) Parts() []gollm.Part {
 	return parts
 }
 
+func (c *ShimCandidate) HasToolCalls() bool {
+	return c.candidate.Action != nil
+}
+
 type ShimPart struct {
 	text   string
 	action *Action
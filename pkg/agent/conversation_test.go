@@ -0,0 +1,205 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package agent
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/gollm"
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/api"
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/sessions"
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/tools"
+)
+
+// countingTool is a tool that counts how many times it has been run.
+type countingTool struct {
+	runs atomic.Int32
+}
+
+func (t *countingTool) Name() string        { return "count_runs" }
+func (t *countingTool) Description() string { return "Counts how many times it has been run." }
+
+func (t *countingTool) FunctionDefinition() *gollm.FunctionDefinition {
+	return &gollm.FunctionDefinition{
+		Name:        t.Name(),
+		Description: t.Description(),
+		Parameters:  &gollm.Schema{Type: gollm.TypeObject, Properties: map[string]*gollm.Schema{}},
+	}
+}
+
+func (t *countingTool) Run(ctx context.Context, args map[string]any) (any, error) {
+	return map[string]any{"runs": t.runs.Add(1)}, nil
+}
+
+func (t *countingTool) IsInteractive(args map[string]any) (bool, error) { return false, nil }
+
+func (t *countingTool) CheckModifiesResource(args map[string]any) string { return "no" }
+
+// scriptedResponse is a single-candidate response with the given text or function calls.
+type scriptedResponse struct {
+	text  string
+	calls []gollm.FunctionCall
+}
+
+func (r *scriptedResponse) UsageMetadata() any               { return nil }
+func (r *scriptedResponse) Candidates() []gollm.Candidate    { return []gollm.Candidate{r} }
+func (r *scriptedResponse) String() string                   { return r.text }
+func (r *scriptedResponse) FinishReason() gollm.FinishReason { return gollm.FinishReasonStop }
+func (r *scriptedResponse) IsRefusal() bool                  { return false }
+func (r *scriptedResponse) HasToolCalls() bool               { return len(r.calls) > 0 }
+func (r *scriptedResponse) RequiresToolCall() bool           { return len(r.calls) > 0 }
+
+func (r *scriptedResponse) Parts() []gollm.Part {
+	if len(r.calls) > 0 {
+		return []gollm.Part{&scriptedPart{calls: r.calls}}
+	}
+	return []gollm.Part{&scriptedPart{text: r.text}}
+}
+
+type scriptedPart struct {
+	text  string
+	calls []gollm.FunctionCall
+}
+
+func (p *scriptedPart) AsText() (string, bool) { return p.text, p.text != "" }
+
+func (p *scriptedPart) AsFunctionCalls() ([]gollm.FunctionCall, bool) {
+	return p.calls, len(p.calls) > 0
+}
+
+// scriptedStep is the outcome of a streaming request to a scriptedChat.
+type scriptedStep struct {
+	response *scriptedResponse
+	err      error
+}
+
+// scriptedChat is a Chat whose streaming requests have the outcomes of its steps, in order.
+// It records the contents of every request.
+type scriptedChat struct {
+	mu       sync.Mutex
+	steps    []scriptedStep
+	requests [][]any
+}
+
+func (c *scriptedChat) Send(ctx context.Context, contents ...any) (gollm.ChatResponse, error) {
+	panic("the agent streams its requests")
+}
+
+func (c *scriptedChat) SendStreaming(ctx context.Context, contents ...any) (gollm.ChatResponseIterator, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.requests = append(c.requests, contents)
+	step := c.steps[0]
+	c.steps = c.steps[1:]
+	if step.err != nil {
+		return nil, step.err
+	}
+	return func(yield func(gollm.ChatResponse, error) bool) {
+		yield(step.response, nil)
+	}, nil
+}
+
+func (c *scriptedChat) SetFunctionDefinitions(functionDefinitions []*gollm.FunctionDefinition) error {
+	return nil
+}
+
+func (c *scriptedChat) IsRetryableError(err error) bool { return gollm.DefaultIsRetryableError(err) }
+func (c *scriptedChat) Initialize(messages []*api.Message) error {
+	return nil
+}
+func (c *scriptedChat) Validate() error { return nil }
+
+// scriptedClient is a Client whose chats are the given scriptedChat.
+type scriptedClient struct {
+	gollm.Client
+
+	chat *scriptedChat
+}
+
+func (c *scriptedClient) StartChat(systemPrompt, model string) gollm.Chat {
+	return c.chat
+}
+
+func TestAgentRetryDoesNotRerunTools(t *testing.T) {
+	retryConfig := chatRetryConfig
+	chatRetryConfig.InitialBackoff = time.Millisecond
+	chatRetryConfig.Jitter = false
+	t.Cleanup(func() { chatRetryConfig = retryConfig })
+
+	tool := &countingTool{}
+	tools.RegisterTool(tool)
+
+	// The model asks for the tool, then fails once with a retryable error
+	// when it is sent the tool's result, then answers.
+	chat := &scriptedChat{steps: []scriptedStep{
+		{response: &scriptedResponse{calls: []gollm.FunctionCall{{ID: "call-1", Name: tool.Name(), Arguments: map[string]any{}}}}},
+		{err: &gollm.APIError{StatusCode: http.StatusServiceUnavailable, Message: "unavailable"}},
+		{response: &scriptedResponse{text: "done"}},
+	}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	a := &Agent{
+		LLM:              &scriptedClient{chat: chat},
+		Tools:            tools.Default(),
+		RunOnce:          true,
+		InitialQuery:     "count",
+		MaxIterations:    5,
+		SkipPermissions:  true,
+		RemoveWorkDir:    true,
+		ChatMessageStore: sessions.NewInMemoryChatStore(),
+	}
+	if err := a.Init(ctx); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	defer a.Close()
+	go func() {
+		for range a.Output {
+		}
+	}()
+	if err := a.Run(ctx, a.InitialQuery); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	deadline := time.Now().Add(10 * time.Second)
+	for a.AgentState() != api.AgentStateExited {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for the agent to finish, in state %s", a.AgentState())
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	if got := tool.runs.Load(); got != 1 {
+		t.Errorf("expected the tool to run exactly once, ran %d times", got)
+	}
+	chat.mu.Lock()
+	defer chat.mu.Unlock()
+	if len(chat.requests) != 3 {
+		t.Fatalf("expected 3 model requests, got %d", len(chat.requests))
+	}
+	for i, request := range chat.requests[1:] {
+		if len(request) != 1 {
+			t.Fatalf("retry %d: expected the tool result alone, got %v", i, request)
+		}
+		if result, ok := request[0].(gollm.FunctionCallResult); !ok || result.ID != "call-1" {
+			t.Errorf("retry %d: expected the result of call-1, got %v", i, request[0])
+		}
+	}
+}